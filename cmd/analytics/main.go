@@ -4,12 +4,17 @@ package main
 import (
 	"context"
 	"log"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"url-shorterner/internal/cache"
 	"url-shorterner/internal/config"
+	"url-shorterner/internal/geoip"
+	"url-shorterner/internal/iprange"
+	"url-shorterner/internal/referrer"
 	"url-shorterner/internal/storage"
 	analyticsApp "url-shorterner/svc/analytics/app"
 	analyticsStore "url-shorterner/svc/analytics/store"
@@ -21,7 +26,8 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	writerPool, err := storage.NewDBPool(ctx, cfg.DatabaseURL)
 	if err != nil {
@@ -37,7 +43,65 @@ func main() {
 
 	analyticsRepo := analyticsStore.NewRepository(writerPool)
 	analyticsDAO := analyticsStore.NewDAO(readerPool)
-	_ = analyticsApp.NewService(analyticsRepo, analyticsDAO)
+	// "analytics anonymize-ips" rewrites the addresses stored before a switch to a more
+	// private ANALYTICS_IP_MODE, then exits.
+	if len(os.Args) > 1 && os.Args[1] == "anonymize-ips" {
+		count, err := analyticsApp.AnonymizeStoredIPs(ctx, analyticsRepo, analyticsApp.IPMode(cfg.AnalyticsIPMode))
+		if err != nil {
+			log.Fatalf("Failed to anonymize stored IP addresses: %v", err) //nolint:gocritic // exitAfterDefer: intentional exit on fatal error
+		}
+		log.Printf("Anonymized %d stored IP addresses (%s mode)", count, cfg.AnalyticsIPMode)
+		return
+	}
+	var geo *geoip.DB
+	if cfg.GeoIPFile != "" {
+		geo = geoip.NewDB(cfg.GeoIPFile, cfg.GeoIPASNFile)
+		if err := geo.Reload(); err != nil {
+			log.Fatalf("Failed to load GeoIP database: %v", err) //nolint:gocritic // exitAfterDefer: intentional exit on fatal error
+		}
+		geo.Start(ctx, cfg.GeoIPReload)
+	}
+	// Referrers from the service's own short links are internal traffic.
+	var internalHosts []string
+	if domain, err := url.Parse(cfg.Domain); err == nil {
+		internalHosts = append(internalHosts, domain.Hostname())
+	}
+	referrers := referrer.NewClassifier(cfg.ReferrerRulesFile, internalHosts...)
+	if err := referrers.Reload(); err != nil {
+		log.Fatalf("Failed to load referrer rules: %v", err) //nolint:gocritic // exitAfterDefer: intentional exit on fatal error
+	}
+	referrers.Start(ctx, cfg.ReferrerRulesReload)
+	// Redis backs the live click feeds, visitor sketches and burst counters, which are shared
+	// by all instances.
+	redisCache, err := cache.NewCache(cfg.RedisAddr, cfg.RedisPassword)
+	if err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err) //nolint:gocritic // exitAfterDefer: intentional exit on fatal error
+	}
+	var visitorCache *cache.VisitorCache
+	if cfg.AnalyticsVisitorDays > 0 {
+		visitorCache = cache.NewVisitorCache(redisCache, time.Duration(cfg.AnalyticsVisitorDays)*24*time.Hour)
+	}
+	bots := analyticsApp.BotOptions{
+		BurstClicks: cfg.BotBurstClicks,
+		BurstWindow: cfg.BotBurstWindow,
+	}
+	if cfg.BotBurstClicks > 0 {
+		bots.Bursts = cache.NewBurstCache(redisCache)
+	}
+	if cfg.BotIPRangesFile != "" {
+		bots.Crawlers = iprange.NewList(cfg.BotIPRangesFile)
+		if err := bots.Crawlers.Reload(); err != nil {
+			log.Fatalf("Failed to load crawler IP ranges: %v", err) //nolint:gocritic // exitAfterDefer: intentional exit on fatal error
+		}
+		bots.Crawlers.Start(ctx, cfg.BotIPRangesReload)
+	}
+	_ = analyticsApp.NewService(
+		analyticsRepo, analyticsDAO, geo, referrers, analyticsApp.IPMode(cfg.AnalyticsIPMode), visitorCache, bots,
+		analyticsApp.LiveOptions{Feed: cache.NewLiveCache(redisCache)},
+	)
+
+	rollupJob := analyticsApp.NewRollupJob(analyticsRepo, cfg.AnalyticsRollupGrace)
+	rollupJob.Start(ctx, cfg.AnalyticsRollupInterval)
 
 	log.Println("Analytics service starting (event-driven mode)...")
 
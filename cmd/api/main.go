@@ -5,22 +5,35 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"url-shorterner/internal/access"
+	"url-shorterner/internal/apikey"
+	"url-shorterner/internal/blocklist"
 	"url-shorterner/internal/cache"
+	"url-shorterner/internal/clientip"
 	"url-shorterner/internal/config"
 	"url-shorterner/internal/events"
+	internalHTTP "url-shorterner/internal/http"
 	"url-shorterner/internal/middleware"
 	"url-shorterner/internal/rate"
 	"url-shorterner/internal/storage"
+	"url-shorterner/internal/wordlist"
 	analyticsApp "url-shorterner/svc/analytics/app"
 	analyticsStore "url-shorterner/svc/analytics/store"
 	analyticsTransport "url-shorterner/svc/api/analytics/transport"
+	campaignsTransport "url-shorterner/svc/api/campaigns/transport"
+	jobsTransport "url-shorterner/svc/api/jobs/transport"
 	shortenerTransport "url-shorterner/svc/api/shortener/transport"
+	campaignsApp "url-shorterner/svc/campaigns/app"
+	campaignsStore "url-shorterner/svc/campaigns/store"
+	jobsApp "url-shorterner/svc/jobs/app"
+	jobsStore "url-shorterner/svc/jobs/store"
 	shortenerApp "url-shorterner/svc/shortener/app"
 	shortenerStore "url-shorterner/svc/shortener/store"
 
@@ -35,10 +48,12 @@ func main() {
 	}
 
 	ctx := context.Background()
+	backgroundCtx, stopBackground := context.WithCancel(ctx)
+	defer stopBackground()
 
 	writerPool, err := storage.NewDBPool(ctx, cfg.DatabaseURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to writer database: %v", err)
+		log.Fatalf("Failed to connect to writer database: %v", err) //nolint:gocritic // exitAfterDefer: intentional exit on fatal error
 	}
 	defer writerPool.Close()
 
@@ -61,31 +76,145 @@ func main() {
 	var eventPublisher events.Publisher
 	// TODO: Initialize event publisher implementation when available
 
+	urlBlocklist := blocklist.NewList(cfg.BlocklistFiles)
+	if err := urlBlocklist.Reload(); err != nil {
+		log.Fatalf("Failed to load blocklist: %v", err)
+	}
+	urlBlocklist.Start(backgroundCtx, cfg.BlocklistReload)
+
+	var aliasWords shortenerApp.WordList
+	if cfg.AliasWordListFile != "" {
+		words, err := wordlist.LoadFile(cfg.AliasWordListFile)
+		if err != nil {
+			log.Fatalf("Failed to load alias word list: %v", err)
+		}
+		aliasWords = words
+	}
+	aliasValidator, err := shortenerApp.NewAliasValidator(shortenerApp.AliasPolicy{
+		MinLength: cfg.AliasMinLength,
+		MaxLength: cfg.AliasMaxLength,
+		Charset:   cfg.AliasCharset,
+	}, aliasWords)
+	if err != nil {
+		log.Fatalf("Invalid alias configuration: %v", err)
+	}
+
+	codeGenerator, err := shortenerApp.NewCodeGenerator(shortenerApp.CodeGeneratorConfig{
+		Strategy:   cfg.CodeStrategy,
+		Length:     cfg.ShortCodeLength,
+		RangeSize:  int64(cfg.CodeRangeSize),
+		NodeID:     int64(cfg.SnowflakeNodeID),
+		HashKey:    cfg.CodeHashKey,
+		CounterKey: cfg.CodeCounterKey,
+	}, shortenerRepo)
+	if err != nil {
+		log.Fatalf("Invalid code generation configuration: %v", err)
+	}
+
+	urlNormalizer := shortenerApp.NewURLNormalizer(shortenerApp.NormalizationPolicy{
+		StripTrackingParams: cfg.URLStripTrackingParams,
+		TrackingParams:      cfg.URLTrackingParams,
+		SortQuery:           cfg.URLSortQuery,
+	})
 	shortenerService := shortenerApp.NewService(
 		shortenerRepo,
 		shortenerDAO,
 		urlCache,
 		cfg.BloomN,
 		cfg.BloomP,
-		cfg.ShortCodeLength,
+		codeGenerator,
 		cfg.Domain,
 		eventPublisher,
+		shortenerApp.NewURLValidator(cfg.Domain, shortenerApp.URLPolicy{
+			BlockPrivateAddresses: cfg.URLBlockPrivateAddresses,
+			BlockNonDefaultPorts:  cfg.URLBlockNonDefaultPorts,
+			ResolveHosts:          cfg.URLResolveHosts,
+		}, urlBlocklist),
+		aliasValidator,
+		urlNormalizer,
+		shortenerApp.BatchOptions{
+			MaxSize:     cfg.BatchMaxSize,
+			Concurrency: cfg.BatchConcurrency,
+		},
 	)
 
+	blocklistScanner := shortenerApp.NewBlocklistScanner(shortenerRepo, shortenerDAO, urlCache, urlBlocklist, urlNormalizer)
+	blocklistScanner.Start(backgroundCtx, cfg.BlocklistRescan)
+
+	jobsRepo := jobsStore.NewRepository(writerPool)
+	jobWorkers := jobsApp.NewWorkerPool(jobsRepo, shortenerService, jobsApp.WorkerOptions{
+		Workers:      cfg.JobWorkers,
+		PollInterval: cfg.JobPollInterval,
+		Lease:        cfg.JobLease,
+		MaxAttempts:  cfg.JobMaxAttempts,
+		Retention:    cfg.JobRetention,
+		BatchSize:    cfg.BatchMaxSize,
+	})
+	jobWorkers.Start(backgroundCtx)
+	jobsService := jobsApp.NewService(jobsRepo, jobsStore.NewDAO(readerPool), jobWorkers)
+
 	analyticsRepo := analyticsStore.NewRepository(writerPool)
 	analyticsDAO := analyticsStore.NewDAO(readerPool)
-	analyticsService := analyticsApp.NewService(analyticsRepo, analyticsDAO)
+	var visitorCache *cache.VisitorCache
+	if cfg.AnalyticsVisitorDays > 0 {
+		visitorCache = cache.NewVisitorCache(redisCache, time.Duration(cfg.AnalyticsVisitorDays)*24*time.Hour)
+	}
+	// The API only reads analytics; clicks are enriched where they are recorded and reach the
+	// live feeds of every replica through Redis.
+	liveFeed := cache.NewLiveCache(redisCache)
+	analyticsService := analyticsApp.NewService(
+		analyticsRepo, analyticsDAO, nil, nil, analyticsApp.IPMode(cfg.AnalyticsIPMode), visitorCache,
+		analyticsApp.BotOptions{},
+		analyticsApp.LiveOptions{Feed: liveFeed, SummaryInterval: cfg.AnalyticsLiveSummary},
+	)
+
+	campaignsService := campaignsApp.NewService(
+		campaignsStore.NewRepository(writerPool),
+		campaignsStore.NewDAO(readerPool),
+		analyticsService,
+	)
 
 	limiter := rate.NewLimiter(rateLimitCache, cfg.RateLimitMax, cfg.RateLimitWindow)
 
+	acl := access.NewList(
+		access.NewStaticSource(cfg.IPAllowlist, cfg.IPDenylist),
+		access.NewDBSource(readerPool),
+	)
+	if err := acl.Refresh(ctx); err != nil {
+		log.Fatalf("Failed to load IP access rules: %v", err)
+	}
+	acl.Start(backgroundCtx, cfg.IPAccessRefresh)
+
+	resolver, err := clientip.NewResolver(cfg.TrustedProxies, cfg.ClientIPHeaders)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	apiKeys, err := apikey.Parse(cfg.APIKeys)
+	if err != nil {
+		log.Fatalf("Invalid API_KEYS: %v", err)
+	}
+	if apiKeys.Len() == 0 {
+		log.Printf("No API_KEYS configured; link updates and rollbacks are disabled")
+	}
+
 	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	router.Use(middleware.Recovery())
+	router.Use(middleware.ResolveClientIP(resolver))
 
-	shortenerTransport.SetupRouter(router, shortenerService, limiter)
-	analyticsTransport.SetupRouter(router, analyticsService, limiter)
+	shortenerTransport.SetupRouter(router, shortenerService, limiter, acl, apiKeys)
+	analyticsTransport.SetupRouter(router, analyticsService, limiter, acl, apiKeys)
+	jobsTransport.SetupRouter(router, jobsService, cfg.JobMaxUploadBytes, limiter, acl, apiKeys)
+	campaignsTransport.SetupRouter(router, campaignsService, limiter, acl)
 
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
+	// Custom aliases must never shadow a registered route.
+	aliasValidator.Reserve(internalHTTP.RouteSegments(router)...)
+
 	if os.Getenv("GIN_MODE") == "" {
 		gin.SetMode(gin.ReleaseMode)
 	} else {
@@ -99,10 +228,20 @@ func main() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	// Live click streams never finish on their own; end them so shutdown does not wait for them.
+	server.RegisterOnShutdown(liveFeed.Close)
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on port %d: %v", cfg.Port, err)
+	}
+	if cfg.ProxyProtocol {
+		listener = clientip.NewProxyProtocolListener(listener, resolver)
+	}
 
 	go func() {
 		log.Printf("API server starting on port %d", cfg.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed: %v", err)
 		}
 	}()
@@ -120,5 +259,8 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Running jobs are put back in the queue for another replica or the next start.
+	jobWorkers.Stop()
+
 	log.Println("API server exited")
 }
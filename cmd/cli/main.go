@@ -0,0 +1,265 @@
+// Package main provides a command-line client for bulk operations against the API server.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"url-shorterner/internal/bulk"
+)
+
+const usage = `Usage: cli <command> [flags]
+
+Commands:
+  import   Create links from a CSV or JSONL file and write the error report
+  export   Download all links as CSV or JSONL
+  clicks   Download raw click records as CSV, JSONL or Parquet
+
+Run "cli <command> -h" for command flags.
+`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = runImport(ctx, os.Args[2:])
+	case "export":
+		err = runExport(ctx, os.Args[2:])
+	case "clicks":
+		err = runClicks(ctx, os.Args[2:])
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2) //nolint:gocritic // exitAfterDefer: stop only cancels the signal context
+	}
+	if err != nil {
+		log.Fatalf("%s: %v", os.Args[1], err)
+	}
+}
+
+func runImport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	server := flags.String("server", defaultServer(), "API server base URL")
+	formatName := flags.String("format", "", "file format: csv or jsonl (default: from the file extension)")
+	reportPath := flags.String("report", "-", "where to write the report (- for stdout)")
+	all := flags.Bool("all", false, "report every record, not only failures")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: cli import [flags] FILE (- for stdin)")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	path := flags.Arg(0)
+	format, err := fileFormat(*formatName, path, bulk.ParseFormat)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path) //nolint:gosec // G304: The user chooses which file to import
+		if err != nil {
+			return err
+		}
+		defer file.Close() //nolint:errcheck // Read-only file
+		body = file
+	}
+
+	query := url.Values{"format": {string(format)}}
+	if *all {
+		query.Set("report", "all")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint(*server, "/links/import", query), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", format.ContentType())
+
+	resp, err := send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck // Response body is fully read below
+
+	if err := copyTo(*reportPath, resp.Body); err != nil {
+		return err
+	}
+	// Trailers are only available once the body has been read.
+	if msg := resp.Trailer.Get("X-Import-Error"); msg != "" {
+		return fmt.Errorf("import aborted: %s", msg)
+	}
+	log.Printf("Imported %s records: %s created, %s failed",
+		resp.Trailer.Get("X-Import-Total"), resp.Trailer.Get("X-Import-Created"), resp.Trailer.Get("X-Import-Failed"))
+	return nil
+}
+
+func runExport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	server := flags.String("server", defaultServer(), "API server base URL")
+	formatName := flags.String("format", "", "file format: csv or jsonl (default: from -o, else csv)")
+	outPath := flags.String("o", "-", "output file (- for stdout)")
+	stats := flags.Bool("stats", false, "include aggregate click stats")
+	key := flags.String("key", os.Getenv("SHORTENER_API_KEY"), "API key (default: $SHORTENER_API_KEY)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := fileFormat(*formatName, *outPath, bulk.ParseFormat)
+	if err != nil {
+		return err
+	}
+	query := url.Values{"format": {string(format)}}
+	if *stats {
+		query.Set("stats", "true")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint(*server, "/links/export", query), http.NoBody)
+	if err != nil {
+		return err
+	}
+	if *key != "" {
+		req.Header.Set("Authorization", "Bearer "+*key)
+	}
+	resp, err := send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck // Response body is fully read below
+
+	if err := copyTo(*outPath, resp.Body); err != nil {
+		return err
+	}
+	if msg := resp.Trailer.Get("X-Export-Error"); msg != "" {
+		return fmt.Errorf("export incomplete: %s", msg)
+	}
+	return nil
+}
+
+func runClicks(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("clicks", flag.ExitOnError)
+	server := flags.String("server", defaultServer(), "API server base URL")
+	formatName := flags.String("format", "", "file format: csv, jsonl or parquet (default: from -o, else csv)")
+	outPath := flags.String("o", "-", "output file (- for stdout)")
+	code := flags.String("code", "", "only export the clicks of this short code")
+	from := flags.String("from", "", "start of the range, RFC 3339 or YYYY-MM-DD in UTC (inclusive)")
+	to := flags.String("to", "", "end of the range, RFC 3339 or YYYY-MM-DD in UTC (exclusive)")
+	fields := flags.String("fields", "", "comma-separated columns in output order (default: all)")
+	key := flags.String("key", os.Getenv("SHORTENER_API_KEY"), "API key (default: $SHORTENER_API_KEY)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := fileFormat(*formatName, *outPath, bulk.ParseExportFormat)
+	if err != nil {
+		return err
+	}
+	query := url.Values{"format": {string(format)}}
+	for name, value := range map[string]string{"code": *code, "from": *from, "to": *to, "fields": *fields} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint(*server, "/analytics/export", query), http.NoBody)
+	if err != nil {
+		return err
+	}
+	if *key != "" {
+		req.Header.Set("Authorization", "Bearer "+*key)
+	}
+	resp, err := send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck // Response body is fully read below
+
+	if err := copyTo(*outPath, resp.Body); err != nil {
+		return err
+	}
+	if msg := resp.Trailer.Get("X-Export-Error"); msg != "" {
+		return fmt.Errorf("export incomplete: %s", msg)
+	}
+	return nil
+}
+
+func defaultServer() string {
+	if server := os.Getenv("SHORTENER_URL"); server != "" {
+		return server
+	}
+	return "http://localhost:8080"
+}
+
+func endpoint(server, path string, query url.Values) string {
+	return strings.TrimSuffix(server, "/") + path + "?" + query.Encode()
+}
+
+// fileFormat returns the explicit format, or infers it from the file extension (csv by default),
+// accepting the formats of parse.
+func fileFormat(name, path string, parse func(string) (bulk.Format, error)) (bulk.Format, error) {
+	if name != "" {
+		return parse(name)
+	}
+	if ext := strings.TrimPrefix(filepath.Ext(path), "."); ext != "" {
+		if format, err := parse(ext); err == nil {
+			return format, nil
+		}
+	}
+	return bulk.FormatCSV, nil
+}
+
+// send performs req and turns non-2xx responses into errors carrying the API error message.
+func send(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()                               //nolint:errcheck // Error body is read once
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096)) //nolint:errcheck // Best-effort error details
+	return nil, fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+func copyTo(path string, r io.Reader) error {
+	if path == "-" {
+		_, err := io.Copy(os.Stdout, r)
+		return err
+	}
+	file, err := os.Create(path) //nolint:gosec // G304: The user chooses the output file
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close() //nolint:errcheck,gosec // The copy error is more relevant
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,174 @@
+// Package access provides CIDR-aware IP allowlist and denylist matching.
+package access
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shorterner/internal/log"
+)
+
+// Action describes what a rule does with a matching address.
+type Action string
+
+const (
+	// ActionAllow exempts matching addresses from rate limiting and overrides deny rules.
+	ActionAllow Action = "allow"
+	// ActionDeny blocks matching addresses outright.
+	ActionDeny Action = "deny"
+)
+
+// Rule is a single allow or deny entry for an IP address or CIDR range.
+type Rule struct {
+	CIDR   string
+	Action Action
+}
+
+// Source provides access rules to a List.
+type Source interface {
+	Rules(ctx context.Context) ([]Rule, error)
+}
+
+// List holds the current allow and deny prefixes and refreshes them from its sources.
+// A nil *List allows everything and denies nothing.
+type List struct {
+	sources []Source
+
+	mu    sync.RWMutex
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// NewList creates a new access list backed by the given sources.
+// Call Refresh (or Start) to load rules before use.
+func NewList(sources ...Source) *List {
+	return &List{sources: sources}
+}
+
+// Refresh reloads rules from all sources and swaps them in atomically.
+// If any source fails, the previously loaded rules are kept.
+func (l *List) Refresh(ctx context.Context) error {
+	var allow, deny []netip.Prefix
+	for _, source := range l.sources {
+		rules, err := source.Rules(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load access rules: %w", err)
+		}
+		for _, rule := range rules {
+			prefix, err := ParsePrefix(rule.CIDR)
+			if err != nil {
+				log.Warn("Skipping invalid access rule %q: %v", rule.CIDR, err)
+				continue
+			}
+			switch rule.Action {
+			case ActionAllow:
+				allow = append(allow, prefix)
+			case ActionDeny:
+				deny = append(deny, prefix)
+			default:
+				log.Warn("Skipping access rule %q with unknown action %q", rule.CIDR, rule.Action)
+			}
+		}
+	}
+
+	l.mu.Lock()
+	l.allow = allow
+	l.deny = deny
+	l.mu.Unlock()
+	return nil
+}
+
+// Start refreshes the list every interval until ctx is canceled.
+// Refresh failures are logged and the last good rules stay in effect.
+func (l *List) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := l.Refresh(ctx); err != nil {
+					log.Error("Access list refresh failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// IsAllowed reports whether ip matches an allow rule.
+func (l *List) IsAllowed(ip string) bool {
+	if l == nil {
+		return false
+	}
+	addr, ok := parseAddr(ip)
+	if !ok {
+		return false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return containsAddr(l.allow, addr)
+}
+
+// IsDenied reports whether ip matches a deny rule and no allow rule.
+func (l *List) IsDenied(ip string) bool {
+	if l == nil {
+		return false
+	}
+	addr, ok := parseAddr(ip)
+	if !ok {
+		return false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return containsAddr(l.deny, addr) && !containsAddr(l.allow, addr)
+}
+
+// ParsePrefix parses a CIDR range or a bare IP address (treated as a single-host prefix).
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		if addr := prefix.Addr(); addr.Is4In6() && prefix.Bits() >= 96 {
+			return netip.PrefixFrom(addr.Unmap(), prefix.Bits()-96).Masked(), nil
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func parseAddr(ip string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package access
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type staticSource struct {
+	rules []Rule
+}
+
+// NewStaticSource creates a source from fixed allow and deny entries, typically loaded from config.
+func NewStaticSource(allow, deny []string) Source {
+	rules := make([]Rule, 0, len(allow)+len(deny))
+	for _, cidr := range allow {
+		rules = append(rules, Rule{CIDR: cidr, Action: ActionAllow})
+	}
+	for _, cidr := range deny {
+		rules = append(rules, Rule{CIDR: cidr, Action: ActionDeny})
+	}
+	return &staticSource{rules: rules}
+}
+
+func (s *staticSource) Rules(_ context.Context) ([]Rule, error) {
+	return s.rules, nil
+}
+
+type dbSource struct {
+	db *pgxpool.Pool
+}
+
+// NewDBSource creates a source that reads rules from the ip_access_rules table.
+func NewDBSource(db *pgxpool.Pool) Source {
+	return &dbSource{db: db}
+}
+
+func (s *dbSource) Rules(ctx context.Context) ([]Rule, error) {
+	query := `
+		SELECT cidr::text, action
+		FROM ip_access_rules
+		WHERE expires_at IS NULL OR expires_at > NOW()
+	`
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var rule Rule
+		var action string
+		if err := rows.Scan(&rule.CIDR, &action); err != nil {
+			return nil, err
+		}
+		rule.Action = Action(action)
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
@@ -0,0 +1,71 @@
+// Package apikey authenticates API clients by the keys they send in the Authorization header.
+package apikey
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKeyName is the Gin context key holding the name of the key a request authenticated with.
+const ContextKeyName = "api_key_name"
+
+// Keys maps API keys to the names of their holders. Keys are stored as SHA-256 digests, so a
+// lookup does not compare the secret byte by byte.
+type Keys struct {
+	names map[[sha256.Size]byte]string
+}
+
+// Parse parses "<name>:<key>" entries, e.g. "ci:3f9a...". The name identifies the holder in
+// link version histories; a key may only be listed once.
+func Parse(entries []string) (*Keys, error) {
+	keys := &Keys{names: make(map[[sha256.Size]byte]string, len(entries))}
+	for i, entry := range entries {
+		name, key, ok := strings.Cut(entry, ":")
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		if !ok || name == "" || key == "" {
+			return nil, fmt.Errorf("invalid API key entry %d: want <name>:<key>", i+1)
+		}
+		digest := sha256.Sum256([]byte(key))
+		if _, ok := keys.names[digest]; ok {
+			return nil, fmt.Errorf("API key of %q is listed twice", name)
+		}
+		keys.names[digest] = name
+	}
+	return keys, nil
+}
+
+// Len returns the number of keys.
+func (k *Keys) Len() int {
+	if k == nil {
+		return 0
+	}
+	return len(k.names)
+}
+
+// Lookup returns the name of key, if it is known.
+func (k *Keys) Lookup(key string) (string, bool) {
+	if k == nil || key == "" {
+		return "", false
+	}
+	name, ok := k.names[sha256.Sum256([]byte(key))]
+	return name, ok
+}
+
+// FromRequest returns the key sent as "Authorization: Bearer <key>", or empty without one.
+func FromRequest(r *http.Request) string {
+	scheme, key, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(key)
+}
+
+// FromContext returns the name of the key the request authenticated with, or empty when it
+// did not go through the RequireAPIKey middleware.
+func FromContext(c *gin.Context) string {
+	return c.GetString(ContextKeyName)
+}
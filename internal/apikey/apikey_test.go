@@ -0,0 +1,68 @@
+package apikey
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	keys, err := Parse([]string{"ci:secret-1", " ops : secret:2 "})
+	require.NoError(t, err)
+	assert.Equal(t, 2, keys.Len())
+
+	name, ok := keys.Lookup("secret-1")
+	assert.True(t, ok)
+	assert.Equal(t, "ci", name)
+	// Only the first colon separates the name.
+	name, ok = keys.Lookup("secret:2")
+	assert.True(t, ok)
+	assert.Equal(t, "ops", name)
+
+	_, ok = keys.Lookup("secret")
+	assert.False(t, ok)
+	_, ok = keys.Lookup("")
+	assert.False(t, ok)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, entries := range [][]string{
+		{"no-key"},
+		{":key"},
+		{"name:"},
+		{"a:same", "b:same"},
+	} {
+		_, err := Parse(entries)
+		assert.Error(t, err, entries)
+	}
+}
+
+func TestNilKeys(t *testing.T) {
+	var keys *Keys
+	assert.Zero(t, keys.Len())
+	_, ok := keys.Lookup("anything")
+	assert.False(t, ok)
+}
+
+func TestFromRequest(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "Bearer abc", want: "abc"},
+		{header: "bearer  abc ", want: "abc"},
+		{header: "Basic abc", want: ""},
+		{header: "abc", want: ""},
+		{header: "", want: ""},
+	}
+	for _, tt := range tests {
+		r, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		assert.Equal(t, tt.want, FromRequest(r), tt.header)
+	}
+}
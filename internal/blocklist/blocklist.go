@@ -0,0 +1,121 @@
+// Package blocklist provides domain and URL-pattern matching against locally stored abuse feeds.
+package blocklist
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"url-shorterner/internal/log"
+)
+
+// List is a hot-reloadable blocklist built from local feed files.
+// A nil *List blocks nothing.
+type List struct {
+	paths []string
+
+	mu       sync.RWMutex
+	matcher  *Matcher
+	modTimes map[string]time.Time
+}
+
+// NewList creates a blocklist backed by the given feed files.
+// Call Reload (or Start) to load the files before use.
+func NewList(paths []string) *List {
+	return &List{
+		paths:    paths,
+		matcher:  NewMatcher(),
+		modTimes: make(map[string]time.Time),
+	}
+}
+
+// Reload parses all feed files and atomically swaps in the new matcher.
+// If any file fails to load, the previous matcher is kept.
+func (l *List) Reload() error {
+	matcher := NewMatcher()
+	modTimes := make(map[string]time.Time, len(l.paths))
+	for _, path := range l.paths {
+		modTime, err := loadFile(matcher, path)
+		if err != nil {
+			return err
+		}
+		modTimes[path] = modTime
+	}
+
+	l.mu.Lock()
+	l.matcher = matcher
+	l.modTimes = modTimes
+	l.mu.Unlock()
+
+	log.Info("Blocklist loaded: %d entries from %d files", matcher.Len(), len(l.paths))
+	return nil
+}
+
+// Start checks the feed files every interval and reloads them when any has changed.
+func (l *List) Start(ctx context.Context, interval time.Duration) {
+	if l == nil || interval <= 0 || len(l.paths) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !l.changed() {
+					continue
+				}
+				if err := l.Reload(); err != nil {
+					log.Error("Blocklist reload failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Match reports whether rawURL is blocked and by which feed.
+func (l *List) Match(rawURL string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+
+	l.mu.RLock()
+	matcher := l.matcher
+	l.mu.RUnlock()
+	return matcher.Match(rawURL)
+}
+
+func (l *List) changed() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, path := range l.paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(l.modTimes[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadFile(matcher *Matcher, path string) (time.Time, error) {
+	file, err := os.Open(path) //nolint:gosec // G304: Blocklist paths come from trusted configuration
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to open blocklist %s: %w", path, err)
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+
+	info, err := file.Stat()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to stat blocklist %s: %w", path, err)
+	}
+	if err := matcher.Load(file, filepath.Base(path)); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse blocklist %s: %w", path, err)
+	}
+	return info.ModTime(), nil
+}
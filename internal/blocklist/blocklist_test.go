@@ -0,0 +1,59 @@
+package blocklist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNilList(t *testing.T) {
+	var l *List
+	_, blocked := l.Match("https://bad.example/")
+	assert.False(t, blocked)
+}
+
+func TestListReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "phishing.txt")
+	require.NoError(t, os.WriteFile(path, []byte("bad.example\n"), 0o600))
+
+	l := NewList([]string{path})
+	_, blocked := l.Match("https://bad.example/")
+	assert.False(t, blocked, "nothing is blocked before the first load")
+
+	require.NoError(t, l.Reload())
+	source, blocked := l.Match("https://www.bad.example/")
+	assert.True(t, blocked)
+	assert.Equal(t, "phishing.txt", source)
+	assert.False(t, l.changed())
+
+	require.NoError(t, os.WriteFile(path, []byte("worse.example\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	assert.True(t, l.changed())
+	require.NoError(t, l.Reload())
+	_, blocked = l.Match("https://bad.example/")
+	assert.False(t, blocked)
+	_, blocked = l.Match("https://worse.example/")
+	assert.True(t, blocked)
+}
+
+func TestListReloadKeepsPreviousOnError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "feed.txt")
+	require.NoError(t, os.WriteFile(path, []byte("bad.example\n"), 0o600))
+
+	l := NewList([]string{path, filepath.Join(dir, "missing.txt")})
+	require.Error(t, l.Reload())
+
+	l = NewList([]string{path})
+	require.NoError(t, l.Reload())
+	require.NoError(t, os.Remove(path))
+	assert.True(t, l.changed())
+	require.Error(t, l.Reload())
+	_, blocked := l.Match("https://bad.example/")
+	assert.True(t, blocked)
+}
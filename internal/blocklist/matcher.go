@@ -0,0 +1,161 @@
+package blocklist
+
+import (
+	"bufio"
+	"io"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// Matcher matches URLs against blocked domains and URL patterns.
+// Domain entries also block every subdomain; URL patterns are host-scoped prefixes
+// in which "*" matches any sequence of characters.
+type Matcher struct {
+	domains  map[string]string
+	patterns map[string][]pattern
+}
+
+type pattern struct {
+	expr   string
+	source string
+}
+
+// NewMatcher creates an empty matcher.
+func NewMatcher() *Matcher {
+	return &Matcher{
+		domains:  make(map[string]string),
+		patterns: make(map[string][]pattern),
+	}
+}
+
+// Len returns the number of loaded entries.
+func (m *Matcher) Len() int {
+	n := len(m.domains)
+	for _, p := range m.patterns {
+		n += len(p)
+	}
+	return n
+}
+
+// Load parses a feed and adds its entries, attributing them to source.
+// Supported line formats: hosts files ("0.0.0.0 bad.example"), plain domains,
+// adblock-style domains ("||bad.example^") and URL patterns ("bad.example/phish/*").
+// Blank lines and lines starting with "#" or "!" are ignored.
+func (m *Matcher) Load(r io.Reader, source string) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if _, err := netip.ParseAddr(fields[0]); err == nil && len(fields) > 1 {
+			// hosts format: the address is a sink, the remaining fields are domains
+			for _, domain := range fields[1:] {
+				m.addDomain(domain, source)
+			}
+			continue
+		}
+		m.addEntry(fields[0], source)
+	}
+	return scanner.Err()
+}
+
+func (m *Matcher) addEntry(entry, source string) {
+	entry = strings.TrimSuffix(strings.TrimPrefix(entry, "||"), "^")
+	if i := strings.Index(entry, "://"); i >= 0 {
+		entry = entry[i+3:]
+	}
+
+	host, rest, hasPath := strings.Cut(entry, "/")
+	if !hasPath || rest == "" || rest == "*" {
+		m.addDomain(host, source)
+		return
+	}
+
+	host = normalizeHost(host)
+	if host == "" {
+		return
+	}
+	m.patterns[host] = append(m.patterns[host], pattern{
+		expr:   host + "/" + rest,
+		source: source,
+	})
+}
+
+func (m *Matcher) addDomain(domain, source string) {
+	domain = normalizeHost(domain)
+	if domain == "" || domain == "localhost" || domain == "0.0.0.0" {
+		return
+	}
+	m.domains[domain] = source
+}
+
+// Match reports whether rawURL is blocked and by which source.
+func (m *Matcher) Match(rawURL string) (string, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	host := normalizeHost(parsed.Hostname())
+	if host == "" {
+		return "", false
+	}
+
+	// Walk up the domain tree so "a.b.bad.example" matches "bad.example".
+	for name := host; name != ""; {
+		if source, ok := m.domains[name]; ok {
+			return source, true
+		}
+		_, parent, found := strings.Cut(name, ".")
+		if !found {
+			break
+		}
+		name = parent
+	}
+
+	if patterns := m.patterns[host]; len(patterns) > 0 {
+		target := host + parsed.EscapedPath()
+		if parsed.RawQuery != "" {
+			target += "?" + parsed.RawQuery
+		}
+		for _, p := range patterns {
+			if globPrefix(p.expr, target) {
+				return p.source, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if h, _, found := strings.Cut(host, ":"); found && !strings.Contains(host, "]") {
+		host = h
+	}
+	return strings.TrimPrefix(host, "*.")
+}
+
+// globPrefix reports whether s starts with a string matching expr, where "*" matches any run of characters.
+func globPrefix(expr, s string) bool {
+	parts := strings.Split(expr, "*")
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return true
+}
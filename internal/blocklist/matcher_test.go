@@ -0,0 +1,102 @@
+package blocklist
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFeed = `# comment
+! adblock comment
+
+0.0.0.0 hosts.example other-hosts.example
+127.0.0.1 localhost
+plain.example # trailing comment
+||adblock.example^
+https://scheme.example/
+Wildcard.Example./*
+*.star.example
+paths.example/phish/*/login
+paths.example/exact
+`
+
+func TestMatcherLoad(t *testing.T) {
+	m := NewMatcher()
+	require.NoError(t, m.Load(strings.NewReader(testFeed), "feed.txt"))
+	// localhost is never blocked; the two path patterns count as entries.
+	assert.Equal(t, 9, m.Len())
+}
+
+func TestMatcherMatch(t *testing.T) {
+	m := NewMatcher()
+	require.NoError(t, m.Load(strings.NewReader(testFeed), "feed.txt"))
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://hosts.example/", true},
+		{"http://other-hosts.example/a?b=c", true},
+		{"https://plain.example", true},
+		{"https://sub.plain.example/x", true},
+		{"https://a.b.plain.example/x", true},
+		{"https://notplain.example/", false},
+		{"https://adblock.example/x", true},
+		{"https://scheme.example/anything", true},
+		{"https://wildcard.example/", true},
+		{"https://STAR.example/", true},
+		{"https://plain.example:8443/x", true},
+		{"https://plain.example./x", true},
+		{"https://localhost/", false},
+		{"https://paths.example/phish/abc/login", true},
+		{"https://paths.example/phish/abc/login?next=1", true},
+		{"https://paths.example/phish/abc/logout", false},
+		{"https://paths.example/exact/more", true},
+		{"https://paths.example/other", false},
+		{"https://sub.paths.example/exact", false},
+		{"not a url\x7f", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			source, blocked := m.Match(tt.url)
+			assert.Equal(t, tt.blocked, blocked)
+			if tt.blocked {
+				assert.Equal(t, "feed.txt", source)
+			}
+		})
+	}
+}
+
+func TestMatcherSources(t *testing.T) {
+	m := NewMatcher()
+	require.NoError(t, m.Load(strings.NewReader("one.example\n"), "first"))
+	require.NoError(t, m.Load(strings.NewReader("two.example/x\n"), "second"))
+
+	source, blocked := m.Match("https://one.example/")
+	assert.True(t, blocked)
+	assert.Equal(t, "first", source)
+	source, blocked = m.Match("https://two.example/x/y")
+	assert.True(t, blocked)
+	assert.Equal(t, "second", source)
+}
+
+func TestGlobPrefix(t *testing.T) {
+	tests := []struct {
+		expr, s string
+		want    bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/b", "a/bc", true},
+		{"a/b", "a/", false},
+		{"a/*/c", "a/x/y/c", true},
+		{"a/*/c", "a/x/y/d", false},
+		{"a/*", "a/", true},
+		{"*c", "abc", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, globPrefix(tt.expr, tt.s), "%s ~ %s", tt.expr, tt.s)
+	}
+}
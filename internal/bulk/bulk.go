@@ -0,0 +1,239 @@
+// Package bulk provides streaming CSV and JSONL encoding for imports, exports and reports, and
+// Parquet encoding for exports.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is a bulk file format.
+type Format string
+
+const (
+	// FormatCSV is comma-separated values with a header row.
+	FormatCSV Format = "csv"
+	// FormatJSONL is one JSON object per line.
+	FormatJSONL Format = "jsonl"
+	// FormatParquet is an Apache Parquet file. Its footer is written last, so it can only be
+	// written in one go, not appended to or read row by row.
+	FormatParquet Format = "parquet"
+)
+
+// ListSeparator joins list values (e.g. tags) in CSV cells.
+const ListSeparator = ";"
+
+// ParseFormat parses a format name; "ndjson" is accepted as an alias of jsonl.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "csv":
+		return FormatCSV, nil
+	case "jsonl", "ndjson":
+		return FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q", s)
+	}
+}
+
+// ParseExportFormat parses a format name for one-shot exports, which also support Parquet.
+func ParseExportFormat(s string) (Format, error) {
+	if strings.EqualFold(strings.TrimSpace(s), string(FormatParquet)) {
+		return FormatParquet, nil
+	}
+	return ParseFormat(s)
+}
+
+// FormatFromContentType maps a request Content-Type to a format.
+func FormatFromContentType(contentType string) (Format, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case "text/csv":
+		return FormatCSV, true
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return FormatJSONL, true
+	default:
+		return "", false
+	}
+}
+
+// ContentType returns the MIME type used when serving f.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// Writer writes rows whose values follow the column order given at construction.
+type Writer interface {
+	Write(values ...interface{}) error
+	// Flush writes buffered rows to the underlying writer.
+	Flush() error
+	// Close flushes the remaining rows and ends the file; the underlying writer is left open.
+	Close() error
+}
+
+// ColumnType is the type of a column in typed formats such as Parquet.
+type ColumnType int
+
+const (
+	// ColumnString holds UTF-8 strings; values of other types are formatted like CSV cells.
+	ColumnString ColumnType = iota
+	// ColumnInt64 holds signed integers.
+	ColumnInt64
+	// ColumnDouble holds floating point numbers.
+	ColumnDouble
+	// ColumnBool holds booleans.
+	ColumnBool
+	// ColumnTimestamp holds times, stored as microseconds since the Unix epoch in UTC.
+	ColumnTimestamp
+)
+
+// Column is a named, typed column.
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// NewWriter creates a writer for format. CSV output starts with a header row of columns;
+// JSONL rows are objects keyed by column in column order; Parquet columns are strings.
+func NewWriter(format Format, w io.Writer, columns []string) (Writer, error) {
+	typed := make([]Column, len(columns))
+	for i, name := range columns {
+		typed[i] = Column{Name: name, Type: ColumnString}
+	}
+	return NewTypedWriter(format, w, typed)
+}
+
+// NewTypedWriter creates a writer for format like NewWriter, with Parquet columns of the given
+// types, so even files without rows have a typed schema.
+func NewTypedWriter(format Format, w io.Writer, typed []Column) (Writer, error) {
+	columns := make([]string, len(typed))
+	for i, column := range typed {
+		columns[i] = column.Name
+	}
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw, columns: columns}, nil
+	case FormatJSONL:
+		return &jsonlWriter{w: bufio.NewWriter(w), columns: columns}, nil
+	case FormatParquet:
+		return newParquetWriter(w, typed), nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+}
+
+func (c *csvWriter) Write(values ...interface{}) error {
+	if len(values) != len(c.columns) {
+		return fmt.Errorf("got %d values for %d columns", len(values), len(c.columns))
+	}
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatCell(value)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+type jsonlWriter struct {
+	w       *bufio.Writer
+	columns []string
+}
+
+func (j *jsonlWriter) Write(values ...interface{}) error {
+	if len(values) != len(j.columns) {
+		return fmt.Errorf("got %d values for %d columns", len(values), len(j.columns))
+	}
+	// Objects are assembled by hand to keep keys in column order.
+	var line bytes.Buffer
+	line.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		key, _ := json.Marshal(j.columns[i]) //nolint:errcheck // Marshaling a string cannot fail
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		line.Write(key)
+		line.WriteByte(':')
+		line.Write(encoded)
+	}
+	line.WriteString("}\n")
+	_, err := j.w.Write(line.Bytes())
+	return err
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.w.Flush()
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.Flush()
+}
+
+// formatCell renders a value as a CSV cell; nil values become empty cells.
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	case []string:
+		return strings.Join(v, ListSeparator)
+	case json.RawMessage:
+		return string(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
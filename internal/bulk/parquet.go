@@ -0,0 +1,221 @@
+package bulk
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRowGroupSize is the number of rows buffered before they are written as a row group;
+// it bounds the memory used by a Parquet writer.
+const parquetRowGroupSize = 10000
+
+// parquetWriter writes Parquet files with one optional column per field, typed after the
+// columns given at construction. Nil values and nil pointers are written as nulls.
+type parquetWriter struct {
+	w       *bufio.Writer
+	file    *parquet.Writer
+	columns []Column
+	row     parquet.Row
+}
+
+func newParquetWriter(w io.Writer, columns []Column) *parquetWriter {
+	buffered := bufio.NewWriter(w)
+	schema := parquet.NewSchema("clicks", newParquetGroup(columns))
+	return &parquetWriter{
+		w:       buffered,
+		file:    parquet.NewWriter(buffered, schema, parquet.MaxRowsPerRowGroup(parquetRowGroupSize)),
+		columns: columns,
+		row:     make(parquet.Row, len(columns)),
+	}
+}
+
+func (p *parquetWriter) Write(values ...interface{}) error {
+	if len(values) != len(p.columns) {
+		return fmt.Errorf("got %d values for %d columns", len(values), len(p.columns))
+	}
+	for i, value := range values {
+		v, err := parquetValue(p.columns[i], value)
+		if err != nil {
+			return err
+		}
+		if v.IsNull() {
+			p.row[i] = v.Level(0, 0, i)
+		} else {
+			p.row[i] = v.Level(0, 1, i)
+		}
+	}
+	_, err := p.file.WriteRows([]parquet.Row{p.row})
+	return err
+}
+
+// Flush writes the completed row groups; buffered rows are only written once their row group
+// is full or the writer is closed.
+func (p *parquetWriter) Flush() error {
+	return p.w.Flush()
+}
+
+// Close writes the buffered rows and the file footer.
+func (p *parquetWriter) Close() error {
+	if err := p.file.Close(); err != nil {
+		return err
+	}
+	return p.w.Flush()
+}
+
+// parquetNode returns the schema node of a column of type typ.
+func parquetNode(typ ColumnType) parquet.Node {
+	switch typ {
+	case ColumnInt64:
+		return parquet.Optional(parquet.Int(64))
+	case ColumnDouble:
+		return parquet.Optional(parquet.Leaf(parquet.DoubleType))
+	case ColumnBool:
+		return parquet.Optional(parquet.Leaf(parquet.BooleanType))
+	case ColumnTimestamp:
+		return parquet.Optional(parquet.Timestamp(parquet.Microsecond))
+	default:
+		return parquet.Optional(parquet.String())
+	}
+}
+
+// parquetValue converts value to the physical type of column, or a null for nil values.
+func parquetValue(column Column, value interface{}) (parquet.Value, error) {
+	value = deref(value)
+	if value == nil {
+		return parquet.NullValue(), nil
+	}
+	switch column.Type {
+	case ColumnInt64:
+		n, ok := toInt64(value)
+		if !ok {
+			return parquet.Value{}, fmt.Errorf("column %s: cannot write %T as an integer", column.Name, value)
+		}
+		return parquet.Int64Value(n), nil
+	case ColumnDouble:
+		f, ok := value.(float64)
+		if !ok {
+			n, isInt := toInt64(value)
+			if !isInt {
+				return parquet.Value{}, fmt.Errorf("column %s: cannot write %T as a number", column.Name, value)
+			}
+			f = float64(n)
+		}
+		return parquet.DoubleValue(f), nil
+	case ColumnBool:
+		b, ok := value.(bool)
+		if !ok {
+			return parquet.Value{}, fmt.Errorf("column %s: cannot write %T as a boolean", column.Name, value)
+		}
+		return parquet.BooleanValue(b), nil
+	case ColumnTimestamp:
+		t, ok := value.(time.Time)
+		if !ok {
+			return parquet.Value{}, fmt.Errorf("column %s: cannot write %T as a timestamp", column.Name, value)
+		}
+		return parquet.Int64Value(t.UnixMicro()), nil
+	default:
+		return parquet.ByteArrayValue([]byte(formatCell(value))), nil
+	}
+}
+
+// parquetGroup is the root of a Parquet schema whose columns keep the order they were given in;
+// parquet.Group sorts them by name.
+type parquetGroup struct {
+	parquet.Group
+	fields []parquet.Field
+}
+
+func newParquetGroup(columns []Column) *parquetGroup {
+	g := &parquetGroup{Group: make(parquet.Group, len(columns))}
+	for _, column := range columns {
+		node := parquetNode(column.Type)
+		g.Group[column.Name] = node
+		g.fields = append(g.fields, parquetField{Node: node, name: column.Name})
+	}
+	return g
+}
+
+func (g *parquetGroup) Fields() []parquet.Field {
+	return g.fields
+}
+
+// parquetField is a named column of a parquetGroup. Rows are written as parquet.Row values, so
+// fields are never read from Go values.
+type parquetField struct {
+	parquet.Node
+	name string
+}
+
+func (f parquetField) Name() string {
+	return f.name
+}
+
+func (f parquetField) Value(reflect.Value) reflect.Value {
+	return reflect.Value{}
+}
+
+// deref returns the value a pointer points to, or nil for nil pointers.
+func deref(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *string:
+		if v != nil {
+			return *v
+		}
+	case *int:
+		if v != nil {
+			return *v
+		}
+	case *int32:
+		if v != nil {
+			return *v
+		}
+	case *int64:
+		if v != nil {
+			return *v
+		}
+	case *uint32:
+		if v != nil {
+			return *v
+		}
+	case *float64:
+		if v != nil {
+			return *v
+		}
+	case *bool:
+		if v != nil {
+			return *v
+		}
+	case *time.Time:
+		if v != nil {
+			return *v
+		}
+	case json.RawMessage:
+		if v != nil {
+			return v
+		}
+	default:
+		return value
+	}
+	return nil
+}
+
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint32:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
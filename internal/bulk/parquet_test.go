@@ -0,0 +1,131 @@
+package bulk
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var parquetColumns = []Column{
+	{Name: "short_code", Type: ColumnString},
+	{Name: "url_version", Type: ColumnInt64},
+	{Name: "clicked_at", Type: ColumnTimestamp},
+	{Name: "is_bot", Type: ColumnBool},
+	{Name: "score", Type: ColumnDouble},
+	{Name: "asn", Type: ColumnInt64},
+}
+
+// readParquet opens a Parquet file and returns its column names, schema and rows.
+func readParquet(t *testing.T, data []byte) ([]string, *parquet.Schema, []parquet.Row) {
+	t.Helper()
+	file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	var names []string
+	for _, field := range file.Schema().Fields() {
+		names = append(names, field.Name())
+	}
+	reader := parquet.NewReader(file)
+	defer reader.Close()
+	var rows []parquet.Row
+	buf := make([]parquet.Row, 16)
+	for {
+		n, err := reader.ReadRows(buf)
+		for _, row := range buf[:n] {
+			rows = append(rows, row.Clone())
+		}
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	return names, file.Schema(), rows
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	var out bytes.Buffer
+	w, err := NewTypedWriter(FormatParquet, &out, parquetColumns)
+	require.NoError(t, err)
+
+	version := 3
+	asn := uint32(64500)
+	clickedAt := time.Date(2026, 10, 18, 12, 30, 0, 123456000, time.FixedZone("CEST", 2*3600))
+	require.NoError(t, w.Write("abc", &version, clickedAt, true, 0.5, &asn))
+	require.NoError(t, w.Write((*string)(nil), (*int)(nil), (*time.Time)(nil), (*bool)(nil), nil, (*uint32)(nil)))
+	require.NoError(t, w.Write("xyz", 7, &clickedAt, false, 2, asn))
+	require.NoError(t, w.Close())
+
+	names, schema, rows := readParquet(t, out.Bytes())
+	assert.Equal(t, []string{"short_code", "url_version", "clicked_at", "is_bot", "score", "asn"}, names)
+	for _, column := range schema.Columns() {
+		leaf, ok := schema.Lookup(column...)
+		require.True(t, ok)
+		assert.True(t, leaf.Node.Optional(), column)
+	}
+	leaf, _ := schema.Lookup("clicked_at")
+	assert.Equal(t, "TIMESTAMP(isAdjustedToUTC=true,unit=MICROS)", leaf.Node.Type().String())
+	leaf, _ = schema.Lookup("short_code")
+	assert.Equal(t, "STRING", leaf.Node.Type().String())
+	leaf, _ = schema.Lookup("is_bot")
+	assert.Equal(t, parquet.Boolean, leaf.Node.Type().Kind())
+	leaf, _ = schema.Lookup("score")
+	assert.Equal(t, parquet.Double, leaf.Node.Type().Kind())
+
+	require.Len(t, rows, 3)
+	first := rows[0]
+	assert.Equal(t, "abc", first[0].String())
+	assert.Equal(t, int64(3), first[1].Int64())
+	assert.Equal(t, clickedAt.UnixMicro(), first[2].Int64())
+	assert.True(t, first[3].Boolean())
+	assert.Equal(t, 0.5, first[4].Double())
+	assert.Equal(t, int64(64500), first[5].Int64())
+	for i, value := range rows[1] {
+		assert.True(t, value.IsNull(), parquetColumns[i].Name)
+	}
+	assert.Equal(t, "xyz", rows[2][0].String())
+	assert.Equal(t, int64(7), rows[2][1].Int64())
+	assert.False(t, rows[2][3].Boolean())
+	assert.Equal(t, 2.0, rows[2][4].Double())
+}
+
+func TestParquetEmptyFileKeepsTypes(t *testing.T) {
+	var out bytes.Buffer
+	w, err := NewTypedWriter(FormatParquet, &out, parquetColumns)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	names, schema, rows := readParquet(t, out.Bytes())
+	assert.Len(t, names, len(parquetColumns))
+	assert.Empty(t, rows)
+	leaf, _ := schema.Lookup("url_version")
+	assert.Equal(t, parquet.Int64, leaf.Node.Type().Kind())
+	leaf, _ = schema.Lookup("is_bot")
+	assert.Equal(t, parquet.Boolean, leaf.Node.Type().Kind())
+}
+
+func TestParquetRowGroups(t *testing.T) {
+	var out bytes.Buffer
+	w, err := NewTypedWriter(FormatParquet, &out, parquetColumns[:2])
+	require.NoError(t, err)
+	for i := 0; i < parquetRowGroupSize+5; i++ {
+		require.NoError(t, w.Write("abc", i))
+	}
+	require.NoError(t, w.Close())
+
+	file, err := parquet.OpenFile(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, int64(parquetRowGroupSize+5), file.NumRows())
+	assert.Len(t, file.RowGroups(), 2)
+}
+
+func TestParquetRejectsMismatchedValues(t *testing.T) {
+	w, err := NewTypedWriter(FormatParquet, io.Discard, parquetColumns[:2])
+	require.NoError(t, err)
+	assert.Error(t, w.Write("abc"))
+	assert.Error(t, w.Write("abc", "not a number"))
+}
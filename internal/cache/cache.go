@@ -5,6 +5,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -16,6 +19,19 @@ type Cache interface {
 	Set(ctx context.Context, key string, value string, ttl time.Duration) error
 	Delete(ctx context.Context, key string) error
 	Exists(ctx context.Context, key string) (bool, error)
+	// PFAdd adds elements to the HyperLogLog at key and sets its TTL unless ttl is 0.
+	PFAdd(ctx context.Context, key string, ttl time.Duration, elements ...string) error
+	// PFCount returns the estimated cardinality of the union of the HyperLogLogs at keys.
+	PFCount(ctx context.Context, keys ...string) (int64, error)
+	// PFMerge stores the union of the HyperLogLogs at keys in dest with TTL.
+	PFMerge(ctx context.Context, dest string, ttl time.Duration, keys ...string) error
+	// Incr increments the counter at key, which expires ttl after its first increment.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Publish sends message to the current subscribers of channel on all instances.
+	Publish(ctx context.Context, channel, message string) error
+	// Subscribe returns the messages published to channel from now on. The returned channel
+	// is closed when ctx is done or the subscription fails.
+	Subscribe(ctx context.Context, channel string) (<-chan string, error)
 }
 
 type cache struct {
@@ -65,6 +81,85 @@ func (c *cache) Exists(ctx context.Context, key string) (bool, error) {
 	return count > 0, nil
 }
 
+func (c *cache) PFAdd(ctx context.Context, key string, ttl time.Duration, elements ...string) error {
+	values := make([]interface{}, len(elements))
+	for i, element := range elements {
+		values[i] = element
+	}
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.PFAdd(ctx, key, values...)
+		if ttl > 0 {
+			pipe.Expire(ctx, key, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (c *cache) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	return c.client.PFCount(ctx, keys...).Result()
+}
+
+func (c *cache) PFMerge(ctx context.Context, dest string, ttl time.Duration, keys ...string) error {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		// PFMERGE includes dest in the union, so start from an empty key.
+		pipe.Del(ctx, dest)
+		pipe.PFMerge(ctx, dest, keys...)
+		pipe.Expire(ctx, dest, ttl)
+		return nil
+	})
+	return err
+}
+
+func (c *cache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(ctx, key, 0, ttl)
+		incr = pipe.Incr(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (c *cache) Publish(ctx context.Context, channel, message string) error {
+	return c.client.Publish(ctx, channel, message).Err()
+}
+
+func (c *cache) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	pubsub := c.client.Subscribe(ctx, channel)
+	// Wait for the confirmation, so messages published after Subscribe returns are received.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close() //nolint:errcheck // Subscription failed anyway
+		return nil, err
+	}
+
+	messages := make(chan string)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close() //nolint:errcheck // Nothing to do on close errors
+		received := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-received:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- msg.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return messages, nil
+}
+
 // URLCache provides URL-specific caching operations.
 type URLCache struct {
 	cache Cache
@@ -75,16 +170,29 @@ func NewURLCache(c Cache) *URLCache {
 	return &URLCache{cache: c}
 }
 
-// GetURL retrieves the original URL for a given short code.
-func (uc *URLCache) GetURL(ctx context.Context, shortCode string) (string, error) {
+// GetURL retrieves the original URL for a given short code along with the link version it
+// belongs to. Entries written before versions were cached report version 0.
+func (uc *URLCache) GetURL(ctx context.Context, shortCode string) (string, int, error) {
 	key := fmt.Sprintf("url:%s", shortCode)
-	return uc.cache.Get(ctx, key)
+	val, err := uc.cache.Get(ctx, key)
+	if err != nil {
+		return "", 0, err
+	}
+	prefix, originalURL, found := strings.Cut(val, "\n")
+	if !found {
+		return val, 0, nil
+	}
+	version, err := strconv.Atoi(prefix)
+	if err != nil {
+		return val, 0, nil
+	}
+	return originalURL, version, nil
 }
 
-// SetURL stores the original URL for a given short code with TTL.
-func (uc *URLCache) SetURL(ctx context.Context, shortCode, originalURL string, ttl time.Duration) error {
+// SetURL stores the original URL and link version for a given short code with TTL.
+func (uc *URLCache) SetURL(ctx context.Context, shortCode, originalURL string, version int, ttl time.Duration) error {
 	key := fmt.Sprintf("url:%s", shortCode)
-	return uc.cache.Set(ctx, key, originalURL, ttl)
+	return uc.cache.Set(ctx, key, fmt.Sprintf("%d\n%s", version, originalURL), ttl)
 }
 
 // DeleteURL removes a URL from cache.
@@ -148,6 +256,211 @@ func (rlc *RateLimitCache) AddToWindow(ctx context.Context, key string, timestam
 	return rlc.SetWindow(ctx, key, filtered, windowSize+time.Second*10)
 }
 
+// VisitorCache estimates unique visitors per short code with HyperLogLog sketches: one per
+// UTC day, kept for the retention, and one for all time, kept until the short code has had no
+// visitor for the retention.
+type VisitorCache struct {
+	cache     Cache
+	retention time.Duration
+}
+
+// mergeTTL bounds the lifetime of the sketches merged for range counts.
+const mergeTTL = time.Minute
+
+// NewVisitorCache creates a visitor cache keeping daily sketches for retention.
+func NewVisitorCache(c Cache, retention time.Duration) *VisitorCache {
+	return &VisitorCache{cache: c, retention: retention}
+}
+
+// AddVisitor counts visitor for shortCode on the UTC day of at and all time.
+func (vc *VisitorCache) AddVisitor(ctx context.Context, shortCode, visitor string, at time.Time) error {
+	// The day sketch outlives its day by the retention.
+	dayTTL := time.Until(at.UTC().Truncate(24*time.Hour).Add(24*time.Hour)) + vc.retention
+	if dayTTL <= 0 {
+		return nil
+	}
+	if err := vc.cache.PFAdd(ctx, visitorDayKey(shortCode, at), dayTTL, visitor); err != nil {
+		return err
+	}
+	// Links cannot be deleted, so the all-time sketch of links nobody visits anymore expires.
+	return vc.cache.PFAdd(ctx, visitorKey(shortCode), vc.retention, visitor)
+}
+
+// CountVisitors returns the estimated number of unique visitors of shortCode of all time.
+func (vc *VisitorCache) CountVisitors(ctx context.Context, shortCode string) (int64, error) {
+	return vc.cache.PFCount(ctx, visitorKey(shortCode))
+}
+
+// CountVisitorsBetween returns the estimated number of unique visitors of shortCode on the UTC
+// days overlapping [from, to), merging the daily sketches. Days past the retention count nothing.
+func (vc *VisitorCache) CountVisitorsBetween(ctx context.Context, shortCode string, from, to time.Time) (int64, error) {
+	first := from.UTC().Truncate(24 * time.Hour)
+	if oldest := time.Now().UTC().Add(-vc.retention).Truncate(24 * time.Hour); first.Before(oldest) {
+		first = oldest
+	}
+	keys := make([]string, 0)
+	last := first
+	for day := first; day.Before(to); day = day.Add(24 * time.Hour) {
+		keys = append(keys, visitorDayKey(shortCode, day))
+		last = day
+	}
+	switch len(keys) {
+	case 0:
+		return 0, nil
+	case 1:
+		return vc.cache.PFCount(ctx, keys[0])
+	}
+
+	dest := fmt.Sprintf("visitors-merge:%s:%s:%s", shortCode, first.Format(time.DateOnly), last.Format(time.DateOnly))
+	if err := vc.cache.PFMerge(ctx, dest, mergeTTL, keys...); err != nil {
+		return 0, err
+	}
+	return vc.cache.PFCount(ctx, dest)
+}
+
+func visitorKey(shortCode string) string {
+	return fmt.Sprintf("visitors:%s", shortCode)
+}
+
+func visitorDayKey(shortCode string, day time.Time) string {
+	return fmt.Sprintf("visitors:%s:%s", shortCode, day.UTC().Format(time.DateOnly))
+}
+
+// BurstCache counts events per key in fixed time windows shared by all instances.
+type BurstCache struct {
+	cache Cache
+}
+
+// NewBurstCache creates a new burst cache instance.
+func NewBurstCache(c Cache) *BurstCache {
+	return &BurstCache{cache: c}
+}
+
+// Hit counts an event for key and returns the number of events in the current window,
+// which starts with the first event after the previous window expired.
+func (bc *BurstCache) Hit(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return bc.cache.Incr(ctx, fmt.Sprintf("burst:%s", key), window)
+}
+
+// liveBuffer is the number of messages buffered per live subscriber; messages for subscribers
+// that fall further behind are dropped.
+const liveBuffer = 64
+
+// LiveCache broadcasts messages per short code to subscribers on all instances with Redis
+// pub/sub. Messages are not stored: subscribers only receive what is published while they
+// are subscribed. The subscribers of a short code on one instance share one Redis
+// subscription.
+type LiveCache struct {
+	cache Cache
+	// closed is done once Close is called.
+	closed context.Context
+	close  context.CancelFunc
+
+	mu    sync.Mutex
+	feeds map[string]*liveFeed
+}
+
+// liveFeed is the shared Redis subscription of a short code.
+type liveFeed struct {
+	subscribers map[chan string]struct{}
+	cancel      context.CancelFunc
+}
+
+// NewLiveCache creates a new live cache instance.
+func NewLiveCache(c Cache) *LiveCache {
+	closed, closeFunc := context.WithCancel(context.Background())
+	return &LiveCache{cache: c, closed: closed, close: closeFunc, feeds: make(map[string]*liveFeed)}
+}
+
+// Close ends the subscriptions of this instance, e.g. so long-lived streams do not hold up a
+// graceful shutdown. Later subscriptions end immediately.
+func (lc *LiveCache) Close() {
+	lc.close()
+}
+
+// Publish sends message to the current subscribers of shortCode.
+func (lc *LiveCache) Publish(ctx context.Context, shortCode, message string) error {
+	return lc.cache.Publish(ctx, liveKey(shortCode), message)
+}
+
+// Subscribe returns the messages published for shortCode until ctx is done or the cache is
+// closed. The first subscriber of shortCode opens its Redis subscription and the last one to
+// leave closes it.
+func (lc *LiveCache) Subscribe(ctx context.Context, shortCode string) (<-chan string, error) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	messages := make(chan string, liveBuffer)
+	if lc.closed.Err() != nil {
+		close(messages)
+		return messages, nil
+	}
+	feed, ok := lc.feeds[shortCode]
+	if !ok {
+		feedCtx, cancel := context.WithCancel(lc.closed)
+		received, err := lc.cache.Subscribe(feedCtx, liveKey(shortCode))
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		feed = &liveFeed{subscribers: make(map[chan string]struct{}), cancel: cancel}
+		lc.feeds[shortCode] = feed
+		go lc.fanOut(shortCode, feed, received)
+	}
+	feed.subscribers[messages] = struct{}{}
+
+	context.AfterFunc(ctx, func() {
+		lc.mu.Lock()
+		defer lc.mu.Unlock()
+		if _, ok := feed.subscribers[messages]; !ok {
+			return
+		}
+		delete(feed.subscribers, messages)
+		close(messages)
+		if len(feed.subscribers) == 0 {
+			lc.remove(shortCode, feed)
+		}
+	})
+	return messages, nil
+}
+
+// fanOut copies the messages of feed to its subscribers until the Redis subscription ends,
+// then ends theirs.
+func (lc *LiveCache) fanOut(shortCode string, feed *liveFeed, received <-chan string) {
+	for message := range received {
+		lc.mu.Lock()
+		for subscriber := range feed.subscribers {
+			select {
+			case subscriber <- message:
+			default:
+				// The feeds are best effort; a slow subscriber must not hold up the others.
+			}
+		}
+		lc.mu.Unlock()
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	for subscriber := range feed.subscribers {
+		close(subscriber)
+	}
+	feed.subscribers = nil
+	lc.remove(shortCode, feed)
+}
+
+// remove closes the Redis subscription of feed and forgets it. It must be called with lc.mu
+// held.
+func (lc *LiveCache) remove(shortCode string, feed *liveFeed) {
+	feed.cancel()
+	if lc.feeds[shortCode] == feed {
+		delete(lc.feeds, shortCode)
+	}
+}
+
+func liveKey(shortCode string) string {
+	return fmt.Sprintf("live:%s", shortCode)
+}
+
 var (
 	// ErrNotFound is returned when a cache key is not found.
 	ErrNotFound = fmt.Errorf("not found")
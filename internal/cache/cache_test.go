@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pubSub implements the pub/sub of Cache in memory and counts the open subscriptions.
+type pubSub struct {
+	Cache
+
+	mu       sync.Mutex
+	channels map[string][]chan string
+	opened   int
+	err      error
+}
+
+func newPubSub() *pubSub {
+	return &pubSub{channels: make(map[string][]chan string)}
+}
+
+func (p *pubSub) Publish(_ context.Context, channel, message string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, messages := range p.channels[channel] {
+		messages <- message
+	}
+	return nil
+}
+
+func (p *pubSub) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	messages := make(chan string, 16)
+	p.channels[channel] = append(p.channels[channel], messages)
+	p.opened++
+	context.AfterFunc(ctx, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		subscribers := p.channels[channel]
+		for i, subscriber := range subscribers {
+			if subscriber == messages {
+				p.channels[channel] = append(subscribers[:i], subscribers[i+1:]...)
+			}
+		}
+		close(messages)
+	})
+	return messages, nil
+}
+
+// open returns the number of open subscriptions of channel.
+func (p *pubSub) open(channel string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.channels[channel])
+}
+
+// subscriptions returns the number of subscriptions ever opened.
+func (p *pubSub) subscriptions() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.opened
+}
+
+func receive(t *testing.T, messages <-chan string) string {
+	t.Helper()
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return ""
+	}
+}
+
+func closed(messages <-chan string) bool {
+	select {
+	case _, ok := <-messages:
+		return !ok
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func TestLiveCacheSharesSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	redis := newPubSub()
+	live := NewLiveCache(redis)
+
+	firstCtx, cancelFirst := context.WithCancel(ctx)
+	first, err := live.Subscribe(firstCtx, "abc")
+	require.NoError(t, err)
+	secondCtx, cancelSecond := context.WithCancel(ctx)
+	defer cancelSecond()
+	second, err := live.Subscribe(secondCtx, "abc")
+	require.NoError(t, err)
+	otherCtx, cancelOther := context.WithCancel(ctx)
+	defer cancelOther()
+	other, err := live.Subscribe(otherCtx, "xyz")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, redis.open("live:abc"))
+	assert.Equal(t, 1, redis.open("live:xyz"))
+	assert.Equal(t, 2, redis.subscriptions())
+
+	require.NoError(t, live.Publish(ctx, "abc", "click"))
+	assert.Equal(t, "click", receive(t, first))
+	assert.Equal(t, "click", receive(t, second))
+	require.NoError(t, live.Publish(ctx, "xyz", "other"))
+	assert.Equal(t, "other", receive(t, other))
+
+	// The subscription stays open while subscribers are left.
+	cancelFirst()
+	assert.True(t, closed(first))
+	assert.Equal(t, 1, redis.open("live:abc"))
+	require.NoError(t, live.Publish(ctx, "abc", "again"))
+	assert.Equal(t, "again", receive(t, second))
+
+	cancelSecond()
+	assert.True(t, closed(second))
+	assert.Eventually(t, func() bool { return redis.open("live:abc") == 0 }, time.Second, time.Millisecond)
+
+	// The next subscriber opens a new subscription.
+	third, err := live.Subscribe(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, 1, redis.open("live:abc"))
+	assert.Equal(t, 3, redis.subscriptions())
+	require.NoError(t, live.Publish(ctx, "abc", "third"))
+	assert.Equal(t, "third", receive(t, third))
+
+	live.Close()
+	assert.True(t, closed(third))
+	assert.True(t, closed(other))
+	later, err := live.Subscribe(ctx, "abc")
+	require.NoError(t, err)
+	assert.True(t, closed(later))
+}
+
+func TestLiveCacheSubscribeError(t *testing.T) {
+	redis := newPubSub()
+	redis.err = errors.New("redis down")
+	live := NewLiveCache(redis)
+
+	_, err := live.Subscribe(context.Background(), "abc")
+	require.Error(t, err)
+
+	// A failed subscription is not shared.
+	redis.err = nil
+	messages, err := live.Subscribe(context.Background(), "abc")
+	require.NoError(t, err)
+	require.NoError(t, live.Publish(context.Background(), "abc", "click"))
+	assert.Equal(t, "click", receive(t, messages))
+}
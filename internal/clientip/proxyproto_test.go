@@ -0,0 +1,168 @@
+package clientip
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadProxyV1(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{"TCP4", "PROXY TCP4 198.51.100.1 10.0.0.1 56324 443\r\n", "198.51.100.1:56324", false},
+		{"TCP6", "PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n", "[2001:db8::1]:56324", false},
+		{"UNKNOWN", "PROXY UNKNOWN\r\n", "", false},
+		{"UNKNOWN with addresses", "PROXY UNKNOWN ffff::1 ffff::2 1 2\r\n", "", false},
+		{"missing CR", "PROXY TCP4 198.51.100.1 10.0.0.1 56324 443\n", "", true},
+		{"missing fields", "PROXY TCP4 198.51.100.1 10.0.0.1 56324\r\n", "", true},
+		{"unknown protocol", "PROXY UDP4 198.51.100.1 10.0.0.1 56324 443\r\n", "", true},
+		{"bad address", "PROXY TCP4 198.51.100.300 10.0.0.1 56324 443\r\n", "", true},
+		{"bad port", "PROXY TCP4 198.51.100.1 10.0.0.1 65536 443\r\n", "", true},
+		{"too long", "PROXY TCP4 " + strings.Repeat("1", 100) + " 10.0.0.1 1 2\r\n", "", true},
+		{"truncated", "PROXY TCP4 198.51.100.1", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := readProxyV1(bufio.NewReader(strings.NewReader(tt.header)))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidProxyHeader)
+				return
+			}
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Nil(t, addr)
+				return
+			}
+			require.NotNil(t, addr)
+			assert.Equal(t, tt.want, addr.String())
+		})
+	}
+}
+
+// proxyV2 builds a PROXY v2 header with the given version and command byte, family byte and
+// address payload.
+func proxyV2(versionCommand, family byte, payload []byte) []byte {
+	header := append([]byte(nil), proxyV2Signature...)
+	header = append(header, versionCommand, family)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	return append(header, payload...)
+}
+
+func proxyV2Payload(src, dst netip.Addr, sport, dport uint16) []byte {
+	payload := append(src.AsSlice(), dst.AsSlice()...)
+	payload = binary.BigEndian.AppendUint16(payload, sport)
+	return binary.BigEndian.AppendUint16(payload, dport)
+}
+
+func TestReadProxyV2(t *testing.T) {
+	v4 := proxyV2Payload(netip.MustParseAddr("198.51.100.1"), netip.MustParseAddr("10.0.0.1"), 56324, 443)
+	v6 := proxyV2Payload(netip.MustParseAddr("2001:db8::1"), netip.MustParseAddr("2001:db8::2"), 56324, 443)
+
+	tests := []struct {
+		name    string
+		header  []byte
+		want    string
+		wantErr bool
+	}{
+		{"PROXY over TCP4", proxyV2(0x21, 0x11, v4), "198.51.100.1:56324", false},
+		{"PROXY over TCP6", proxyV2(0x21, 0x21, v6), "[2001:db8::1]:56324", false},
+		{"TLVs after the addresses", proxyV2(0x21, 0x11, append(v4, 0x04, 0x00, 0x01, 0xff)), "198.51.100.1:56324", false},
+		{"LOCAL keeps the peer", proxyV2(0x20, 0x11, v4), "", false},
+		{"LOCAL without addresses", proxyV2(0x20, 0x00, nil), "", false},
+		{"unix socket family", proxyV2(0x21, 0x31, make([]byte, 216)), "", false},
+		{"unsupported version", proxyV2(0x11, 0x11, v4), "", true},
+		{"short IPv4 payload", proxyV2(0x21, 0x11, v4[:11]), "", true},
+		{"short IPv6 payload", proxyV2(0x21, 0x21, v6[:35]), "", true},
+		{"truncated header", proxyV2(0x21, 0x11, v4)[:14], "", true},
+		{"truncated payload", proxyV2(0x21, 0x11, v4)[:20], "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := readProxyV2(bufio.NewReader(bytes.NewReader(tt.header)))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidProxyHeader)
+				return
+			}
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Nil(t, addr)
+				return
+			}
+			require.NotNil(t, addr)
+			assert.Equal(t, tt.want, addr.String())
+		})
+	}
+}
+
+func TestProxyProtocolListener(t *testing.T) {
+	resolver, err := NewResolver([]string{"127.0.0.1"}, nil)
+	require.NoError(t, err)
+	untrusted, err := NewResolver(nil, nil)
+	require.NoError(t, err)
+	v4 := proxyV2Payload(netip.MustParseAddr("198.51.100.1"), netip.MustParseAddr("10.0.0.1"), 56324, 443)
+
+	tests := []struct {
+		name       string
+		resolver   *Resolver
+		send       []byte
+		wantRemote string
+		wantBody   string
+		wantErr    bool
+	}{
+		{"v1 header", resolver, []byte("PROXY TCP4 198.51.100.1 10.0.0.1 56324 443\r\nGET /"), "198.51.100.1:56324", "GET /", false},
+		{"v2 header", resolver, append(proxyV2(0x21, 0x11, v4), "GET /"...), "198.51.100.1:56324", "GET /", false},
+		{"v1 UNKNOWN keeps the peer", resolver, []byte("PROXY UNKNOWN\r\nGET /"), "127.0.0.1", "GET /", false},
+		{"v2 LOCAL keeps the peer", resolver, append(proxyV2(0x20, 0x00, nil), "GET /"...), "127.0.0.1", "GET /", false},
+		{"no header", resolver, []byte("GET / HTTP/1.1"), "127.0.0.1", "GET / HTTP/1.1", false},
+		{"invalid header", resolver, []byte("PROXY TCP4 nope\r\nGET /"), "127.0.0.1", "", true},
+		{"untrusted peer keeps the header", untrusted, []byte("PROXY TCP4 198.51.100.1 10.0.0.1 56324 443\r\n"), "127.0.0.1", "PROXY TCP4 198.51.100.1 10.0.0.1 56324 443\r\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer ln.Close() //nolint:errcheck // Test listener
+			ln = NewProxyProtocolListener(ln, tt.resolver)
+
+			go func() {
+				client, err := net.Dial("tcp", ln.Addr().String())
+				if err != nil {
+					return
+				}
+				defer client.Close()  //nolint:errcheck // Test connection
+				client.Write(tt.send) //nolint:errcheck,gosec // The server side asserts what arrived
+			}()
+
+			conn, err := ln.Accept()
+			require.NoError(t, err)
+			defer conn.Close() //nolint:errcheck // Test connection
+
+			// The peer's own port is ephemeral.
+			remote := conn.RemoteAddr().String()
+			if host, _, err := net.SplitHostPort(remote); err == nil && host == "127.0.0.1" {
+				remote = host
+			}
+			assert.Equal(t, tt.wantRemote, remote)
+
+			body, err := io.ReadAll(conn)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidProxyHeader)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBody, string(body))
+		})
+	}
+}
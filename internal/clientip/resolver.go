@@ -0,0 +1,177 @@
+// Package clientip resolves the originating client address of requests arriving through trusted proxies.
+package clientip
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"url-shorterner/internal/access"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKeyClientIP is the key used to store the resolved client IP in Gin context.
+const ContextKeyClientIP = "client_ip"
+
+// Supported forwarding headers.
+const (
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-IP"
+	HeaderForwarded     = "Forwarded"
+)
+
+// Resolver determines the client IP of a request.
+// Forwarding headers are only honored when the direct peer is a trusted proxy,
+// and the chain is walked right to left so clients cannot spoof entries added by our proxies.
+type Resolver struct {
+	trusted []netip.Prefix
+	headers []string
+}
+
+// NewResolver creates a resolver trusting the given proxy CIDRs and reading the given headers in order.
+func NewResolver(trustedProxies, headers []string) (*Resolver, error) {
+	trusted, err := ParsePrefixes(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	canonical := make([]string, 0, len(headers))
+	for _, header := range headers {
+		canonical = append(canonical, http.CanonicalHeaderKey(strings.TrimSpace(header)))
+	}
+	return &Resolver{trusted: trusted, headers: canonical}, nil
+}
+
+// ParsePrefixes parses a list of CIDR ranges or bare IP addresses.
+func ParsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		prefix, err := access.ParsePrefix(entry)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// Resolve returns the client IP for r.
+func (r *Resolver) Resolve(req *http.Request) string {
+	remote, ok := parseHostPort(req.RemoteAddr)
+	if !ok {
+		return ""
+	}
+	if !r.isTrusted(remote) {
+		return remote.String()
+	}
+
+	for _, header := range r.headers {
+		var chain []string
+		switch header {
+		case HeaderForwarded:
+			chain = parseForwarded(req.Header.Values(header))
+		case HeaderXForwardedFor:
+			chain = splitList(req.Header.Values(header))
+		default:
+			if value := strings.TrimSpace(req.Header.Get(header)); value != "" {
+				chain = []string{value}
+			}
+		}
+		if ip, ok := r.walk(chain); ok {
+			return ip.String()
+		}
+	}
+
+	return remote.String()
+}
+
+// IsTrusted reports whether addr belongs to a trusted proxy.
+func (r *Resolver) IsTrusted(addr netip.Addr) bool {
+	return r.isTrusted(addr.Unmap())
+}
+
+// walk returns the rightmost untrusted address in chain.
+// If every hop is trusted, the leftmost one is returned.
+func (r *Resolver) walk(chain []string) (netip.Addr, bool) {
+	var last netip.Addr
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok := parseHostPort(chain[i])
+		if !ok {
+			break
+		}
+		last = addr
+		if !r.isTrusted(addr) {
+			return addr, true
+		}
+	}
+	return last, last.IsValid()
+}
+
+func (r *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// FromContext returns the client IP resolved by the ResolveClientIP middleware,
+// falling back to Gin's own resolution when the middleware is not installed.
+func FromContext(c *gin.Context) string {
+	if ip := c.GetString(ContextKeyClientIP); ip != "" {
+		return ip
+	}
+	return c.ClientIP()
+}
+
+// parseHostPort parses "ip", "ip:port", "[ipv6]" or "[ipv6]:port".
+func parseHostPort(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return netip.Addr{}, false
+	}
+	if addr, err := netip.ParseAddr(strings.Trim(s, "[]")); err == nil {
+		return addr.Unmap(), true
+	}
+	host, _, err := net.SplitHostPort(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// parseForwarded extracts the for= node of each element of RFC 7239 Forwarded headers.
+// Elements without a for= parameter yield an empty entry so the chain stops there.
+func parseForwarded(values []string) []string {
+	var nodes []string
+	for _, element := range splitList(values) {
+		node := ""
+		for _, pair := range strings.Split(element, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if found && strings.EqualFold(key, "for") {
+				node = strings.Trim(value, `"`)
+				break
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
@@ -0,0 +1,141 @@
+package clientip
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	resolver, err := NewResolver(
+		[]string{"10.0.0.0/8", "2001:db8:1::/48", "192.0.2.1"},
+		[]string{"forwarded", "x-forwarded-for", "X-Real-IP"},
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string][]string
+		want    string
+	}{
+		{"direct client", "203.0.113.7:4000", nil, "203.0.113.7"},
+		{
+			"untrusted peer ignores headers", "203.0.113.7:4000",
+			map[string][]string{HeaderXForwardedFor: {"198.51.100.1"}, HeaderXRealIP: {"198.51.100.2"}},
+			"203.0.113.7",
+		},
+		{"trusted peer without headers", "10.0.0.1:4000", nil, "10.0.0.1"},
+		{
+			"single hop", "10.0.0.1:4000",
+			map[string][]string{HeaderXForwardedFor: {"198.51.100.1"}},
+			"198.51.100.1",
+		},
+		{
+			"spoofed leftmost entry", "10.0.0.1:4000",
+			map[string][]string{HeaderXForwardedFor: {"1.2.3.4, 198.51.100.1, 10.0.0.2"}},
+			"198.51.100.1",
+		},
+		{
+			"chain split over header lines", "10.0.0.1:4000",
+			map[string][]string{HeaderXForwardedFor: {"1.2.3.4", "198.51.100.1 , 10.0.0.2"}},
+			"198.51.100.1",
+		},
+		{
+			"all hops trusted", "10.0.0.1:4000",
+			map[string][]string{HeaderXForwardedFor: {"10.0.0.3, 192.0.2.1, 10.0.0.2"}},
+			"10.0.0.3",
+		},
+		{
+			"garbage stops the walk", "10.0.0.1:4000",
+			map[string][]string{HeaderXForwardedFor: {"198.51.100.1, not-an-ip, 10.0.0.2"}},
+			"10.0.0.2",
+		},
+		{
+			"unparsable chain falls through", "10.0.0.1:4000",
+			map[string][]string{HeaderXForwardedFor: {"not-an-ip"}, HeaderXRealIP: {"198.51.100.9"}},
+			"198.51.100.9",
+		},
+		{
+			"forwarded takes precedence", "10.0.0.1:4000",
+			map[string][]string{HeaderForwarded: {"for=198.51.100.5;proto=https"}, HeaderXForwardedFor: {"198.51.100.1"}},
+			"198.51.100.5",
+		},
+		{
+			"forwarded bracketed IPv6 with port", "10.0.0.1:4000",
+			map[string][]string{HeaderForwarded: {`for="[2001:db8:2::7]:4711", for=10.0.0.2`}},
+			"2001:db8:2::7",
+		},
+		{
+			"forwarded parameters in any order and case", "10.0.0.1:4000",
+			map[string][]string{HeaderForwarded: {"proto=https;For=198.51.100.6;by=10.0.0.2"}},
+			"198.51.100.6",
+		},
+		{
+			"forwarded element without for stops the walk", "10.0.0.1:4000",
+			map[string][]string{HeaderForwarded: {"for=1.2.3.4, proto=https, for=10.0.0.2"}},
+			"10.0.0.2",
+		},
+		{
+			"forwarded obfuscated node", "10.0.0.1:4000",
+			map[string][]string{HeaderForwarded: {"for=_hidden"}, HeaderXForwardedFor: {"198.51.100.1"}},
+			"198.51.100.1",
+		},
+		{
+			"trusted IPv6 proxy", "[2001:db8:1::1]:443",
+			map[string][]string{HeaderXForwardedFor: {"2001:db8:2::1, 2001:db8:1::2"}},
+			"2001:db8:2::1",
+		},
+		{
+			"IPv4-mapped peer is trusted", "[::ffff:10.0.0.1]:4000",
+			map[string][]string{HeaderXForwardedFor: {"::ffff:198.51.100.1"}},
+			"198.51.100.1",
+		},
+		{"unparsable remote address", "pipe", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/", http.NoBody)
+			require.NoError(t, err)
+			req.RemoteAddr = tt.remote
+			for name, values := range tt.headers {
+				for _, value := range values {
+					req.Header.Add(name, value)
+				}
+			}
+			assert.Equal(t, tt.want, resolver.Resolve(req))
+		})
+	}
+}
+
+func TestResolveHeaderOrder(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8"}, []string{HeaderXRealIP})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "/", http.NoBody)
+	require.NoError(t, err)
+	req.RemoteAddr = "10.0.0.1:4000"
+	// Headers that are not configured are ignored.
+	req.Header.Set(HeaderXForwardedFor, "198.51.100.1")
+	assert.Equal(t, "10.0.0.1", resolver.Resolve(req))
+	req.Header.Set(HeaderXRealIP, "198.51.100.2")
+	assert.Equal(t, "198.51.100.2", resolver.Resolve(req))
+}
+
+func TestNewResolverErrors(t *testing.T) {
+	_, err := NewResolver([]string{"10.0.0.0/33"}, nil)
+	assert.Error(t, err)
+	_, err = NewResolver([]string{"proxy.example"}, nil)
+	assert.Error(t, err)
+}
+
+func TestIsTrusted(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8"}, nil)
+	require.NoError(t, err)
+	assert.True(t, resolver.IsTrusted(netip.MustParseAddr("10.1.2.3")))
+	assert.True(t, resolver.IsTrusted(netip.MustParseAddr("::ffff:10.1.2.3")))
+	assert.False(t, resolver.IsTrusted(netip.MustParseAddr("11.0.0.1")))
+}
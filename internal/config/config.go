@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,11 +17,69 @@ type Config struct {
 	RedisAddr         string
 	RedisPassword     string
 	ShortCodeLength   int
+	CodeStrategy      string
+	CodeRangeSize     int
+	SnowflakeNodeID   int
+	CodeHashKey       string
+	CodeCounterKey    string
 	RateLimitMax      int
 	RateLimitWindow   time.Duration
 	BloomN            uint
 	BloomP            float64
 	Domain            string
+	IPAllowlist       []string
+	IPDenylist        []string
+	IPAccessRefresh   time.Duration
+	TrustedProxies    []string
+	ClientIPHeaders   []string
+	ProxyProtocol     bool
+	// APIKeys are "<name>:<key>" entries accepted by endpoints that require an API key.
+	APIKeys []string
+	// URL destination policy
+	URLBlockPrivateAddresses bool
+	URLBlockNonDefaultPorts  bool
+	URLResolveHosts          bool
+	// URL normalization
+	URLStripTrackingParams bool
+	URLTrackingParams      []string
+	URLSortQuery           bool
+	// Abuse blocklist
+	BlocklistFiles  []string
+	BlocklistReload time.Duration
+	BlocklistRescan time.Duration
+	// Custom alias rules
+	AliasMinLength    int
+	AliasMaxLength    int
+	AliasCharset      string
+	AliasWordListFile string
+	// Batch shortening
+	BatchMaxSize     int
+	BatchConcurrency int
+	// Async bulk jobs
+	JobWorkers        int
+	JobPollInterval   time.Duration
+	JobLease          time.Duration
+	JobMaxAttempts    int
+	JobRetention      time.Duration
+	JobMaxUploadBytes int64
+	// Analytics rollups
+	AnalyticsRollupInterval time.Duration
+	AnalyticsRollupGrace    time.Duration
+	AnalyticsIPMode         string
+	AnalyticsVisitorDays    int
+	AnalyticsLiveSummary    time.Duration
+	// GeoIP enrichment
+	GeoIPFile    string
+	GeoIPASNFile string
+	GeoIPReload  time.Duration
+	// Referrer classification
+	ReferrerRulesFile   string
+	ReferrerRulesReload time.Duration
+	// Bot detection
+	BotIPRangesFile   string
+	BotIPRangesReload time.Duration
+	BotBurstClicks    int
+	BotBurstWindow    time.Duration
 }
 
 // Load reads configuration from environment variables and returns a Config instance.
@@ -39,17 +98,134 @@ func Load() (*Config, error) {
 		RedisAddr:         getEnv("REDIS_ADDR", "localhost:6379"),
 		RedisPassword:     getEnv("REDIS_PASSWORD", ""),
 		ShortCodeLength:   getEnvInt("SHORT_CODE_LENGTH", 8),
+		CodeStrategy:      getEnv("CODE_STRATEGY", "random"),
+		CodeRangeSize:     getEnvInt("CODE_RANGE_SIZE", 1000),
+		SnowflakeNodeID:   getEnvInt("SNOWFLAKE_NODE_ID", 0),
+		CodeHashKey:       getEnv("CODE_HASH_KEY", ""),
+		CodeCounterKey:    getEnv("CODE_COUNTER_KEY", ""),
 		RateLimitMax:      getEnvInt("RATE_LIMIT_MAX", 100),
 		RateLimitWindow:   time.Duration(getEnvInt("RATE_LIMIT_WINDOW_SECONDS", 60)) * time.Second,
 		BloomN:            uint(getEnvInt("BLOOM_N", 1000000)), //nolint:gosec // G115: Bloom filter size is configurable and validated
 		BloomP:            getEnvFloat("BLOOM_P", 0.001),
 		Domain:            getEnv("DOMAIN", "http://localhost:8080"),
+		IPAllowlist:       getEnvList("IP_ALLOWLIST"),
+		IPDenylist:        getEnvList("IP_DENYLIST"),
+		IPAccessRefresh:   time.Duration(getEnvInt("IP_ACCESS_REFRESH_SECONDS", 60)) * time.Second,
+		TrustedProxies:    getEnvList("TRUSTED_PROXIES"),
+		ClientIPHeaders:   getEnvList("CLIENT_IP_HEADERS"),
+		ProxyProtocol:     getEnvBool("PROXY_PROTOCOL", false),
+		APIKeys:           getEnvList("API_KEYS"),
+
+		URLBlockPrivateAddresses: getEnvBool("URL_BLOCK_PRIVATE_ADDRESSES", true),
+		URLBlockNonDefaultPorts:  getEnvBool("URL_BLOCK_NON_DEFAULT_PORTS", false),
+		URLResolveHosts:          getEnvBool("URL_RESOLVE_HOSTS", true),
+
+		URLStripTrackingParams: getEnvBool("URL_STRIP_TRACKING_PARAMS", false),
+		URLTrackingParams:      getEnvList("URL_TRACKING_PARAMS"),
+		URLSortQuery:           getEnvBool("URL_SORT_QUERY", false),
+
+		BlocklistFiles:  getEnvList("BLOCKLIST_FILES"),
+		BlocklistReload: time.Duration(getEnvInt("BLOCKLIST_RELOAD_SECONDS", 60)) * time.Second,
+		BlocklistRescan: time.Duration(getEnvInt("BLOCKLIST_RESCAN_SECONDS", 3600)) * time.Second,
+
+		AliasMinLength:    getEnvInt("ALIAS_MIN_LENGTH", 3),
+		AliasMaxLength:    getEnvInt("ALIAS_MAX_LENGTH", 64),
+		AliasCharset:      getEnv("ALIAS_CHARSET", "a-zA-Z0-9_-"),
+		AliasWordListFile: getEnv("ALIAS_WORDLIST_FILE", ""),
+
+		BatchMaxSize:     getEnvInt("BATCH_MAX_SIZE", 100),
+		BatchConcurrency: getEnvInt("BATCH_CONCURRENCY", 8),
+
+		JobWorkers:        getEnvInt("JOB_WORKERS", 2),
+		JobPollInterval:   time.Duration(getEnvInt("JOB_POLL_INTERVAL_SECONDS", 2)) * time.Second,
+		JobLease:          time.Duration(getEnvInt("JOB_LEASE_SECONDS", 60)) * time.Second,
+		JobMaxAttempts:    getEnvInt("JOB_MAX_ATTEMPTS", 3),
+		JobRetention:      time.Duration(getEnvInt("JOB_RETENTION_HOURS", 168)) * time.Hour,
+		JobMaxUploadBytes: int64(getEnvInt("JOB_MAX_UPLOAD_BYTES", 100*1024*1024)),
+
+		AnalyticsRollupInterval: time.Duration(getEnvInt("ANALYTICS_ROLLUP_SECONDS", 300)) * time.Second,
+		AnalyticsRollupGrace:    time.Duration(getEnvInt("ANALYTICS_ROLLUP_GRACE_SECONDS", 300)) * time.Second,
+		AnalyticsIPMode:         getEnv("ANALYTICS_IP_MODE", "full"),
+		AnalyticsVisitorDays:    getEnvInt("ANALYTICS_VISITOR_RETENTION_DAYS", 400),
+		AnalyticsLiveSummary:    time.Duration(getEnvInt("ANALYTICS_LIVE_SUMMARY_SECONDS", 10)) * time.Second,
+
+		GeoIPFile:    getEnv("GEOIP_DB_FILE", ""),
+		GeoIPASNFile: getEnv("GEOIP_ASN_DB_FILE", ""),
+		GeoIPReload:  time.Duration(getEnvInt("GEOIP_RELOAD_SECONDS", 60)) * time.Second,
+
+		ReferrerRulesFile:   getEnv("REFERRER_RULES_FILE", ""),
+		ReferrerRulesReload: time.Duration(getEnvInt("REFERRER_RULES_RELOAD_SECONDS", 60)) * time.Second,
+
+		BotIPRangesFile:   getEnv("BOT_IP_RANGES_FILE", ""),
+		BotIPRangesReload: time.Duration(getEnvInt("BOT_IP_RANGES_RELOAD_SECONDS", 60)) * time.Second,
+		BotBurstClicks:    getEnvInt("BOT_BURST_CLICKS", 20),
+		BotBurstWindow:    time.Duration(getEnvInt("BOT_BURST_WINDOW_SECONDS", 60)) * time.Second,
+	}
+
+	if cfg.ClientIPHeaders == nil {
+		cfg.ClientIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
 	}
 
 	if cfg.ShortCodeLength < 4 || cfg.ShortCodeLength > 20 {
 		return nil, fmt.Errorf("SHORT_CODE_LENGTH must be between 4 and 20")
 	}
 
+	if cfg.AliasMinLength < 1 || cfg.AliasMaxLength > 255 || cfg.AliasMinLength > cfg.AliasMaxLength {
+		return nil, fmt.Errorf("ALIAS_MIN_LENGTH and ALIAS_MAX_LENGTH must satisfy 1 <= min <= max <= 255")
+	}
+
+	// Atomic batches are inserted in one statement, bounded by Postgres' 65535 bind parameters.
+	if cfg.BatchMaxSize < 1 || cfg.BatchMaxSize > 5000 {
+		return nil, fmt.Errorf("BATCH_MAX_SIZE must be between 1 and 5000")
+	}
+
+	if cfg.BatchConcurrency < 1 {
+		return nil, fmt.Errorf("BATCH_CONCURRENCY must be at least 1")
+	}
+
+	if cfg.JobWorkers < 0 {
+		return nil, fmt.Errorf("JOB_WORKERS must not be negative")
+	}
+
+	// Leases are renewed every third of their duration.
+	if cfg.JobPollInterval < time.Second || cfg.JobLease < 3*time.Second {
+		return nil, fmt.Errorf("JOB_POLL_INTERVAL_SECONDS must be at least 1 and JOB_LEASE_SECONDS at least 3")
+	}
+
+	if cfg.JobMaxAttempts < 1 {
+		return nil, fmt.Errorf("JOB_MAX_ATTEMPTS must be at least 1")
+	}
+
+	if cfg.GeoIPASNFile != "" && cfg.GeoIPFile == "" {
+		return nil, fmt.Errorf("GEOIP_ASN_DB_FILE requires GEOIP_DB_FILE")
+	}
+
+	if cfg.AnalyticsRollupGrace < 0 {
+		return nil, fmt.Errorf("ANALYTICS_ROLLUP_GRACE_SECONDS must not be negative")
+	}
+
+	if cfg.AnalyticsVisitorDays < 0 {
+		return nil, fmt.Errorf("ANALYTICS_VISITOR_RETENTION_DAYS must not be negative")
+	}
+
+	if cfg.AnalyticsLiveSummary < time.Second {
+		return nil, fmt.Errorf("ANALYTICS_LIVE_SUMMARY_SECONDS must be at least 1")
+	}
+
+	if cfg.BotBurstClicks < 0 {
+		return nil, fmt.Errorf("BOT_BURST_CLICKS must not be negative")
+	}
+
+	if cfg.BotBurstClicks > 0 && cfg.BotBurstWindow < time.Second {
+		return nil, fmt.Errorf("BOT_BURST_WINDOW_SECONDS must be at least 1")
+	}
+
+	switch cfg.AnalyticsIPMode {
+	case "full", "truncated", "hash":
+	default:
+		return nil, fmt.Errorf("ANALYTICS_IP_MODE must be full, truncated or hash")
+	}
+
 	return cfg, nil
 }
 
@@ -60,6 +236,20 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvList(key string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -69,6 +259,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvFloat(key string, defaultValue float64) float64 {
 	if value := os.Getenv(key); value != "" {
 		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
@@ -15,6 +15,18 @@ const (
 	ErrCodeInvalidURLFormat ErrorCode = "ERR_INVALID_URL_FORMAT"
 	// ErrCodeInvalidURLScheme indicates an invalid URL scheme error.
 	ErrCodeInvalidURLScheme ErrorCode = "ERR_INVALID_URL_SCHEME"
+	// ErrCodeURLCredentials indicates that a URL embeds user credentials.
+	ErrCodeURLCredentials ErrorCode = "ERR_URL_CREDENTIALS"
+	// ErrCodeURLPrivateAddress indicates that a URL points at a private, loopback or link-local address.
+	ErrCodeURLPrivateAddress ErrorCode = "ERR_URL_PRIVATE_ADDRESS"
+	// ErrCodeURLUnresolvableHost indicates that a URL host could not be resolved.
+	ErrCodeURLUnresolvableHost ErrorCode = "ERR_URL_UNRESOLVABLE_HOST"
+	// ErrCodeURLPortNotAllowed indicates that a URL uses a non-default port.
+	ErrCodeURLPortNotAllowed ErrorCode = "ERR_URL_PORT_NOT_ALLOWED"
+	// ErrCodeURLSelfReference indicates that a URL points back at the shortener itself.
+	ErrCodeURLSelfReference ErrorCode = "ERR_URL_SELF_REFERENCE"
+	// ErrCodeURLBlocked indicates that a URL matches the abuse blocklist.
+	ErrCodeURLBlocked ErrorCode = "ERR_URL_BLOCKED"
 
 	// ErrCodeNotFound indicates a resource not found error.
 	ErrCodeNotFound ErrorCode = "ERR_NOT_FOUND"
@@ -23,6 +35,62 @@ const (
 	ErrCodeConflict ErrorCode = "ERR_CONFLICT"
 	// ErrCodeAliasExists indicates that an alias already exists.
 	ErrCodeAliasExists ErrorCode = "ERR_ALIAS_EXISTS"
+	// ErrCodeAliasLength indicates that an alias is too short or too long.
+	ErrCodeAliasLength ErrorCode = "ERR_ALIAS_LENGTH"
+	// ErrCodeAliasCharset indicates that an alias contains disallowed characters.
+	ErrCodeAliasCharset ErrorCode = "ERR_ALIAS_CHARSET"
+	// ErrCodeAliasReserved indicates that an alias is reserved for system routes.
+	ErrCodeAliasReserved ErrorCode = "ERR_ALIAS_RESERVED"
+	// ErrCodeAliasOffensive indicates that an alias contains a disallowed word.
+	ErrCodeAliasOffensive ErrorCode = "ERR_ALIAS_OFFENSIVE"
+
+	// ErrCodeBatchTooLarge indicates that a batch exceeds the configured maximum size.
+	ErrCodeBatchTooLarge ErrorCode = "ERR_BATCH_TOO_LARGE"
+	// ErrCodeBatchMode indicates an unknown batch mode.
+	ErrCodeBatchMode ErrorCode = "ERR_BATCH_MODE"
+	// ErrCodeBatchAborted indicates that an item was rolled back because another item of an atomic batch failed.
+	ErrCodeBatchAborted ErrorCode = "ERR_BATCH_ABORTED"
+	// ErrCodeInvalidTags indicates that tags are too long or too many.
+	ErrCodeInvalidTags ErrorCode = "ERR_INVALID_TAGS"
+	// ErrCodeTitleTooLong indicates that a link title exceeds the maximum length.
+	ErrCodeTitleTooLong ErrorCode = "ERR_TITLE_TOO_LONG"
+	// ErrCodeDescriptionTooLong indicates that a link description exceeds the maximum length.
+	ErrCodeDescriptionTooLong ErrorCode = "ERR_DESCRIPTION_TOO_LONG"
+	// ErrCodeInvalidMetadata indicates that link metadata is not a JSON object or is too large.
+	ErrCodeInvalidMetadata ErrorCode = "ERR_INVALID_METADATA"
+	// ErrCodeInvalidCursor indicates a malformed pagination cursor.
+	ErrCodeInvalidCursor ErrorCode = "ERR_INVALID_CURSOR"
+	// ErrCodeInvalidGroupBy indicates an unsupported analytics grouping.
+	ErrCodeInvalidGroupBy ErrorCode = "ERR_INVALID_GROUP_BY"
+	// ErrCodeInvalidInterval indicates an unsupported time series interval.
+	ErrCodeInvalidInterval ErrorCode = "ERR_INVALID_INTERVAL"
+	// ErrCodeInvalidTimeRange indicates a malformed or empty analytics time range.
+	ErrCodeInvalidTimeRange ErrorCode = "ERR_INVALID_TIME_RANGE"
+	// ErrCodeInvalidTimezone indicates an unknown time zone name.
+	ErrCodeInvalidTimezone ErrorCode = "ERR_INVALID_TIMEZONE"
+	// ErrCodeInvalidIncludeBots indicates an include_bots value that is not a boolean.
+	ErrCodeInvalidIncludeBots ErrorCode = "ERR_INVALID_INCLUDE_BOTS"
+	// ErrCodeSeriesTooLong indicates a time series with too many buckets.
+	ErrCodeSeriesTooLong ErrorCode = "ERR_SERIES_TOO_LONG"
+	// ErrCodeInvalidCampaign indicates a missing or too long campaign name.
+	ErrCodeInvalidCampaign ErrorCode = "ERR_INVALID_CAMPAIGN"
+	// ErrCodeImportRecord indicates that an import record could not be parsed.
+	ErrCodeImportRecord ErrorCode = "ERR_IMPORT_RECORD"
+	// ErrCodeImportFormat indicates an unsupported bulk file format.
+	ErrCodeImportFormat ErrorCode = "ERR_IMPORT_FORMAT"
+	// ErrCodeExportFormat indicates an unsupported export file format.
+	ErrCodeExportFormat ErrorCode = "ERR_EXPORT_FORMAT"
+	// ErrCodeInvalidExportField indicates an unknown field in an export field selection.
+	ErrCodeInvalidExportField ErrorCode = "ERR_INVALID_EXPORT_FIELD"
+	// ErrCodeExpiryInPast indicates that an absolute expiry lies in the past.
+	ErrCodeExpiryInPast ErrorCode = "ERR_EXPIRY_IN_PAST"
+
+	// ErrCodeJobNotReady indicates that a job result was requested before the job finished.
+	ErrCodeJobNotReady ErrorCode = "ERR_JOB_NOT_READY"
+	// ErrCodeJobAbandoned indicates that a job was given up after its workers stopped responding.
+	ErrCodeJobAbandoned ErrorCode = "ERR_JOB_ABANDONED"
+	// ErrCodeUploadTooLarge indicates that an upload exceeds the configured maximum size.
+	ErrCodeUploadTooLarge ErrorCode = "ERR_UPLOAD_TOO_LARGE"
 
 	// ErrCodeExpired indicates that a resource has expired.
 	ErrCodeExpired ErrorCode = "ERR_EXPIRED"
@@ -31,6 +99,8 @@ const (
 	ErrCodeUnauthorized ErrorCode = "ERR_UNAUTHORIZED"
 	// ErrCodeForbidden indicates a forbidden access error.
 	ErrCodeForbidden ErrorCode = "ERR_FORBIDDEN"
+	// ErrCodeAPIKeyRequired indicates a request without a valid API key to an endpoint that needs one.
+	ErrCodeAPIKeyRequired ErrorCode = "ERR_API_KEY_REQUIRED"
 
 	// ErrCodeInternal indicates an internal server error.
 	ErrCodeInternal ErrorCode = "ERR_INTERNAL"
@@ -43,4 +113,7 @@ const (
 	ResourceURL       = "URL"
 	ResourceShortCode = "ShortCode"
 	ResourceAlias     = "Alias"
+	ResourceJob       = "Job"
+	ResourceCampaign  = "Campaign"
+	ResourceVersion   = "Version"
 )
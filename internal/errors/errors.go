@@ -12,6 +12,7 @@ import (
 type ValidationError struct {
 	code    ErrorCode
 	message string
+	data    map[string]interface{}
 }
 
 // Ensure ValidationError implements CodedError
@@ -34,6 +35,11 @@ func (e *ValidationError) Code() ErrorCode {
 	return e.code
 }
 
+// TemplateData returns the data used to render the translated message.
+func (e *ValidationError) TemplateData() map[string]interface{} {
+	return e.data
+}
+
 // NotFoundError represents a 404 Not Found error.
 type NotFoundError struct {
 	errorCode ErrorCode
@@ -66,6 +72,7 @@ func (e *NotFoundError) Code() ErrorCode {
 type ConflictError struct {
 	code    ErrorCode
 	message string
+	data    map[string]interface{}
 }
 
 // Ensure ConflictError implements CodedError
@@ -88,6 +95,11 @@ func (e *ConflictError) Code() ErrorCode {
 	return e.code
 }
 
+// TemplateData returns the data used to render the translated message.
+func (e *ConflictError) TemplateData() map[string]interface{} {
+	return e.data
+}
+
 // GoneError represents a 410 Gone error (resource expired).
 type GoneError struct {
 	code    ErrorCode
@@ -114,6 +126,65 @@ func (e *GoneError) Code() ErrorCode {
 	return e.code
 }
 
+// ForbiddenError represents a 403 Forbidden error.
+type ForbiddenError struct {
+	code    ErrorCode
+	message string
+	data    map[string]interface{}
+}
+
+// Ensure ForbiddenError implements CodedError
+var _ CodedError = (*ForbiddenError)(nil)
+
+// NewForbiddenError creates a new forbidden error with a message.
+func NewForbiddenError(message string) *ForbiddenError {
+	return &ForbiddenError{
+		code:    ErrCodeForbidden,
+		message: message,
+	}
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.message
+}
+
+// Code returns the error code.
+func (e *ForbiddenError) Code() ErrorCode {
+	return e.code
+}
+
+// TemplateData returns the data used to render the translated message.
+func (e *ForbiddenError) TemplateData() map[string]interface{} {
+	return e.data
+}
+
+// UnauthorizedError represents a 401 Unauthorized error.
+type UnauthorizedError struct {
+	code    ErrorCode
+	message string
+}
+
+// Ensure UnauthorizedError implements CodedError
+var _ CodedError = (*UnauthorizedError)(nil)
+
+// Unauthorized creates a new UnauthorizedError with an error code.
+// The message will be translated in the error handler based on request language.
+func Unauthorized(code ErrorCode) *UnauthorizedError {
+	return &UnauthorizedError{code: code}
+}
+
+func (e *UnauthorizedError) Error() string {
+	if e.message != "" {
+		return e.message
+	}
+	return string(e.code)
+}
+
+// Code returns the error code.
+func (e *UnauthorizedError) Code() ErrorCode {
+	return e.code
+}
+
 // InvalidError represents a validation/invalid input error.
 type InvalidError struct {
 	Code    ErrorCode
@@ -264,6 +335,37 @@ func ExpiredWithMessage(message string) *DomainExpiredError {
 	}
 }
 
+// DomainForbiddenError represents a domain-specific forbidden access error (e.g., blocked resource).
+type DomainForbiddenError struct {
+	Code    ErrorCode
+	Message string
+	Data    map[string]interface{}
+}
+
+func (e *DomainForbiddenError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return string(e.Code)
+}
+
+// GetCode returns the error code for i18n translation.
+func (e *DomainForbiddenError) GetCode() ErrorCode {
+	if e.Code != "" {
+		return e.Code
+	}
+	return ErrCodeForbidden
+}
+
+// Forbidden creates a new DomainForbiddenError with an error code and optional context data.
+// The message will be translated in the error handler based on request language.
+func Forbidden(code ErrorCode, data map[string]interface{}) *DomainForbiddenError {
+	return &DomainForbiddenError{
+		Code: code,
+		Data: data,
+	}
+}
+
 // StatusCode returns the HTTP status code for an error.
 // It checks if the error implements CodedError interface or is a known error type.
 // It also checks for typed domain errors (like app.InvalidError) and maps them appropriately.
@@ -283,6 +385,8 @@ func StatusCode(err error) int {
 		return 409
 	case "*errors.DomainExpiredError":
 		return 410 // Gone
+	case "*errors.DomainForbiddenError":
+		return 403
 	}
 
 	// Check for GoneError (410)
@@ -291,6 +395,18 @@ func StatusCode(err error) int {
 		return 410
 	}
 
+	// Check for ForbiddenError
+	var forbiddenErr *ForbiddenError
+	if errors.As(err, &forbiddenErr) {
+		return 403
+	}
+
+	// Check for UnauthorizedError
+	var unauthorizedErr *UnauthorizedError
+	if errors.As(err, &unauthorizedErr) {
+		return 401
+	}
+
 	// Check for ValidationError
 	var validationErr *ValidationError
 	if errors.As(err, &validationErr) {
@@ -356,6 +472,7 @@ func ConvertError(err error) error {
 		return &ValidationError{
 			code:    code,
 			message: "", // Empty message - handler will translate based on code
+			data:    invalidErr.Data,
 		}
 	case "*errors.DomainNotFoundError":
 		// Extract resource name from DomainNotFoundError struct using reflection
@@ -379,6 +496,7 @@ func ConvertError(err error) error {
 		return &ConflictError{
 			code:    code,
 			message: "", // Empty message - handler will translate based on code
+			data:    conflictErr.Data,
 		}
 	case "*errors.DomainExpiredError":
 		// Use ERR_EXPIRED code for translation
@@ -386,6 +504,14 @@ func ConvertError(err error) error {
 			code:    ErrCodeExpired,
 			message: "", // Empty message - handler will translate based on code
 		}
+	case "*errors.DomainForbiddenError":
+		// Extract error code and data from DomainForbiddenError
+		forbiddenErr := err.(*DomainForbiddenError)
+		return &ForbiddenError{
+			code:    forbiddenErr.GetCode(),
+			message: "", // Empty message - handler will translate based on code
+			data:    forbiddenErr.Data,
+		}
 	}
 
 	// Fallback to message-based pattern matching for legacy errors
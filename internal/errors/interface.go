@@ -7,6 +7,12 @@ type CodedError interface {
 	Code() ErrorCode
 }
 
+// TemplatedError is an interface for errors that carry data for their translated message.
+type TemplatedError interface {
+	error
+	TemplateData() map[string]interface{}
+}
+
 // GetErrorCode extracts the error code from an error if it implements CodedError.
 func GetErrorCode(err error) (ErrorCode, bool) {
 	if codedErr, ok := err.(CodedError); ok {
@@ -15,6 +15,35 @@ const (
 	DefaultLanguage = i18n.DefaultLanguage
 )
 
+// Localize converts err to a generic error and returns its code and the message translated into lang.
+// Errors without a code keep their own message.
+func Localize(err error, lang Language) (ErrorCode, string) {
+	err = ConvertError(err)
+	code, ok := GetErrorCode(err)
+	if !ok {
+		return "", err.Error()
+	}
+
+	// Handle special case for NotFoundError with resource
+	if notFoundErr, ok := err.(*NotFoundError); ok {
+		return code, GetMessage(code, lang, map[string]interface{}{"Resource": notFoundErr.Resource})
+	}
+	if templatedErr, ok := err.(TemplatedError); ok && templatedErr.TemplateData() != nil {
+		// Render the message with the context data attached to the error
+		return code, GetMessage(code, lang, templatedErr.TemplateData())
+	}
+	return code, GetMessage(code, lang)
+}
+
+// LocalizeForClient is Localize for errors reported to API clients outside the error middleware,
+// such as per-item batch and job errors: server errors are replaced by ErrCodeInternal.
+func LocalizeForClient(err error, lang Language) (ErrorCode, string) {
+	if StatusCode(ConvertError(err)) >= 500 {
+		return ErrCodeInternal, GetMessage(ErrCodeInternal, lang)
+	}
+	return Localize(err, lang)
+}
+
 // GetMessage returns the error message for a given error code and language using i18n.
 // If the language is not supported, it falls back to the default language.
 func GetMessage(code ErrorCode, lang Language, args ...interface{}) string {
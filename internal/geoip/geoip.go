@@ -0,0 +1,186 @@
+// Package geoip resolves IP addresses to locations and networks from MaxMind-format (MMDB)
+// database files on disk, without any network lookup.
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+
+	"url-shorterner/internal/log"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location is what the databases know about an IP address. Unknown fields are empty.
+type Location struct {
+	// Country is the ISO 3166-1 alpha-2 country code.
+	Country string
+	// Region is the English name of the first subdivision, e.g. a state.
+	Region string
+	// City is the English city name.
+	City string
+	// ASN is the autonomous system number, 0 if unknown.
+	ASN uint32
+	// ASOrg is the organization of the autonomous system.
+	ASOrg string
+}
+
+// cityRecord is the part of a City, Country or ASN database record that Lookup reads.
+type cityRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	ASN   uint32 `maxminddb:"autonomous_system_number"`
+	ASOrg string `maxminddb:"autonomous_system_organization"`
+}
+
+// DB is a hot-reloadable pair of a City (or Country) database and an optional ASN database.
+// A nil *DB resolves nothing.
+type DB struct {
+	cityPath string
+	asnPath  string
+
+	mu       sync.RWMutex
+	city     *maxminddb.Reader
+	asn      *maxminddb.Reader
+	modTimes map[string]time.Time
+}
+
+// NewDB creates a database backed by the given files; asnPath may be empty.
+// Call Reload (or Start) to load the files before use.
+func NewDB(cityPath, asnPath string) *DB {
+	return &DB{
+		cityPath: cityPath,
+		asnPath:  asnPath,
+		modTimes: make(map[string]time.Time),
+	}
+}
+
+// Reload reads the database files and atomically swaps them in.
+// If any file fails to load, the previous databases are kept.
+func (db *DB) Reload() error {
+	modTimes := make(map[string]time.Time, 2)
+	city, err := loadFile(db.cityPath, modTimes)
+	if err != nil {
+		return err
+	}
+	var asn *maxminddb.Reader
+	if db.asnPath != "" {
+		if asn, err = loadFile(db.asnPath, modTimes); err != nil {
+			return err
+		}
+	}
+
+	db.mu.Lock()
+	db.city = city
+	db.asn = asn
+	db.modTimes = modTimes
+	db.mu.Unlock()
+
+	log.Info("GeoIP database loaded: %s", city.Metadata.DatabaseType)
+	return nil
+}
+
+// Start checks the database files every interval and reloads them when any has changed.
+func (db *DB) Start(ctx context.Context, interval time.Duration) {
+	if db == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !db.changed() {
+					continue
+				}
+				if err := db.Reload(); err != nil {
+					log.Error("GeoIP reload failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Lookup resolves ip. Invalid, private and unknown addresses yield an empty Location.
+func (db *DB) Lookup(ip string) Location {
+	var location Location
+	if db == nil {
+		return location
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || addr.IsPrivate() || addr.IsLoopback() || addr.IsUnspecified() {
+		return location
+	}
+
+	db.mu.RLock()
+	city, asn := db.city, db.asn
+	db.mu.RUnlock()
+
+	ip4or6 := net.IP(addr.Unmap().AsSlice())
+	if city != nil {
+		var record cityRecord
+		if err := city.Lookup(ip4or6, &record); err == nil {
+			location.Country = record.Country.ISOCode
+			if len(record.Subdivisions) > 0 {
+				location.Region = record.Subdivisions[0].Names["en"]
+			}
+			location.City = record.City.Names["en"]
+			// ASN fields are also present in combined databases.
+			location.ASN = record.ASN
+			location.ASOrg = record.ASOrg
+		}
+	}
+	if asn != nil {
+		var record cityRecord
+		if err := asn.Lookup(ip4or6, &record); err == nil && record.ASN != 0 {
+			location.ASN = record.ASN
+			location.ASOrg = record.ASOrg
+		}
+	}
+	return location
+}
+
+func (db *DB) changed() bool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for path, modTime := range db.modTimes {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadFile(path string, modTimes map[string]time.Time) (*maxminddb.Reader, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat GeoIP database %s: %w", path, err)
+	}
+	buf, err := os.ReadFile(path) //nolint:gosec // G304: GeoIP paths come from trusted configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoIP database %s: %w", path, err)
+	}
+	reader, err := maxminddb.FromBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GeoIP database %s: %w", path, err)
+	}
+	modTimes[path] = info.ModTime()
+	return reader, nil
+}
@@ -0,0 +1,93 @@
+package geoip
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The testdata databases were written with github.com/maxmind/mmdbwriter:
+//   - city.mmdb (24-bit records): 81.2.69.0/24 and 81.2.70.0/24 share one London record, so the
+//     second network points to the first one's data; 2001:db8:1::/48 is Stockholm.
+//   - city-v2.mmdb (28-bit records): 81.2.69.0/24 is Paris.
+//   - asn.mmdb (32-bit records): AS64500 for 81.2.69.0/24 and AS64501 for 2001:db8:1::/48.
+
+func TestLookup(t *testing.T) {
+	db := NewDB(filepath.Join("testdata", "city.mmdb"), filepath.Join("testdata", "asn.mmdb"))
+	require.NoError(t, db.Reload())
+
+	london := Location{Country: "GB", Region: "England", City: "London"}
+	tests := []struct {
+		ip   string
+		want Location
+	}{
+		{"81.2.69.160", Location{Country: "GB", Region: "England", City: "London", ASN: 64500, ASOrg: "Example Net"}},
+		{"81.2.70.1", london},
+		{"::ffff:81.2.69.160", Location{Country: "GB", Region: "England", City: "London", ASN: 64500, ASOrg: "Example Net"}},
+		{"2001:db8:1::1", Location{Country: "SE", Region: "Stockholm", City: "Stockholm", ASN: 64501, ASOrg: "Example Six"}},
+		{"2001:db8:2::1", Location{}},
+		{"8.8.8.8", Location{}},
+		{"10.0.0.1", Location{}},
+		{"127.0.0.1", Location{}},
+		{"not-an-ip", Location{}},
+		{"", Location{}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, db.Lookup(tt.ip), tt.ip)
+	}
+}
+
+func TestLookupNilDB(t *testing.T) {
+	var db *DB
+	assert.Equal(t, Location{}, db.Lookup("81.2.69.160"))
+}
+
+func TestReloadKeepsDatabasesOnError(t *testing.T) {
+	path := copyDatabase(t, "city.mmdb")
+	db := NewDB(path, "")
+	require.NoError(t, db.Reload())
+
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0o600))
+	assert.Error(t, db.Reload())
+	assert.Equal(t, "GB", db.Lookup("81.2.69.160").Country)
+
+	assert.Error(t, NewDB(filepath.Join(t.TempDir(), "missing.mmdb"), "").Reload())
+}
+
+func TestStartReloadsChangedDatabases(t *testing.T) {
+	path := copyDatabase(t, "city.mmdb")
+	db := NewDB(path, "")
+	require.NoError(t, db.Reload())
+	assert.False(t, db.changed())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db.Start(ctx, 10*time.Millisecond)
+
+	buf, err := os.ReadFile(filepath.Join("testdata", "city-v2.mmdb"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, buf, 0o600))
+	// File systems with coarse timestamps may not see the rewrite as a change.
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, future, future))
+
+	assert.Eventually(t, func() bool {
+		return db.Lookup("81.2.69.160").City == "Paris"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, Location{}, db.Lookup("2001:db8:1::1"))
+}
+
+// copyDatabase copies a testdata database to a temporary file and returns its path.
+func copyDatabase(t *testing.T, name string) string {
+	t.Helper()
+	buf, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, buf, 0o600))
+	return path
+}
@@ -2,6 +2,9 @@
 package http
 
 import (
+	"strings"
+
+	"url-shorterner/internal/access"
 	"url-shorterner/internal/middleware"
 	"url-shorterner/internal/rate"
 
@@ -9,11 +12,31 @@ import (
 )
 
 // Router creates a router group with common middleware applied.
-func Router(router *gin.Engine, path string, limiter rate.Limiter) *gin.RouterGroup {
+func Router(router *gin.Engine, path string, limiter rate.Limiter, acl *access.List) *gin.RouterGroup {
 	group := router.Group(path)
 	group.Use(middleware.Logger())
-	group.Use(middleware.RateLimit(limiter))
+	group.Use(middleware.AccessControl(acl))
+	group.Use(middleware.RateLimit(limiter, acl))
 	group.Use(middleware.Prometheus())
 	group.Use(middleware.ErrorHandler())
 	return group
 }
+
+// RouteSegments returns the distinct static first path segments of all registered routes,
+// e.g. "shorten" for "/shorten/batch". Parameter and wildcard segments are skipped.
+func RouteSegments(router *gin.Engine) []string {
+	seen := make(map[string]struct{})
+	var segments []string
+	for _, route := range router.Routes() {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(route.Path, "/"), "/")
+		if segment == "" || segment[0] == ':' || segment[0] == '*' {
+			continue
+		}
+		if _, ok := seen[segment]; ok {
+			continue
+		}
+		seen[segment] = struct{}{}
+		segments = append(segments, segment)
+	}
+	return segments
+}
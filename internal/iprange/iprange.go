@@ -0,0 +1,204 @@
+// Package iprange matches IP addresses against named network ranges from a local file, such as
+// the published address ranges of search engine crawlers.
+package iprange
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shorterner/internal/log"
+)
+
+// Ranges maps networks to names, looked up by longest prefix.
+type Ranges struct {
+	// byBits maps a prefix length to the networks of that length.
+	byBits map[int]map[netip.Prefix]string
+	// bits lists the prefix lengths present, longest first.
+	bits []int
+	n    int
+}
+
+// Len returns the number of loaded networks.
+func (r *Ranges) Len() int {
+	return r.n
+}
+
+// Parse reads "<cidr> [name]" lines, e.g. "66.249.64.0/19 Googlebot"; the name defaults to the
+// network. Bare addresses are single-address networks. Blank lines and lines starting with "#"
+// are ignored.
+func Parse(r io.Reader) (*Ranges, error) {
+	ranges := &Ranges{byBits: make(map[int]map[netip.Prefix]string)}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		prefix, err := parsePrefix(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		name := strings.Join(fields[1:], " ")
+		if name == "" {
+			name = prefix.String()
+		}
+
+		networks, ok := ranges.byBits[prefix.Bits()]
+		if !ok {
+			networks = make(map[netip.Prefix]string)
+			ranges.byBits[prefix.Bits()] = networks
+			ranges.bits = append(ranges.bits, prefix.Bits())
+		}
+		if _, exists := networks[prefix]; !exists {
+			networks[prefix] = name
+			ranges.n++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// Longest prefixes first, so the most specific network names a match.
+	sort.Sort(sort.Reverse(sort.IntSlice(ranges.bits)))
+	return ranges, nil
+}
+
+// Match returns the name of the most specific network containing addr.
+func (r *Ranges) Match(addr netip.Addr) (string, bool) {
+	addr = addr.Unmap().WithZone("")
+	for _, bits := range r.bits {
+		if bits > addr.BitLen() {
+			continue
+		}
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			continue
+		}
+		if name, ok := r.byBits[bits][prefix]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// parsePrefix parses a CIDR network or a bare address, masking host bits.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked(), nil
+}
+
+// List is a hot-reloadable range file. A nil *List matches nothing.
+type List struct {
+	path string
+
+	mu      sync.RWMutex
+	ranges  *Ranges
+	modTime time.Time
+}
+
+// NewList creates a list backed by the file at path.
+// Call Reload (or Start) to load the file before use.
+func NewList(path string) *List {
+	return &List{
+		path:   path,
+		ranges: &Ranges{},
+	}
+}
+
+// Reload reads the file and atomically swaps it in.
+// If the file fails to load, the previous ranges are kept.
+func (l *List) Reload() error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat IP ranges %s: %w", l.path, err)
+	}
+	file, err := os.Open(l.path) //nolint:gosec // G304: Range paths come from trusted configuration
+	if err != nil {
+		return fmt.Errorf("failed to open IP ranges %s: %w", l.path, err)
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+
+	ranges, err := Parse(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse IP ranges %s: %w", l.path, err)
+	}
+
+	l.mu.Lock()
+	l.ranges = ranges
+	l.modTime = info.ModTime()
+	l.mu.Unlock()
+
+	log.Info("IP ranges loaded from %s: %d networks", l.path, ranges.Len())
+	return nil
+}
+
+// Start checks the file every interval and reloads it when it has changed.
+func (l *List) Start(ctx context.Context, interval time.Duration) {
+	if l == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !l.changed() {
+					continue
+				}
+				if err := l.Reload(); err != nil {
+					log.Error("IP ranges reload failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Match returns the name of the most specific network containing ip.
+// Invalid addresses match nothing.
+func (l *List) Match(ip string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", false
+	}
+
+	l.mu.RLock()
+	ranges := l.ranges
+	l.mu.RUnlock()
+	return ranges.Match(addr)
+}
+
+func (l *List) changed() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	info, err := os.Stat(l.path)
+	return err != nil || !info.ModTime().Equal(l.modTime)
+}
@@ -0,0 +1,91 @@
+package iprange
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRanges = `
+# Crawler networks
+66.249.64.0/19 Googlebot
+66.249.66.0/24 Googlebot Images
+66.249.66.1
+2001:4860:4801::/48 Googlebot
+2001:4860:4801:10::/64 Googlebot IPv6 Special
+::ffff:157.55.39.0/120 Bingbot
+192.0.2.77/16
+`
+
+func TestMatch(t *testing.T) {
+	ranges, err := Parse(strings.NewReader(testRanges))
+	require.NoError(t, err)
+	assert.Equal(t, 7, ranges.Len())
+
+	tests := []struct {
+		ip   string
+		want string
+		ok   bool
+	}{
+		{"66.249.64.1", "Googlebot", true},
+		{"66.249.95.255", "Googlebot", true},
+		{"66.249.96.0", "", false},
+		// The most specific network names the match.
+		{"66.249.66.2", "Googlebot Images", true},
+		{"66.249.66.1", "66.249.66.1/32", true},
+		{"2001:4860:4801:1::1", "Googlebot", true},
+		{"2001:4860:4801:10::1", "Googlebot IPv6 Special", true},
+		{"2001:4860:4802::1", "", false},
+		{"fe80::1%eth0", "", false},
+		// IPv4-mapped addresses match IPv4 networks and the other way around.
+		{"::ffff:66.249.64.1", "Googlebot", true},
+		{"157.55.39.10", "Bingbot", true},
+		{"::ffff:157.55.39.10", "Bingbot", true},
+		// Host bits of networks are masked.
+		{"192.0.200.1", "192.0.0.0/16", true},
+		{"10.0.0.1", "", false},
+	}
+	for _, tt := range tests {
+		name, ok := ranges.Match(netip.MustParseAddr(tt.ip))
+		assert.Equal(t, tt.ok, ok, tt.ip)
+		assert.Equal(t, tt.want, name, tt.ip)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, line := range []string{"66.249.64.0/33", "not-an-ip", "2001:db8::/129"} {
+		_, err := Parse(strings.NewReader("10.0.0.0/8\n" + line))
+		require.Error(t, err, line)
+		assert.Contains(t, err.Error(), "line 2", line)
+	}
+}
+
+func TestList(t *testing.T) {
+	var nilList *List
+	_, ok := nilList.Match("66.249.64.1")
+	assert.False(t, ok)
+
+	path := filepath.Join(t.TempDir(), "ranges.txt")
+	require.NoError(t, os.WriteFile(path, []byte(testRanges), 0o600))
+	list := NewList(path)
+	_, ok = list.Match("66.249.64.1")
+	assert.False(t, ok, "nothing matches before the first load")
+
+	require.NoError(t, list.Reload())
+	name, ok := list.Match("66.249.64.1")
+	assert.True(t, ok)
+	assert.Equal(t, "Googlebot", name)
+	_, ok = list.Match("not-an-ip")
+	assert.False(t, ok)
+
+	// A file that fails to parse keeps the previous ranges.
+	require.NoError(t, os.WriteFile(path, []byte("bogus\n"), 0o600))
+	assert.Error(t, list.Reload())
+	_, ok = list.Match("66.249.64.1")
+	assert.True(t, ok)
+}
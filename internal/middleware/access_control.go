@@ -0,0 +1,26 @@
+// Package middleware provides HTTP middleware functions for rate limiting, metrics, logging, and error handling.
+package middleware
+
+import (
+	"net/http"
+
+	"url-shorterner/internal/access"
+	"url-shorterner/internal/clientip"
+	"url-shorterner/internal/prometheus"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessControl returns a Gin middleware that rejects requests from denylisted networks.
+func AccessControl(acl *access.List) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if acl.IsDenied(clientip.FromContext(c)) {
+			prometheus.AccessDeniedTotal.Inc()
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
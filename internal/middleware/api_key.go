@@ -0,0 +1,24 @@
+// Package middleware provides HTTP middleware functions for rate limiting, metrics, logging, and error handling.
+package middleware
+
+import (
+	"url-shorterner/internal/apikey"
+	appErrors "url-shorterner/internal/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAPIKey returns a Gin middleware that rejects requests without a known API key and
+// records the name of the key for apikey.FromContext. Without keys every request is rejected.
+func RequireAPIKey(keys *apikey.Keys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, ok := keys.Lookup(apikey.FromRequest(c.Request))
+		if !ok {
+			c.Error(appErrors.Unauthorized(appErrors.ErrCodeAPIKeyRequired)) //nolint:errcheck // Error is handled by ErrorHandler middleware
+			c.Abort()
+			return
+		}
+		c.Set(apikey.ContextKeyName, name)
+		c.Next()
+	}
+}
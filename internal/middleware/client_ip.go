@@ -0,0 +1,19 @@
+// Package middleware provides HTTP middleware functions for rate limiting, metrics, logging, and error handling.
+package middleware
+
+import (
+	"url-shorterner/internal/clientip"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResolveClientIP returns a Gin middleware that resolves the client IP once per request
+// so rate limiting, access control and analytics all see the same address.
+func ResolveClientIP(resolver *clientip.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ip := resolver.Resolve(c.Request); ip != "" {
+			c.Set(clientip.ContextKeyClientIP, ip)
+		}
+		c.Next()
+	}
+}
@@ -26,24 +26,8 @@ func ErrorHandler() gin.HandlerFunc {
 				status := mapErrorToStatus(err)
 				lang := appErrors.GetLanguageFromContext(c)
 
-				// Get error message and code
-				var errorMsg string
-				var errorCode appErrors.ErrorCode
-
 				// Extract error code and translate message based on request language
-				if code, ok := appErrors.GetErrorCode(err); ok {
-					errorCode = code
-					// Handle special case for NotFoundError with resource
-					if notFoundErr, ok := err.(*appErrors.NotFoundError); ok {
-						errorMsg = appErrors.GetMessage(code, lang, map[string]interface{}{"Resource": notFoundErr.Resource})
-					} else {
-						// For all other errors, use the code for translation
-						errorMsg = appErrors.GetMessage(code, lang)
-					}
-				} else {
-					// Fallback to error message if no code found
-					errorMsg = err.Error()
-				}
+				errorCode, errorMsg := appErrors.Localize(err, lang)
 
 				// For internal server errors, don't expose internal error details to clients
 				if status == http.StatusInternalServerError {
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"url-shorterner/internal/clientip"
 	"url-shorterner/internal/log"
 
 	"github.com/gin-gonic/gin"
@@ -23,7 +24,7 @@ func Logger() gin.HandlerFunc {
 		c.Next()
 
 		latency := time.Since(start)
-		clientIP := c.ClientIP()
+		clientIP := clientip.FromContext(c)
 		method := c.Request.Method
 		statusCode := c.Writer.Status()
 		errors := c.Errors.ByType(gin.ErrorTypePrivate)
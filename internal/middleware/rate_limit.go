@@ -4,6 +4,8 @@ package middleware
 import (
 	"net/http"
 
+	"url-shorterner/internal/access"
+	"url-shorterner/internal/clientip"
 	"url-shorterner/internal/prometheus"
 	"url-shorterner/internal/rate"
 
@@ -11,9 +13,15 @@ import (
 )
 
 // RateLimit returns a Gin middleware that enforces rate limiting.
-func RateLimit(limiter rate.Limiter) gin.HandlerFunc {
+// Identifiers on the access allowlist are not rate limited.
+func RateLimit(limiter rate.Limiter, acl *access.List) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		identifier := c.ClientIP()
+		identifier := clientip.FromContext(c)
+		if acl.IsAllowed(identifier) {
+			c.Next()
+			return
+		}
+
 		allowed, err := limiter.Allow(c.Request.Context(), identifier)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "rate limit check failed"})
@@ -8,6 +8,7 @@ import (
 	"runtime/debug"
 	"strings"
 
+	"url-shorterner/internal/clientip"
 	"url-shorterner/internal/log"
 
 	"github.com/gin-gonic/gin"
@@ -21,7 +22,7 @@ func Recovery() gin.HandlerFunc {
 			recovered,
 			c.Request.Method,
 			c.Request.URL.Path,
-			c.ClientIP(),
+			clientip.FromContext(c),
 			stackTrace,
 		)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
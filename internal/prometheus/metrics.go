@@ -43,5 +43,12 @@ var (
 		},
 		[]string{"identifier"},
 	)
-)
 
+	// AccessDeniedTotal counts the total number of requests rejected by the IP denylist.
+	AccessDeniedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "access_denied_total",
+			Help: "Total number of requests rejected by the IP denylist",
+		},
+	)
+)
@@ -0,0 +1,274 @@
+// Package referrer extracts the host of Referer headers and classifies the traffic source of
+// clicks from editable host rules.
+package referrer
+
+import (
+	"bufio"
+	"context"
+	_ "embed" // default rules
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shorterner/internal/log"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Traffic sources.
+const (
+	// SourceDirect is traffic without a referrer, e.g. typed, bookmarked or from an app.
+	SourceDirect = "direct"
+	// SourceSearch is traffic from search engines.
+	SourceSearch = "search"
+	// SourceSocial is traffic from social networks and messengers.
+	SourceSocial = "social"
+	// SourceEmail is traffic from webmail and mail apps.
+	SourceEmail = "email"
+	// SourceInternal is traffic from the service's own pages.
+	SourceInternal = "internal"
+	// SourceReferral is traffic from any other site.
+	SourceReferral = "referral"
+)
+
+// maxHostLength bounds stored hosts; longer values are not DNS names.
+const maxHostLength = 255
+
+//go:embed rules.txt
+var defaultRules string
+
+// builtin are the default rules, used when no rules file is configured and by nil classifiers.
+var builtin = mustParse(defaultRules)
+
+// Rules map referrer hosts to sources.
+type Rules struct {
+	// hosts maps a host to the source of it and its subdomains.
+	hosts map[string]string
+	// wildcards maps the first label of "name.*" entries to their source.
+	wildcards map[string]string
+}
+
+// ParseRules reads "<source> <host>" lines, where source is search, social, email or internal.
+// Blank lines and lines starting with "#" are ignored.
+func ParseRules(r io.Reader) (*Rules, error) {
+	rules := &Rules{
+		hosts:     make(map[string]string),
+		wildcards: make(map[string]string),
+	}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"<source> <host>\"", n)
+		}
+		source := strings.ToLower(fields[0])
+		switch source {
+		case SourceSearch, SourceSocial, SourceEmail, SourceInternal:
+		default:
+			return nil, fmt.Errorf("line %d: unknown source %q", n, fields[0])
+		}
+		host := normalizeHost(fields[1])
+		if name, ok := strings.CutSuffix(host, ".*"); ok && name != "" && !strings.Contains(name, ".") {
+			if _, exists := rules.wildcards[name]; !exists {
+				rules.wildcards[name] = source
+			}
+			continue
+		}
+		if host == "" || strings.Contains(host, "*") {
+			return nil, fmt.Errorf("line %d: invalid host %q", n, fields[1])
+		}
+		if _, exists := rules.hosts[host]; !exists {
+			rules.hosts[host] = source
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// match returns the source of the most specific entry matching host.
+func (r *Rules) match(host string) (string, bool) {
+	for name := host; name != ""; {
+		if source, ok := r.hosts[name]; ok {
+			return source, true
+		}
+		label, parent, found := strings.Cut(name, ".")
+		if !found {
+			break
+		}
+		// "google.*" matches google.com and google.co.uk, but not google.example.com.
+		if source, ok := r.wildcards[label]; ok && isPublicSuffix(parent) {
+			return source, true
+		}
+		name = parent
+	}
+	return "", false
+}
+
+// Classifier classifies referrers with the rules of a hot-reloadable file, falling back to the
+// built-in rules when no file is configured. A nil *Classifier uses the built-in rules.
+type Classifier struct {
+	path     string
+	internal []string
+
+	mu      sync.RWMutex
+	rules   *Rules
+	modTime time.Time
+}
+
+// NewClassifier creates a classifier reading rules from path, which may be empty.
+// Referrers on internalHosts or their subdomains are internal traffic.
+// Call Reload (or Start) to load the file before use.
+func NewClassifier(path string, internalHosts ...string) *Classifier {
+	internal := make([]string, 0, len(internalHosts))
+	for _, host := range internalHosts {
+		if host = normalizeHost(host); host != "" {
+			internal = append(internal, host)
+		}
+	}
+	return &Classifier{
+		path:     path,
+		internal: internal,
+		rules:    builtin,
+	}
+}
+
+// Reload reads the rules file and atomically swaps it in.
+// If the file fails to load, the previous rules are kept.
+func (c *Classifier) Reload() error {
+	if c.path == "" {
+		return nil
+	}
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat referrer rules %s: %w", c.path, err)
+	}
+	file, err := os.Open(c.path) //nolint:gosec // G304: Rules path comes from trusted configuration
+	if err != nil {
+		return fmt.Errorf("failed to open referrer rules %s: %w", c.path, err)
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+
+	rules, err := ParseRules(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse referrer rules %s: %w", c.path, err)
+	}
+
+	c.mu.Lock()
+	c.rules = rules
+	c.modTime = info.ModTime()
+	c.mu.Unlock()
+
+	log.Info("Referrer rules loaded: %d hosts, %d wildcards", len(rules.hosts), len(rules.wildcards))
+	return nil
+}
+
+// Start checks the rules file every interval and reloads it when it has changed.
+func (c *Classifier) Start(ctx context.Context, interval time.Duration) {
+	if c == nil || c.path == "" || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !c.changed() {
+					continue
+				}
+				if err := c.Reload(); err != nil {
+					log.Error("Referrer rules reload failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Classify returns the host of referer, without "www.", and the source of the traffic.
+// Clicks without a referrer host are direct, unless utmMedium names a source, since mail
+// clients and apps rarely send one.
+func (c *Classifier) Classify(referer, utmMedium string) (string, string) {
+	host := Host(referer)
+	if host == "" {
+		switch medium := strings.ToLower(strings.TrimSpace(utmMedium)); medium {
+		case SourceSearch, SourceSocial, SourceEmail:
+			return "", medium
+		}
+		return "", SourceDirect
+	}
+
+	rules := builtin
+	if c != nil {
+		for _, internal := range c.internal {
+			if host == internal || strings.HasSuffix(host, "."+internal) {
+				return host, SourceInternal
+			}
+		}
+		c.mu.RLock()
+		rules = c.rules
+		c.mu.RUnlock()
+	}
+	if source, ok := rules.match(host); ok {
+		return host, source
+	}
+	return host, SourceReferral
+}
+
+// Host returns the lowercased host of referer without port and "www." prefix, or "" if it
+// has none.
+func Host(referer string) string {
+	referer = strings.TrimSpace(referer)
+	if referer == "" {
+		return ""
+	}
+	parsed, err := url.Parse(referer)
+	if err != nil {
+		return ""
+	}
+	host := normalizeHost(parsed.Hostname())
+	if len(host) > maxHostLength {
+		return ""
+	}
+	return host
+}
+
+func (c *Classifier) changed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	info, err := os.Stat(c.path)
+	return err != nil || !info.ModTime().Equal(c.modTime)
+}
+
+// isPublicSuffix reports whether domain is a public suffix of the ICANN section of the Public
+// Suffix List, such as "com" or "co.uk". Privately registered suffixes like "blogspot.com"
+// are not, so "google.*" does not match a google.blogspot.com blog.
+func isPublicSuffix(domain string) bool {
+	suffix, icann := publicsuffix.PublicSuffix(domain)
+	return icann && suffix == domain
+}
+
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	return strings.TrimPrefix(host, "www.")
+}
+
+func mustParse(s string) *Rules {
+	rules, err := ParseRules(strings.NewReader(s))
+	if err != nil {
+		panic(fmt.Sprintf("invalid default referrer rules: %v", err))
+	}
+	return rules
+}
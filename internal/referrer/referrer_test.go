@@ -0,0 +1,122 @@
+package referrer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(`
+# comment
+search example.*
+SOCIAL WWW.Social.Example.
+email mail.social.example
+search social.example
+search example.*
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"social.example":      SourceSocial,
+		"mail.social.example": SourceEmail,
+	}, rules.hosts)
+	assert.Equal(t, map[string]string{"example": SourceSearch}, rules.wildcards)
+}
+
+func TestParseRulesErrors(t *testing.T) {
+	tests := map[string]string{
+		"missing host":    "search",
+		"extra field":     "search a.example b.example",
+		"unknown source":  "referral a.example",
+		"direct source":   "direct a.example",
+		"inner wildcard":  "search *.example",
+		"dotted wildcard": "search a.b.*",
+		"bare wildcard":   "search *",
+	}
+	for name, line := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseRules(strings.NewReader("search ok.example\n" + line))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "line 2")
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	classifier := NewClassifier("", "Short.Example")
+
+	tests := []struct {
+		referer    string
+		utmMedium  string
+		wantHost   string
+		wantSource string
+	}{
+		{"", "", "", SourceDirect},
+		{"", "Email", "", SourceEmail},
+		{"", "social", "", SourceSocial},
+		{"", "cpc", "", SourceDirect},
+		{"not a url\x7f", "", "", SourceDirect},
+		{"https://www.google.com/search?q=x", "email", "google.com", SourceSearch},
+		{"https://www.google.co.uk/", "", "google.co.uk", SourceSearch},
+		{"https://news.google.de/", "", "news.google.de", SourceSearch},
+		{"https://mail.google.com/mail/u/0", "", "mail.google.com", SourceEmail},
+		{"https://google.evil.com/", "", "google.evil.com", SourceReferral},
+		{"https://google.com.evil.com/", "", "google.com.evil.com", SourceReferral},
+		{"https://google.blogspot.com/", "", "google.blogspot.com", SourceReferral},
+		{"https://google.invalidtld/", "", "google.invalidtld", SourceReferral},
+		{"https://t.co/abc", "", "t.co", SourceSocial},
+		{"https://old.reddit.com/r/golang", "", "old.reddit.com", SourceSocial},
+		{"https://notreddit.com/", "", "notreddit.com", SourceReferral},
+		{"http://WWW.Blog.Example:8080/post", "", "blog.example", SourceReferral},
+		{"https://short.example/links", "", "short.example", SourceInternal},
+		{"https://app.short.example/", "", "app.short.example", SourceInternal},
+		{"android-app://com.google.android.gm/", "", "com.google.android.gm", SourceEmail},
+	}
+	for _, tt := range tests {
+		host, source := classifier.Classify(tt.referer, tt.utmMedium)
+		assert.Equal(t, tt.wantHost, host, tt.referer)
+		assert.Equal(t, tt.wantSource, source, tt.referer)
+	}
+
+	// A nil classifier uses the built-in rules and knows no internal hosts.
+	var nilClassifier *Classifier
+	host, source := nilClassifier.Classify("https://short.example/", "")
+	assert.Equal(t, "short.example", host)
+	assert.Equal(t, SourceReferral, source)
+}
+
+func TestClassifierReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.txt")
+	require.NoError(t, os.WriteFile(path, []byte("social blog.example\n"), 0o600))
+	classifier := NewClassifier(path)
+	require.NoError(t, classifier.Reload())
+
+	_, source := classifier.Classify("https://blog.example/", "")
+	assert.Equal(t, SourceSocial, source)
+	// The file replaces the built-in rules.
+	_, source = classifier.Classify("https://www.google.com/", "")
+	assert.Equal(t, SourceReferral, source)
+
+	require.NoError(t, os.WriteFile(path, []byte("bogus line here\n"), 0o600))
+	assert.Error(t, classifier.Reload())
+	_, source = classifier.Classify("https://blog.example/", "")
+	assert.Equal(t, SourceSocial, source)
+}
+
+func TestHost(t *testing.T) {
+	tests := map[string]string{
+		"":                              "",
+		"   ":                           "",
+		"https://www.Example.com./path": "example.com",
+		"https://[2001:db8::1]:443/":    "2001:db8::1",
+		"/relative/path":                "",
+		"https://" + strings.Repeat("a", 256) + ".example/": "",
+	}
+	for referer, want := range tests {
+		assert.Equal(t, want, Host(referer), referer)
+	}
+}
@@ -56,6 +56,23 @@ func RunMigrations(ctx context.Context, pool *pgxpool.Pool, migrationsPath strin
 
 	migrationFiles := []string{
 		"001_create_tables.up.sql",
+		"002_create_ip_access_rules.up.sql",
+		"003_add_url_blocking.up.sql",
+		"004_create_code_ranges.up.sql",
+		"005_add_url_owner.up.sql",
+		"006_add_url_canonical.up.sql",
+		"007_create_tags.up.sql",
+		"008_create_jobs.up.sql",
+		"009_add_url_details.up.sql",
+		"010_create_campaigns.up.sql",
+		"011_create_url_versions.up.sql",
+		"012_create_analytics_rollups.up.sql",
+		"013_add_analytics_user_agent.up.sql",
+		"014_add_analytics_geo.up.sql",
+		"015_add_analytics_referrer.up.sql",
+		"016_create_analytics_ip_salts.up.sql",
+		"017_add_analytics_bot_reason.up.sql",
+		"018_track_late_analytics.up.sql",
 	}
 
 	for _, migrationFile := range migrationFiles {
@@ -1,12 +1,31 @@
 // Package storage defines storage layer error types.
 package storage
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolationCode is the Postgres SQLSTATE for unique constraint violations.
+const uniqueViolationCode = "23505"
 
 var (
 	// ErrNotFound is returned when a requested resource is not found.
 	ErrNotFound = errors.New("url not found")
 	// ErrExpired is returned when a URL has expired.
 	ErrExpired = errors.New("url expired")
+	// ErrDuplicate is returned when a write violates a unique constraint.
+	ErrDuplicate = errors.New("duplicate key")
 )
 
+// MapError converts driver errors into storage errors, wrapping unique violations in ErrDuplicate.
+// Other errors are returned unchanged.
+func MapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return fmt.Errorf("%w: %s", ErrDuplicate, pgErr.ConstraintName)
+	}
+	return err
+}
@@ -0,0 +1,249 @@
+// Package useragent classifies User-Agent strings into browser, operating system and device
+// class using built-in rules, without any network lookup.
+package useragent
+
+import "strings"
+
+// Device classes.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceOther   = "other"
+)
+
+// Other is the browser or OS family of unrecognized user agents.
+const Other = "Other"
+
+// Info is what a User-Agent string tells about a client.
+type Info struct {
+	// Browser is the browser family, or the name of a bot.
+	Browser string
+	// BrowserVersion is the version token of the browser, empty if unknown.
+	BrowserVersion string
+	// OS is the operating system family.
+	OS string
+	// Device is one of the Device* classes.
+	Device string
+	// Bot is set for crawlers, link previewers and HTTP libraries.
+	Bot bool
+}
+
+// rule maps a User-Agent token to a family name. Browser versions follow the token.
+type rule struct {
+	token string
+	name  string
+}
+
+// botRules are matched case-insensitively, most specific first.
+var botRules = []rule{
+	{"googlebot", "Googlebot"},
+	{"bingbot", "Bingbot"},
+	{"bingpreview", "Bing Preview"},
+	{"duckduckbot", "DuckDuckBot"},
+	{"yandexbot", "YandexBot"},
+	{"baiduspider", "Baiduspider"},
+	{"applebot", "Applebot"},
+	{"facebookexternalhit", "Facebook"},
+	{"facebookcatalog", "Facebook"},
+	{"twitterbot", "Twitterbot"},
+	{"slackbot", "Slackbot"},
+	{"slack-imgproxy", "Slackbot"},
+	{"linkedinbot", "LinkedInBot"},
+	{"discordbot", "Discordbot"},
+	{"telegrambot", "TelegramBot"},
+	{"whatsapp", "WhatsApp"},
+	{"skypeuripreview", "Skype"},
+	{"pinterestbot", "Pinterestbot"},
+	{"redditbot", "Redditbot"},
+	{"embedly", "Embedly"},
+	{"quora link preview", "Quora"},
+	{"google web preview", "Google Web Preview"},
+	{"iframely", "Iframely"},
+	{"vkshare", "VK"},
+	{"mastodon/", "Mastodon"},
+	{"cardyb", "Bluesky"},
+	{"headlesschrome", "HeadlessChrome"},
+	{"lighthouse", "Lighthouse"},
+	{"uptimerobot", "UptimeRobot"},
+	{"pingdom", "Pingdom"},
+	{"curl/", "curl"},
+	{"wget/", "Wget"},
+	{"python-requests", "Python"},
+	{"python-urllib", "Python"},
+	{"aiohttp", "Python"},
+	{"go-http-client", "Go"},
+	{"okhttp", "OkHttp"},
+	{"java/", "Java"},
+	{"apache-httpclient", "Java"},
+	{"libwww-perl", "Perl"},
+	{"axios/", "Node.js"},
+	{"node-fetch", "Node.js"},
+	{"postmanruntime", "Postman"},
+	// Generic markers last, so named bots win. A bare "bot" is matched by hasBotWord.
+	{"crawler", Other},
+	{"spider", Other},
+	{"slurp", Other},
+}
+
+// browserRules are matched in order: many browsers also announce Chrome and Safari.
+var browserRules = []rule{
+	{"Edg/", "Edge"},
+	{"EdgA/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"Edge/", "Edge"},
+	{"OPR/", "Opera"},
+	{"OPiOS/", "Opera"},
+	{"Opera/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"YaBrowser/", "Yandex Browser"},
+	{"UCBrowser/", "UC Browser"},
+	{"Vivaldi/", "Vivaldi"},
+	{"FBAV/", "Facebook App"},
+	{"Instagram ", "Instagram App"},
+	{"FxiOS/", "Firefox"},
+	{"Firefox/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chromium/", "Chromium"},
+	{"Chrome/", "Chrome"},
+	{"MSIE ", "Internet Explorer"},
+	{"Trident/", "Internet Explorer"},
+}
+
+// osRules are matched in order.
+var osRules = []rule{
+	{"Windows Phone", "Windows Phone"},
+	{"Windows", "Windows"},
+	{"iPhone", "iOS"},
+	{"iPad", "iOS"},
+	{"iPod", "iOS"},
+	{"Android", "Android"},
+	{"CrOS", "ChromeOS"},
+	{"Macintosh", "macOS"},
+	{"Mac OS X", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// Parse classifies a User-Agent string. Empty strings yield Other families and DeviceOther.
+func Parse(ua string) Info {
+	info := Info{Browser: Other, OS: Other, Device: DeviceOther}
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return info
+	}
+
+	info.OS = match(osRules, ua)
+
+	lower := strings.ToLower(ua)
+	for _, r := range botRules {
+		if strings.Contains(lower, r.token) {
+			info.Browser = r.name
+			info.Bot = true
+			info.Device = DeviceBot
+			return info
+		}
+	}
+	if hasBotWord(lower) {
+		info.Browser = Other
+		info.Bot = true
+		info.Device = DeviceBot
+		return info
+	}
+
+	info.Browser, info.BrowserVersion = browser(ua)
+	info.Device = device(ua, info.OS)
+	return info
+}
+
+// hasBotWord reports whether the lowercase lower contains "bot" as a word or as the end of a
+// product name, e.g. "AhrefsBot/7.0", "(compatible; bot)" or "my-bot", but not inside a
+// device name like "CUBOT_X19".
+func hasBotWord(lower string) bool {
+	for offset := 0; ; {
+		i := strings.Index(lower[offset:], "bot")
+		if i < 0 {
+			return false
+		}
+		start := offset + i
+		end := start + len("bot")
+		if end == len(lower) || strings.IndexByte("/;)", lower[end]) >= 0 {
+			return true
+		}
+		if (start == 0 || !isLetter(lower[start-1])) && !isLetter(lower[end]) {
+			return true
+		}
+		offset = end
+	}
+}
+
+// isLetter reports whether c is an ASCII letter.
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// browser returns the browser family and version of ua.
+func browser(ua string) (string, string) {
+	for _, r := range browserRules {
+		if i := strings.Index(ua, r.token); i >= 0 {
+			version := versionAt(ua[i+len(r.token):])
+			if r.token == "Trident/" {
+				version = ""
+				if j := strings.Index(ua, "rv:"); j >= 0 {
+					version = versionAt(ua[j+len("rv:"):])
+				}
+			}
+			return r.name, version
+		}
+	}
+	// Safari announces its version separately from the WebKit "Safari/" build number.
+	if strings.Contains(ua, "Safari/") || (strings.Contains(ua, "AppleWebKit/") && strings.Contains(ua, "Mobile/")) {
+		version := ""
+		if i := strings.Index(ua, "Version/"); i >= 0 {
+			version = versionAt(ua[i+len("Version/"):])
+		}
+		return "Safari", version
+	}
+	return Other, ""
+}
+
+// device returns the device class of a non-bot ua running on os.
+func device(ua, os string) string {
+	switch {
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet"),
+		os == "Android" && !strings.Contains(ua, "Mobile"):
+		return DeviceTablet
+	case strings.Contains(ua, "Mobi") || strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPod"),
+		os == "Windows Phone":
+		return DeviceMobile
+	case os == "Windows" || os == "macOS" || os == "Linux" || os == "ChromeOS":
+		return DeviceDesktop
+	default:
+		return DeviceOther
+	}
+}
+
+// match returns the name of the first rule whose token occurs in ua.
+func match(rules []rule, ua string) string {
+	for _, r := range rules {
+		if strings.Contains(ua, r.token) {
+			return r.name
+		}
+	}
+	return Other
+}
+
+// versionAt returns the version token at the start of s, e.g. "120.0.6099.109".
+func versionAt(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r == '.')
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	version := strings.TrimRight(s[:end], ".")
+	if len(version) > 32 {
+		version = version[:32]
+	}
+	return version
+}
@@ -0,0 +1,119 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want Info
+	}{
+		{
+			name: "empty",
+			ua:   "  ",
+			want: Info{Browser: Other, OS: Other, Device: DeviceOther},
+		},
+		{
+			name: "chrome on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.109 Safari/537.36",
+			want: Info{Browser: "Chrome", BrowserVersion: "120.0.6099.109", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name: "edge announces chrome",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			want: Info{Browser: "Edge", BrowserVersion: "120.0.2210.91", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name: "safari on iphone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			want: Info{Browser: "Safari", BrowserVersion: "17.2", OS: "iOS", Device: DeviceMobile},
+		},
+		{
+			name: "firefox on android tablet",
+			ua:   "Mozilla/5.0 (Android 13; Tablet; rv:121.0) Gecko/121.0 Firefox/121.0",
+			want: Info{Browser: "Firefox", BrowserVersion: "121.0", OS: "Android", Device: DeviceTablet},
+		},
+		{
+			name: "internet explorer 11",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Trident/7.0; rv:11.0) like Gecko",
+			want: Info{Browser: "Internet Explorer", BrowserVersion: "11.0", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name: "phone model containing bot",
+			ua:   "Mozilla/5.0 (Linux; Android 9; CUBOT_X19) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/96.0.4664.45 Mobile Safari/537.36",
+			want: Info{Browser: "Chrome", BrowserVersion: "96.0.4664.45", OS: "Android", Device: DeviceMobile},
+		},
+		{
+			name: "phone model containing bot and a space",
+			ua:   "Mozilla/5.0 (Linux; Android 10; CUBOT X30) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/96.0.4664.45 Mobile Safari/537.36",
+			want: Info{Browser: "Chrome", BrowserVersion: "96.0.4664.45", OS: "Android", Device: DeviceMobile},
+		},
+		{
+			name: "named bot",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: Info{Browser: "Googlebot", OS: Other, Device: DeviceBot, Bot: true},
+		},
+		{
+			name: "named previewer",
+			ua:   "Mozilla/5.0 (Windows NT 6.1; rv:6.0) Gecko/20110814 Firefox/6.0 Google Web Preview",
+			want: Info{Browser: "Google Web Preview", OS: "Windows", Device: DeviceBot, Bot: true},
+		},
+		{
+			name: "http library",
+			ua:   "curl/8.4.0",
+			want: Info{Browser: "curl", OS: Other, Device: DeviceBot, Bot: true},
+		},
+		{
+			name: "unnamed bot with a version",
+			ua:   "Mozilla/5.0 (compatible; AhrefsBot/7.0; +http://ahrefs.com/robot/)",
+			want: Info{Browser: Other, OS: Other, Device: DeviceBot, Bot: true},
+		},
+		{
+			name: "unnamed bot closing a comment",
+			ua:   "Mozilla/5.0 (compatible; SomeBot)",
+			want: Info{Browser: Other, OS: Other, Device: DeviceBot, Bot: true},
+		},
+		{
+			name: "unnamed bot after a hyphen",
+			ua:   "acme-bot 1.0",
+			want: Info{Browser: Other, OS: Other, Device: DeviceBot, Bot: true},
+		},
+		{
+			name: "generic crawler",
+			ua:   "Mozilla/5.0 (compatible; ExampleCrawler 1.0)",
+			want: Info{Browser: Other, OS: Other, Device: DeviceBot, Bot: true},
+		},
+		{
+			name: "unnamed preview is not a bot",
+			ua:   "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 PreviewApp/1.0",
+			want: Info{Browser: "Chrome", BrowserVersion: "120.0.0.0", OS: "Linux", Device: DeviceDesktop},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.ua))
+		})
+	}
+}
+
+func TestHasBotWord(t *testing.T) {
+	tests := map[string]bool{
+		"bot":                 true,
+		"mj12bot/v1.4.8":      true,
+		"(compatible; bot)":   true,
+		"my-bot 2.0":          true,
+		"a bot here":          true,
+		"cubot_x19":           false,
+		"cubot x30":           false,
+		"robotics app":        false,
+		"bottle/1.0":          false,
+		"cubot_x19; dotbot/1": true,
+	}
+	for ua, want := range tests {
+		assert.Equal(t, want, hasBotWord(ua), ua)
+	}
+}
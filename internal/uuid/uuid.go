@@ -9,3 +9,8 @@ import (
 func Generate() string {
 	return uuid.New().String()
 }
+
+// Valid reports whether s is a well-formed UUID.
+func Valid(s string) bool {
+	return uuid.Validate(s) == nil
+}
@@ -0,0 +1,104 @@
+// Package wordlist provides matching of identifiers against a list of disallowed words.
+package wordlist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// leetReplacer folds common character substitutions used to evade filters.
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s",
+)
+
+// List matches identifiers against disallowed words.
+// Plain entries match whole tokens (split on "-", "_", "." and "~") or the entire identifier;
+// entries prefixed with "*" match anywhere, which catches concatenations at the cost of false positives.
+type List struct {
+	tokens    map[string]struct{}
+	substring []string
+}
+
+// New creates a list from the given entries.
+func New(entries []string) *List {
+	l := &List{tokens: make(map[string]struct{})}
+	for _, entry := range entries {
+		l.add(entry)
+	}
+	return l
+}
+
+// Load reads one entry per line; blank lines and lines starting with "#" are ignored.
+func Load(r io.Reader) (*List, error) {
+	l := New(nil)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		l.add(line)
+	}
+	return l, scanner.Err()
+}
+
+// LoadFile reads a word list from path.
+func LoadFile(path string) (*List, error) {
+	file, err := os.Open(path) //nolint:gosec // G304: Word list path comes from trusted configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to open word list %s: %w", path, err)
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+
+	l, err := Load(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read word list %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// Contains reports whether s contains a disallowed word.
+func (l *List) Contains(s string) bool {
+	normalized := normalize(s)
+
+	tokens := strings.FieldsFunc(normalized, isSeparator)
+	for _, token := range tokens {
+		if _, ok := l.tokens[token]; ok {
+			return true
+		}
+	}
+
+	compact := strings.Join(tokens, "")
+	if _, ok := l.tokens[compact]; ok {
+		return true
+	}
+	for _, word := range l.substring {
+		if strings.Contains(compact, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *List) add(entry string) {
+	if word, ok := strings.CutPrefix(entry, "*"); ok {
+		if word = normalize(word); word != "" {
+			l.substring = append(l.substring, word)
+		}
+		return
+	}
+	if word := normalize(entry); word != "" {
+		l.tokens[word] = struct{}{}
+	}
+}
+
+func normalize(s string) string {
+	return leetReplacer.Replace(strings.ToLower(strings.TrimSpace(s)))
+}
+
+func isSeparator(r rune) bool {
+	return r == '-' || r == '_' || r == '.' || r == '~'
+}
@@ -0,0 +1,62 @@
+package wordlist
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContains(t *testing.T) {
+	l := New([]string{"bad", " Worse ", "*evil", "", "*"})
+
+	tests := map[string]bool{
+		"bad":          true,
+		"BAD":          true,
+		"my-bad-link":  true,
+		"my_bad.link":  true,
+		"bad~":         true,
+		"worse":        true,
+		"b4d":          true,
+		"w0r$3":        true,
+		"b-a-d":        true,
+		"b.4_d":        true,
+		"badge":        false,
+		"abad":         false,
+		"mybadlink":    false,
+		"good":         false,
+		"evil":         true,
+		"notevilatall": true,
+		"n0t-3v1l":     true,
+		"ev-il":        true,
+		"devi-l":       true,
+		"":             false,
+		"---":          false,
+	}
+	for s, want := range tests {
+		assert.Equal(t, want, l.Contains(s), s)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	l, err := Load(strings.NewReader("# comment\n\nbad\n  *evil  \n#worse\n"))
+	require.NoError(t, err)
+	assert.True(t, l.Contains("bad"))
+	assert.True(t, l.Contains("reallyevil"))
+	assert.False(t, l.Contains("worse"))
+	assert.False(t, l.Contains("comment"))
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(path, []byte("bad\n"), 0o600))
+	l, err := LoadFile(path)
+	require.NoError(t, err)
+	assert.True(t, l.Contains("so-bad"))
+
+	_, err = LoadFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS ip_access_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    cidr CIDR NOT NULL,
+    action VARCHAR(16) NOT NULL CHECK (action IN ('allow', 'deny')),
+    note TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    UNIQUE (cidr, action)
+);
+
+CREATE INDEX idx_ip_access_rules_expires_at ON ip_access_rules(expires_at);
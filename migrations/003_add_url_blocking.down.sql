@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_urls_blocked_at;
+ALTER TABLE urls DROP COLUMN IF EXISTS blocked_reason;
+ALTER TABLE urls DROP COLUMN IF EXISTS blocked_at;
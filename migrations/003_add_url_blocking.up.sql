@@ -0,0 +1,4 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS blocked_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS blocked_reason TEXT;
+
+CREATE INDEX idx_urls_blocked_at ON urls(blocked_at) WHERE blocked_at IS NOT NULL;
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS code_ranges (
+    name VARCHAR(64) PRIMARY KEY,
+    next_value BIGINT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
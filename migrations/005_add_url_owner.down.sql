@@ -0,0 +1 @@
+ALTER TABLE urls DROP COLUMN IF EXISTS owner;
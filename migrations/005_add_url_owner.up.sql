@@ -0,0 +1 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS owner VARCHAR(255) NOT NULL DEFAULT '';
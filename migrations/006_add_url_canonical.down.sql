@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_urls_canonical_url;
+ALTER TABLE urls DROP COLUMN IF EXISTS canonical_url;
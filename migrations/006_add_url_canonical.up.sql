@@ -0,0 +1,4 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS canonical_url TEXT NOT NULL DEFAULT '';
+
+-- Hash index: canonical URLs can exceed the btree row size limit and are only looked up by equality.
+CREATE INDEX IF NOT EXISTS idx_urls_canonical_url ON urls USING HASH (canonical_url);
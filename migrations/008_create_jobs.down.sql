@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS job_files;
+DROP TABLE IF EXISTS jobs;
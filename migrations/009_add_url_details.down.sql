@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_urls_created_at_id;
+ALTER TABLE urls DROP COLUMN IF EXISTS metadata;
+ALTER TABLE urls DROP COLUMN IF EXISTS description;
+ALTER TABLE urls DROP COLUMN IF EXISTS title;
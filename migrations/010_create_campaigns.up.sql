@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS campaigns (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+-- A link belongs to at most one campaign; deleting a campaign keeps its links.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS campaign_id UUID REFERENCES campaigns(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_urls_campaign_id ON urls(campaign_id) WHERE campaign_id IS NOT NULL;
+
+-- Campaign and time series analytics scan the clicks of a set of codes within a time range.
+CREATE INDEX IF NOT EXISTS idx_analytics_short_code_clicked_at ON analytics(short_code, clicked_at);
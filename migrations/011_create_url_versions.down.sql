@@ -0,0 +1,3 @@
+ALTER TABLE analytics DROP COLUMN IF EXISTS url_version;
+DROP TABLE IF EXISTS url_versions;
+ALTER TABLE urls DROP COLUMN IF EXISTS version;
@@ -0,0 +1,34 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+-- Every version of a link: its destination, expiry and settings (title, description, tags, metadata).
+CREATE TABLE IF NOT EXISTS url_versions (
+    url_id UUID NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    original_url TEXT NOT NULL,
+    canonical_url TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    settings JSONB NOT NULL DEFAULT '{}',
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    action VARCHAR(16) NOT NULL,
+    rolled_back_from INT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    PRIMARY KEY (url_id, version)
+);
+
+-- Existing links start at version 1.
+INSERT INTO url_versions (url_id, version, original_url, canonical_url, expires_at, settings, actor, action, created_at)
+SELECT u.id, u.version, u.original_url, u.canonical_url, u.expires_at,
+    jsonb_build_object(
+        'title', u.title,
+        'description', u.description,
+        'metadata', u.metadata,
+        'tags', to_jsonb(ARRAY(
+            SELECT t.name FROM url_tags ut JOIN tags t ON t.id = ut.tag_id WHERE ut.url_id = u.id ORDER BY t.name
+        ))
+    ),
+    u.owner, 'create', u.created_at
+FROM urls u
+ON CONFLICT DO NOTHING;
+
+-- The version of the link that served a click; NULL for clicks recorded before versioning.
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS url_version INT;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS analytics_rollup_state;
+DROP TABLE IF EXISTS analytics_unique_ips;
+DROP TABLE IF EXISTS analytics_daily;
+DROP TABLE IF EXISTS analytics_hourly;
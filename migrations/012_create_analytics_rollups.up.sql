@@ -0,0 +1,36 @@
+-- Clicks per short code per UTC hour and per UTC day, maintained by the analytics rollup job.
+CREATE TABLE IF NOT EXISTS analytics_hourly (
+    short_code VARCHAR(255) NOT NULL,
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    clicks BIGINT NOT NULL,
+    unique_ips BIGINT NOT NULL,
+    last_click TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (short_code, bucket)
+);
+
+CREATE TABLE IF NOT EXISTS analytics_daily (
+    short_code VARCHAR(255) NOT NULL,
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    clicks BIGINT NOT NULL,
+    unique_ips BIGINT NOT NULL,
+    last_click TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (short_code, bucket)
+);
+
+-- Distinct IPs per short code, so all-time unique counts need no scan of the raw clicks.
+CREATE TABLE IF NOT EXISTS analytics_unique_ips (
+    short_code VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(255) NOT NULL,
+    PRIMARY KEY (short_code, ip_address)
+);
+
+-- Clicks before rolled_up_to (an hour boundary) are in the hourly rollups and unique IPs;
+-- days ending before it are in the daily rollups. Readers merge in the raw clicks after it.
+CREATE TABLE IF NOT EXISTS analytics_rollup_state (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    rolled_up_to TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+INSERT INTO analytics_rollup_state (rolled_up_to)
+SELECT date_trunc('hour', COALESCE(MIN(clicked_at), NOW()), 'UTC') FROM analytics
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+ALTER TABLE analytics DROP COLUMN IF EXISTS is_bot;
+ALTER TABLE analytics DROP COLUMN IF EXISTS device;
+ALTER TABLE analytics DROP COLUMN IF EXISTS os;
+ALTER TABLE analytics DROP COLUMN IF EXISTS browser_version;
+ALTER TABLE analytics DROP COLUMN IF EXISTS browser;
@@ -0,0 +1,6 @@
+-- Parsed User-Agent of each click; NULL for clicks recorded before parsing.
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS browser VARCHAR(64);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS browser_version VARCHAR(32);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS os VARCHAR(64);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS device VARCHAR(16);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT FALSE;
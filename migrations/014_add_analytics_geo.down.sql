@@ -0,0 +1,5 @@
+ALTER TABLE analytics DROP COLUMN IF EXISTS as_org;
+ALTER TABLE analytics DROP COLUMN IF EXISTS asn;
+ALTER TABLE analytics DROP COLUMN IF EXISTS city;
+ALTER TABLE analytics DROP COLUMN IF EXISTS region;
+ALTER TABLE analytics DROP COLUMN IF EXISTS country;
@@ -0,0 +1,6 @@
+-- Location of each click resolved from the GeoIP database; NULL when unknown.
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS country VARCHAR(2);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS region VARCHAR(255);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS city VARCHAR(255);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS asn BIGINT;
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS as_org VARCHAR(255);
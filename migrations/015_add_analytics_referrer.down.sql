@@ -0,0 +1,7 @@
+ALTER TABLE analytics DROP COLUMN IF EXISTS utm_content;
+ALTER TABLE analytics DROP COLUMN IF EXISTS utm_term;
+ALTER TABLE analytics DROP COLUMN IF EXISTS utm_campaign;
+ALTER TABLE analytics DROP COLUMN IF EXISTS utm_medium;
+ALTER TABLE analytics DROP COLUMN IF EXISTS utm_source;
+ALTER TABLE analytics DROP COLUMN IF EXISTS source;
+ALTER TABLE analytics DROP COLUMN IF EXISTS referrer_host;
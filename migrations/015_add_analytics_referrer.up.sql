@@ -0,0 +1,9 @@
+-- Referrer host and traffic source of each click, plus the utm_* parameters of the redirect
+-- request; NULL when absent or recorded before they were captured.
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS referrer_host VARCHAR(255);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS source VARCHAR(16);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS utm_source VARCHAR(255);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS utm_medium VARCHAR(255);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS utm_campaign VARCHAR(255);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS utm_term VARCHAR(255);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS utm_content VARCHAR(255);
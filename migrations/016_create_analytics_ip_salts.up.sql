@@ -0,0 +1,6 @@
+-- Random salt per UTC day for hashing click IP addresses in the "hash" privacy mode.
+-- Salts are deleted the day after theirs, so stored hashes can no longer be linked to addresses.
+CREATE TABLE IF NOT EXISTS analytics_ip_salts (
+    day DATE PRIMARY KEY,
+    salt BYTEA NOT NULL
+);
@@ -0,0 +1,8 @@
+ALTER TABLE analytics_unique_ips DROP COLUMN IF EXISTS human;
+ALTER TABLE analytics_daily DROP COLUMN IF EXISTS human_last_click;
+ALTER TABLE analytics_daily DROP COLUMN IF EXISTS human_unique_ips;
+ALTER TABLE analytics_daily DROP COLUMN IF EXISTS human_clicks;
+ALTER TABLE analytics_hourly DROP COLUMN IF EXISTS human_last_click;
+ALTER TABLE analytics_hourly DROP COLUMN IF EXISTS human_unique_ips;
+ALTER TABLE analytics_hourly DROP COLUMN IF EXISTS human_clicks;
+ALTER TABLE analytics DROP COLUMN IF EXISTS bot_reason;
@@ -0,0 +1,42 @@
+-- Why a click was flagged as a bot: user_agent, ip_range or burst; NULL for human clicks.
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS bot_reason VARCHAR(16);
+UPDATE analytics SET bot_reason = 'user_agent' WHERE is_bot AND bot_reason IS NULL;
+
+-- Rollups count human clicks separately, so stats can exclude bots without scanning raw clicks.
+ALTER TABLE analytics_hourly ADD COLUMN IF NOT EXISTS human_clicks BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE analytics_hourly ADD COLUMN IF NOT EXISTS human_unique_ips BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE analytics_hourly ADD COLUMN IF NOT EXISTS human_last_click TIMESTAMP WITH TIME ZONE;
+ALTER TABLE analytics_daily ADD COLUMN IF NOT EXISTS human_clicks BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE analytics_daily ADD COLUMN IF NOT EXISTS human_unique_ips BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE analytics_daily ADD COLUMN IF NOT EXISTS human_last_click TIMESTAMP WITH TIME ZONE;
+-- human is set once any click from the address was not a bot.
+ALTER TABLE analytics_unique_ips ADD COLUMN IF NOT EXISTS human BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE analytics_hourly h
+SET human_clicks = s.clicks, human_unique_ips = s.unique_ips, human_last_click = s.last_click
+FROM (
+    SELECT short_code, date_trunc('hour', clicked_at, 'UTC') AS bucket,
+        COUNT(*) AS clicks, COUNT(DISTINCT ip_address) AS unique_ips, MAX(clicked_at) AS last_click
+    FROM analytics
+    WHERE NOT is_bot
+    GROUP BY 1, 2
+) s
+WHERE h.short_code = s.short_code AND h.bucket = s.bucket;
+
+UPDATE analytics_daily d
+SET human_clicks = s.clicks, human_unique_ips = s.unique_ips, human_last_click = s.last_click
+FROM (
+    SELECT short_code, date_trunc('day', clicked_at, 'UTC') AS bucket,
+        COUNT(*) AS clicks, COUNT(DISTINCT ip_address) AS unique_ips, MAX(clicked_at) AS last_click
+    FROM analytics
+    WHERE NOT is_bot
+    GROUP BY 1, 2
+) s
+WHERE d.short_code = s.short_code AND d.bucket = s.bucket;
+
+UPDATE analytics_unique_ips u
+SET human = TRUE
+WHERE EXISTS (
+    SELECT 1 FROM analytics a
+    WHERE a.short_code = u.short_code AND a.ip_address = u.ip_address AND NOT a.is_bot
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS analytics_breakdown_ips;
+DROP TABLE IF EXISTS analytics_daily_breakdown;
+ALTER TABLE analytics_rollup_state DROP COLUMN IF EXISTS settled_up_to;
+DROP INDEX IF EXISTS idx_analytics_inserted_at;
+ALTER TABLE analytics DROP COLUMN IF EXISTS inserted_at;
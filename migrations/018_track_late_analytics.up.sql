@@ -0,0 +1,78 @@
+-- When a click was recorded, so the rollup job can find clicks recorded after their hour was
+-- rolled up. Clicks recorded before this migration stay NULL.
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS inserted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE analytics ALTER COLUMN inserted_at SET DEFAULT NOW();
+CREATE INDEX IF NOT EXISTS idx_analytics_inserted_at ON analytics(inserted_at);
+
+-- Clicks recorded before settled_up_to are in the rollups whenever they were clicked.
+ALTER TABLE analytics_rollup_state ADD COLUMN IF NOT EXISTS settled_up_to TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+-- Clicks per short code, UTC day and breakdown dimension value. context is the country of
+-- region and city values, the AS organization of ASN values and the source of referrer hosts.
+CREATE TABLE IF NOT EXISTS analytics_daily_breakdown (
+    short_code VARCHAR(255) NOT NULL,
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    dimension VARCHAR(32) NOT NULL,
+    value TEXT NOT NULL,
+    context TEXT NOT NULL,
+    clicks BIGINT NOT NULL,
+    human_clicks BIGINT NOT NULL,
+    PRIMARY KEY (short_code, dimension, bucket, value, context)
+);
+
+-- Distinct IPs per short code and breakdown dimension value. context is the country of region
+-- and city values and empty otherwise.
+CREATE TABLE IF NOT EXISTS analytics_breakdown_ips (
+    short_code VARCHAR(255) NOT NULL,
+    dimension VARCHAR(32) NOT NULL,
+    value TEXT NOT NULL,
+    context TEXT NOT NULL,
+    ip_address VARCHAR(255) NOT NULL,
+    human BOOLEAN NOT NULL,
+    PRIMARY KEY (short_code, dimension, value, context, ip_address)
+);
+
+INSERT INTO analytics_daily_breakdown (short_code, bucket, dimension, value, context, clicks, human_clicks)
+SELECT a.short_code, date_trunc('day', a.clicked_at, 'UTC'), d.dimension, d.value, d.context,
+    COUNT(*), COUNT(*) FILTER (WHERE NOT a.is_bot)
+FROM analytics a
+CROSS JOIN analytics_rollup_state s
+CROSS JOIN LATERAL (VALUES
+    ('asn', COALESCE(a.asn::text, 'unknown'), COALESCE(a.as_org, ''), ''),
+    ('browser', COALESCE(a.browser, 'unknown'), '', ''),
+    ('city', COALESCE(a.city, 'unknown'), COALESCE(a.country, ''), COALESCE(a.country, '')),
+    ('country', COALESCE(a.country, 'unknown'), '', ''),
+    ('device', COALESCE(a.device, 'unknown'), '', ''),
+    ('os', COALESCE(a.os, 'unknown'), '', ''),
+    ('referrer', a.referrer_host, COALESCE(a.source, ''), ''),
+    ('region', COALESCE(a.region, 'unknown'), COALESCE(a.country, ''), COALESCE(a.country, '')),
+    ('source', COALESCE(a.source, 'unknown'), '', ''),
+    ('utm_campaign', a.utm_campaign, '', ''),
+    ('utm_medium', a.utm_medium, '', ''),
+    ('utm_source', a.utm_source, '', '')
+) AS d(dimension, value, context, key)
+WHERE a.clicked_at < date_trunc('day', s.rolled_up_to, 'UTC') AND d.value IS NOT NULL
+GROUP BY 1, 2, 3, 4, 5
+ON CONFLICT DO NOTHING;
+
+INSERT INTO analytics_breakdown_ips (short_code, dimension, value, context, ip_address, human)
+SELECT a.short_code, d.dimension, d.value, d.key, a.ip_address, bool_or(NOT a.is_bot)
+FROM analytics a
+CROSS JOIN analytics_rollup_state s
+CROSS JOIN LATERAL (VALUES
+    ('asn', COALESCE(a.asn::text, 'unknown'), COALESCE(a.as_org, ''), ''),
+    ('browser', COALESCE(a.browser, 'unknown'), '', ''),
+    ('city', COALESCE(a.city, 'unknown'), COALESCE(a.country, ''), COALESCE(a.country, '')),
+    ('country', COALESCE(a.country, 'unknown'), '', ''),
+    ('device', COALESCE(a.device, 'unknown'), '', ''),
+    ('os', COALESCE(a.os, 'unknown'), '', ''),
+    ('referrer', a.referrer_host, COALESCE(a.source, ''), ''),
+    ('region', COALESCE(a.region, 'unknown'), COALESCE(a.country, ''), COALESCE(a.country, '')),
+    ('source', COALESCE(a.source, 'unknown'), '', ''),
+    ('utm_campaign', a.utm_campaign, '', ''),
+    ('utm_medium', a.utm_medium, '', ''),
+    ('utm_source', a.utm_source, '', '')
+) AS d(dimension, value, context, key)
+WHERE a.clicked_at < s.rolled_up_to AND a.ip_address IS NOT NULL AND d.value IS NOT NULL
+GROUP BY 1, 2, 3, 4, 5
+ON CONFLICT DO NOTHING;
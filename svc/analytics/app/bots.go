@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"url-shorterner/internal/cache"
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/internal/iprange"
+	"url-shorterner/internal/log"
+	"url-shorterner/internal/useragent"
+	"url-shorterner/svc/analytics/events"
+)
+
+// Reasons for flagging a click as a bot, in order of precedence.
+const (
+	// BotReasonUserAgent marks user agents of crawlers, link previewers and HTTP libraries.
+	BotReasonUserAgent = "user_agent"
+	// BotReasonIPRange marks addresses in a known crawler network.
+	BotReasonIPRange = "ip_range"
+	// BotReasonBurst marks clicks beyond the burst limit of an address on a link.
+	BotReasonBurst = "burst"
+)
+
+// ParseIncludeBots parses the include_bots query parameter, which asks to count bot clicks.
+// Empty values count human clicks only; values other than booleans are invalid.
+func ParseIncludeBots(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	include, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, appErrors.Invalid(appErrors.ErrCodeInvalidIncludeBots, map[string]interface{}{"Value": value})
+	}
+	return include, nil
+}
+
+// BotOptions configures bot detection beyond user agent patterns.
+type BotOptions struct {
+	// Crawlers lists known crawler networks; nil matches none.
+	Crawlers *iprange.List
+	// Bursts counts recent clicks per address and link; nil disables burst detection.
+	Bursts *cache.BurstCache
+	// BurstClicks is the number of clicks of one address on one link within BurstWindow
+	// after which further clicks are bots; 0 disables burst detection.
+	BurstClicks int
+	// BurstWindow is the length of the windows clicks are counted in.
+	BurstWindow time.Duration
+}
+
+// botReason returns why the click of event at clickedAt is a bot, or "" for a human click.
+// Bursts are counted under the pseudonym of the address from ips, so cache keys do not
+// reveal it.
+func (o BotOptions) botReason(
+	ctx context.Context,
+	event events.ClickEvent,
+	clickedAt time.Time,
+	agent useragent.Info,
+	ips *ipAnonymizer,
+) string {
+	if agent.Bot {
+		return BotReasonUserAgent
+	}
+	if _, ok := o.Crawlers.Match(event.IPAddress); ok {
+		return BotReasonIPRange
+	}
+	if o.Bursts == nil || o.BurstClicks <= 0 || event.IPAddress == "" {
+		return ""
+	}
+
+	visitor, err := ips.pseudonym(ctx, event.IPAddress, clickedAt)
+	if err != nil {
+		// Detection is best effort; the click is recorded as human.
+		log.Error("Failed to count click burst of %s: %v", event.ShortCode, err)
+		return ""
+	}
+	clicks, err := o.Bursts.Hit(ctx, event.ShortCode+":"+visitor, o.BurstWindow)
+	if err != nil {
+		log.Error("Failed to count click burst of %s: %v", event.ShortCode, err)
+		return ""
+	}
+	if clicks > int64(o.BurstClicks) {
+		return BotReasonBurst
+	}
+	return ""
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"url-shorterner/internal/cache"
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/internal/iprange"
+	"url-shorterner/internal/useragent"
+	"url-shorterner/svc/analytics/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counterCache implements the counters of cache.Cache in memory, with a clock tests move.
+type counterCache struct {
+	cache.Cache
+
+	now     time.Time
+	counts  map[string]int64
+	expires map[string]time.Time
+	err     error
+}
+
+func newCounterCache() *counterCache {
+	return &counterCache{
+		now:     time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		counts:  make(map[string]int64),
+		expires: make(map[string]time.Time),
+	}
+}
+
+func (c *counterCache) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	if expires, ok := c.expires[key]; ok && !c.now.Before(expires) {
+		delete(c.counts, key)
+		delete(c.expires, key)
+	}
+	if _, ok := c.counts[key]; !ok {
+		c.expires[key] = c.now.Add(ttl)
+	}
+	c.counts[key]++
+	return c.counts[key], nil
+}
+
+func TestBotReasonBurst(t *testing.T) {
+	ctx := context.Background()
+	counters := newCounterCache()
+	ips := newIPAnonymizer(IPModeFull, newIPStore())
+	clickedAt := time.Now().UTC()
+	options := BotOptions{
+		Bursts:      cache.NewBurstCache(counters),
+		BurstClicks: 3,
+		BurstWindow: time.Minute,
+	}
+	human := useragent.Parse("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	click := events.ClickEvent{ShortCode: "abc", IPAddress: "203.0.113.7"}
+
+	// The threshold is the number of clicks allowed per window.
+	for i := 1; i <= 3; i++ {
+		assert.Empty(t, options.botReason(ctx, click, clickedAt, human, ips), "click %d", i)
+	}
+	assert.Equal(t, BotReasonBurst, options.botReason(ctx, click, clickedAt, human, ips))
+	assert.Equal(t, BotReasonBurst, options.botReason(ctx, click, clickedAt, human, ips))
+
+	// Keys hold the salted pseudonym of the address, not the address or its plain hash.
+	visitor, err := ips.pseudonym(ctx, click.IPAddress, clickedAt)
+	require.NoError(t, err)
+	assert.Len(t, counters.counts, 1)
+	assert.Contains(t, counters.counts, "burst:abc:"+visitor)
+	sum := sha256.Sum256([]byte(click.IPAddress))
+	assert.NotContains(t, visitor, hex.EncodeToString(sum[:16]))
+
+	// Bursts are counted per address and link.
+	assert.Empty(t, options.botReason(ctx, events.ClickEvent{ShortCode: "abc", IPAddress: "203.0.113.8"}, clickedAt, human, ips))
+	assert.Empty(t, options.botReason(ctx, events.ClickEvent{ShortCode: "xyz", IPAddress: "203.0.113.7"}, clickedAt, human, ips))
+	// Clicks without an address cannot be counted.
+	assert.Empty(t, options.botReason(ctx, events.ClickEvent{ShortCode: "abc"}, clickedAt, human, ips))
+
+	// A new window starts a new count.
+	counters.now = counters.now.Add(59 * time.Second)
+	assert.Equal(t, BotReasonBurst, options.botReason(ctx, click, clickedAt, human, ips))
+	counters.now = counters.now.Add(time.Second)
+	assert.Empty(t, options.botReason(ctx, click, clickedAt, human, ips))
+
+	// Counting failures record the click as human.
+	counters.err = errors.New("redis down")
+	for i := 0; i < 5; i++ {
+		assert.Empty(t, options.botReason(ctx, click, clickedAt, human, ips))
+	}
+
+	// Burst detection is off without a threshold or a cache.
+	counters.err = nil
+	disabled := options
+	disabled.BurstClicks = 0
+	for i := 0; i < 5; i++ {
+		assert.Empty(t, disabled.botReason(ctx, click, clickedAt, human, ips))
+	}
+	disabled = options
+	disabled.Bursts = nil
+	assert.Empty(t, disabled.botReason(ctx, click, clickedAt, human, ips))
+}
+
+func TestBotReasonPrecedence(t *testing.T) {
+	ctx := context.Background()
+	ips := newIPAnonymizer(IPModeFull, newIPStore())
+	clickedAt := time.Now().UTC()
+	path := filepath.Join(t.TempDir(), "crawlers.txt")
+	require.NoError(t, os.WriteFile(path, []byte("66.249.64.0/19 Googlebot\n"), 0o600))
+	crawlers := iprange.NewList(path)
+	require.NoError(t, crawlers.Reload())
+
+	options := BotOptions{
+		Crawlers:    crawlers,
+		Bursts:      cache.NewBurstCache(newCounterCache()),
+		BurstClicks: 1,
+		BurstWindow: time.Minute,
+	}
+	human := useragent.Parse("Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15")
+	bot := useragent.Parse("curl/8.4.0")
+	crawler := events.ClickEvent{ShortCode: "abc", IPAddress: "66.249.64.10"}
+
+	assert.Equal(t, BotReasonUserAgent, options.botReason(ctx, crawler, clickedAt, bot, ips))
+	assert.Equal(t, BotReasonIPRange, options.botReason(ctx, crawler, clickedAt, human, ips))
+	assert.Equal(t, BotReasonIPRange, options.botReason(ctx, events.ClickEvent{ShortCode: "abc", IPAddress: "::ffff:66.249.64.10"}, clickedAt, human, ips))
+
+	visitor := events.ClickEvent{ShortCode: "abc", IPAddress: "203.0.113.7"}
+	assert.Empty(t, options.botReason(ctx, visitor, clickedAt, human, ips))
+	assert.Equal(t, BotReasonBurst, options.botReason(ctx, visitor, clickedAt, human, ips))
+	// The user agent wins over a burst.
+	assert.Equal(t, BotReasonUserAgent, options.botReason(ctx, visitor, clickedAt, bot, ips))
+
+	// Without crawler ranges or bursts only user agents flag bots.
+	assert.Empty(t, BotOptions{}.botReason(ctx, crawler, clickedAt, human, ips))
+}
+
+func TestParseIncludeBots(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{"", false, false},
+		{"true", true, false},
+		{"1", true, false},
+		{"false", false, false},
+		{"0", false, false},
+		{"yes", false, true},
+		{"on", false, true},
+	}
+	for _, tt := range tests {
+		include, err := ParseIncludeBots(tt.value)
+		if tt.wantErr {
+			var invalid *appErrors.InvalidError
+			if assert.ErrorAs(t, err, &invalid, tt.value) {
+				assert.Equal(t, appErrors.ErrCodeInvalidIncludeBots, invalid.GetCode())
+			}
+			continue
+		}
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, include, tt.value)
+	}
+}
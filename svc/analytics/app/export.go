@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"url-shorterner/internal/bulk"
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/svc/analytics/entity"
+)
+
+// exportField is a column of a click export.
+type exportField struct {
+	name  string
+	typ   bulk.ColumnType
+	value func(record *entity.Record) interface{}
+}
+
+// exportFields are the fields of exported clicks in default column order, with their column
+// types in typed formats such as Parquet. Values keep their types (nil pointers for unknown
+// values).
+var exportFields = []exportField{
+	{"id", bulk.ColumnString, func(r *entity.Record) interface{} { return r.ID }},
+	{"short_code", bulk.ColumnString, func(r *entity.Record) interface{} { return r.ShortCode }},
+	{"url_version", bulk.ColumnInt64, func(r *entity.Record) interface{} { return r.URLVersion }},
+	{"clicked_at", bulk.ColumnTimestamp, func(r *entity.Record) interface{} { return r.ClickedAt }},
+	{"ip_address", bulk.ColumnString, func(r *entity.Record) interface{} { return r.IPAddress }},
+	{"user_agent", bulk.ColumnString, func(r *entity.Record) interface{} { return r.UserAgent }},
+	{"browser", bulk.ColumnString, func(r *entity.Record) interface{} { return r.Browser }},
+	{"browser_version", bulk.ColumnString, func(r *entity.Record) interface{} { return r.BrowserVersion }},
+	{"os", bulk.ColumnString, func(r *entity.Record) interface{} { return r.OS }},
+	{"device", bulk.ColumnString, func(r *entity.Record) interface{} { return r.Device }},
+	{"is_bot", bulk.ColumnBool, func(r *entity.Record) interface{} { return r.IsBot }},
+	{"bot_reason", bulk.ColumnString, func(r *entity.Record) interface{} { return r.BotReason }},
+	{"country", bulk.ColumnString, func(r *entity.Record) interface{} { return r.Country }},
+	{"region", bulk.ColumnString, func(r *entity.Record) interface{} { return r.Region }},
+	{"city", bulk.ColumnString, func(r *entity.Record) interface{} { return r.City }},
+	{"asn", bulk.ColumnInt64, func(r *entity.Record) interface{} {
+		if r.ASN == 0 {
+			return (*uint32)(nil)
+		}
+		return r.ASN
+	}},
+	{"as_org", bulk.ColumnString, func(r *entity.Record) interface{} { return r.ASOrg }},
+	{"referer", bulk.ColumnString, func(r *entity.Record) interface{} { return r.Referer }},
+	{"referrer_host", bulk.ColumnString, func(r *entity.Record) interface{} { return r.ReferrerHost }},
+	{"source", bulk.ColumnString, func(r *entity.Record) interface{} { return r.Source }},
+	{"utm_source", bulk.ColumnString, func(r *entity.Record) interface{} { return r.UTMSource }},
+	{"utm_medium", bulk.ColumnString, func(r *entity.Record) interface{} { return r.UTMMedium }},
+	{"utm_campaign", bulk.ColumnString, func(r *entity.Record) interface{} { return r.UTMCampaign }},
+	{"utm_term", bulk.ColumnString, func(r *entity.Record) interface{} { return r.UTMTerm }},
+	{"utm_content", bulk.ColumnString, func(r *entity.Record) interface{} { return r.UTMContent }},
+}
+
+// ExportFields returns the names of all export fields in default column order.
+func ExportFields() []string {
+	names := make([]string, len(exportFields))
+	for i, field := range exportFields {
+		names[i] = field.name
+	}
+	return names
+}
+
+// ParseExportQuery parses the code, from, to and fields parameters of a click export. from and
+// to are RFC 3339 timestamps or YYYY-MM-DD dates (midnight UTC); either may be omitted to leave
+// the range open. fields is a comma-separated list of ExportFields in column order, all by default.
+func ParseExportQuery(code, from, to, fields string) (entity.ExportQuery, []string, error) {
+	query := entity.ExportQuery{ShortCode: strings.TrimSpace(code)}
+
+	var ok bool
+	if from != "" {
+		if query.From, ok = parseTime(from, time.UTC); !ok {
+			return query, nil, appErrors.Invalid(appErrors.ErrCodeInvalidTimeRange, nil)
+		}
+	}
+	if to != "" {
+		if query.To, ok = parseTime(to, time.UTC); !ok {
+			return query, nil, appErrors.Invalid(appErrors.ErrCodeInvalidTimeRange, nil)
+		}
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return query, nil, appErrors.Invalid(appErrors.ErrCodeInvalidTimeRange, nil)
+	}
+
+	columns := make([]string, 0, len(exportFields))
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(fields, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		if exportFieldIndex(name) < 0 {
+			return query, nil, appErrors.Invalid(appErrors.ErrCodeInvalidExportField, map[string]interface{}{
+				"Field":   name,
+				"Allowed": strings.Join(ExportFields(), ", "),
+			})
+		}
+		seen[name] = true
+		columns = append(columns, name)
+	}
+	if len(columns) == 0 {
+		columns = ExportFields()
+	}
+	return query, columns, nil
+}
+
+// ExportColumns returns the typed columns of the export fields in columns, which must be
+// export fields.
+func ExportColumns(columns []string) []bulk.Column {
+	typed := make([]bulk.Column, len(columns))
+	for i, name := range columns {
+		typed[i] = bulk.Column{Name: name, Type: exportFields[exportFieldIndex(name)].typ}
+	}
+	return typed
+}
+
+// ExportValues returns the values of record for columns, which must be export fields.
+func ExportValues(record *entity.Record, columns []string) []interface{} {
+	values := make([]interface{}, len(columns))
+	for i, name := range columns {
+		values[i] = exportFields[exportFieldIndex(name)].value(record)
+	}
+	return values
+}
+
+func exportFieldIndex(name string) int {
+	for i, field := range exportFields {
+		if field.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// ExportClicks streams every click matching query, oldest first, to emit. Rows are read
+// through a server-side cursor on the reader pool.
+func (s *service) ExportClicks(ctx context.Context, query entity.ExportQuery, emit func(record *entity.Record) error) error {
+	return s.dao.StreamRecords(ctx, query, emit)
+}
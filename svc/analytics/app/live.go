@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"url-shorterner/internal/cache"
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/internal/log"
+	"url-shorterner/svc/analytics/entity"
+)
+
+// defaultSummaryInterval is used when LiveOptions.SummaryInterval is not set.
+const defaultSummaryInterval = 10 * time.Second
+
+// LiveOptions configures the live click feeds.
+type LiveOptions struct {
+	// Feed broadcasts recorded clicks to the feeds of all instances; nil disables the feeds.
+	Feed *cache.LiveCache
+	// SummaryInterval is how often feeds send a count summary.
+	SummaryInterval time.Duration
+}
+
+// publish broadcasts a recorded click to the live feeds of its short code.
+func (o LiveOptions) publish(ctx context.Context, record *entity.Record) {
+	if o.Feed == nil {
+		return
+	}
+	message, err := json.Marshal(entity.LiveClick{
+		Timestamp:    record.ClickedAt,
+		Country:      record.Country,
+		ReferrerHost: record.ReferrerHost,
+		Source:       record.Source,
+		Device:       record.Device,
+		IsBot:        record.IsBot,
+	})
+	if err != nil {
+		log.Error("Failed to encode live click of %s: %v", record.ShortCode, err)
+		return
+	}
+	// The feeds are best effort; the click itself is recorded.
+	if err := o.Feed.Publish(ctx, record.ShortCode, string(message)); err != nil {
+		log.Error("Failed to publish live click of %s: %v", record.ShortCode, err)
+	}
+}
+
+// stream forwards the clicks of messages to events, with a summary when it starts and every
+// summary interval, until ctx is done or messages is closed. totalClicks is the number of
+// clicks of the link when the stream starts.
+func (o LiveOptions) stream(ctx context.Context, messages <-chan string, includeBots bool, totalClicks int64, events chan<- entity.LiveEvent) {
+	defer close(events)
+
+	interval := o.SummaryInterval
+	if interval <= 0 {
+		interval = defaultSummaryInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	send := func(event entity.LiveEvent) bool {
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	summary := entity.LiveSummary{TotalClicks: totalClicks}
+	summarize := func(now time.Time) bool {
+		current := summary
+		current.Timestamp = now.UTC()
+		summary.IntervalClicks = 0
+		return send(entity.LiveEvent{Summary: &current})
+	}
+
+	// The first summary opens the stream before any click arrives.
+	if !summarize(time.Now()) {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			var click entity.LiveClick
+			if err := json.Unmarshal([]byte(message), &click); err != nil {
+				log.Error("Failed to decode live click: %v", err)
+				continue
+			}
+			if click.IsBot && !includeBots {
+				continue
+			}
+			summary.TotalClicks++
+			summary.Clicks++
+			summary.IntervalClicks++
+			if !send(entity.LiveEvent{Click: &click}) {
+				return
+			}
+		case now := <-ticker.C:
+			if !summarize(now) {
+				return
+			}
+		}
+	}
+}
+
+// StreamClicks streams the clicks on shortCode recorded by any instance from now on, with a
+// count summary every summary interval, until ctx is done. Bot clicks are skipped unless
+// includeBots is set.
+func (s *service) StreamClicks(ctx context.Context, shortCode string, includeBots bool) (<-chan entity.LiveEvent, error) {
+	if s.live.Feed == nil {
+		return nil, fmt.Errorf("live click feed is not configured")
+	}
+	exists, err := s.dao.LinkExists(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, appErrors.NotFound(appErrors.ResourceURL)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	messages, err := s.live.Feed.Subscribe(ctx, shortCode)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// Counting after subscribing misses no click, at the cost of counting clicks recorded in
+	// between twice.
+	stats, err := s.dao.GetAnalyticsStats(ctx, shortCode, includeBots)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	events := make(chan entity.LiveEvent)
+	go func() {
+		defer cancel()
+		s.live.stream(ctx, messages, includeBots, int64(stats.TotalClicks), events)
+	}()
+	return events, nil
+}
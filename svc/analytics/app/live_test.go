@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"url-shorterner/internal/cache"
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/svc/analytics/entity"
+	analyticsStore "url-shorterner/svc/analytics/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// linkStats knows a set of links and their click totals.
+type linkStats struct {
+	analyticsStore.DAO
+
+	clicks map[string]int
+}
+
+func (d *linkStats) LinkExists(_ context.Context, shortCode string) (bool, error) {
+	_, ok := d.clicks[shortCode]
+	return ok, nil
+}
+
+func (d *linkStats) GetAnalyticsStats(_ context.Context, shortCode string, _ bool) (*entity.Stats, error) {
+	return &entity.Stats{TotalClicks: d.clicks[shortCode]}, nil
+}
+
+// feedCache implements the pub/sub of cache.Cache in memory.
+type feedCache struct {
+	cache.Cache
+
+	mu          sync.Mutex
+	subscribers map[string][]chan string
+}
+
+func (f *feedCache) Publish(_ context.Context, channel, message string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, subscriber := range f.subscribers[channel] {
+		subscriber <- message
+	}
+	return nil
+}
+
+func (f *feedCache) Subscribe(_ context.Context, channel string) (<-chan string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages := make(chan string, 16)
+	f.subscribers[channel] = append(f.subscribers[channel], messages)
+	return messages, nil
+}
+
+func TestStreamClicks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	feed := cache.NewLiveCache(&feedCache{subscribers: make(map[string][]chan string)})
+	defer feed.Close()
+	svc := NewService(nil, &linkStats{clicks: map[string]int{"abc": 41}}, nil, nil, "", nil, BotOptions{},
+		LiveOptions{Feed: feed, SummaryInterval: time.Hour})
+
+	_, err := svc.StreamClicks(ctx, "missing", false)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, appErrors.StatusCode(err))
+
+	events, err := svc.StreamClicks(ctx, "abc", false)
+	require.NoError(t, err)
+	first := <-events
+	require.NotNil(t, first.Summary)
+	assert.Equal(t, int64(41), first.Summary.TotalClicks)
+	assert.Zero(t, first.Summary.Clicks)
+
+	for _, click := range []entity.LiveClick{{IsBot: true}, {Country: "FR"}} {
+		message, err := json.Marshal(click)
+		require.NoError(t, err)
+		require.NoError(t, feed.Publish(ctx, "abc", string(message)))
+	}
+	event := <-events
+	require.NotNil(t, event.Click)
+	assert.Equal(t, "FR", event.Click.Country)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestLiveStreamSummaries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messages := make(chan string, 3)
+	events := make(chan entity.LiveEvent)
+	go LiveOptions{SummaryInterval: 10 * time.Millisecond}.stream(ctx, messages, true, 10, events)
+
+	first := <-events
+	require.NotNil(t, first.Summary)
+	assert.Equal(t, int64(10), first.Summary.TotalClicks)
+	for _, message := range []string{`{"is_bot":true}`, `not json`, `{"country":"FR"}`} {
+		messages <- message
+	}
+
+	// Summaries keep counting until both clicks are in, then count no new clicks.
+	var interval int64
+	for event := range events {
+		if event.Summary == nil {
+			continue
+		}
+		interval += event.Summary.IntervalClicks
+		assert.Equal(t, 10+event.Summary.Clicks, event.Summary.TotalClicks)
+		if event.Summary.Clicks == 2 && event.Summary.IntervalClicks == 0 {
+			break
+		}
+	}
+	assert.Equal(t, int64(2), interval)
+
+	close(messages)
+	for range events {
+	}
+}
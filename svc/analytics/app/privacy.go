@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"sync"
+	"time"
+
+	analyticsStore "url-shorterner/svc/analytics/store"
+)
+
+// IPMode is how click IP addresses are stored.
+type IPMode string
+
+const (
+	// IPModeFull stores addresses as received.
+	IPModeFull IPMode = "full"
+	// IPModeTruncated stores the /24 network of IPv4 and the /48 network of IPv6 addresses,
+	// so unique counts count networks.
+	IPModeTruncated IPMode = "truncated"
+	// IPModeHash stores a hash of the address salted per UTC day with a random salt that is
+	// deleted the day after, so unique counts are exact within a day and count a returning
+	// visitor once per day across days. Clicks older than the previous day, whose salt is gone,
+	// are hashed with the previous day's salt.
+	IPModeHash IPMode = "hash"
+)
+
+// saltSize is the length in bytes of generated daily salts.
+const saltSize = 32
+
+// anonymizeBatchSize is the number of stored clicks rewritten per round trip by
+// AnonymizeStoredIPs.
+const anonymizeBatchSize = 1000
+
+// ipAnonymizer transforms addresses according to an IPMode before they are stored.
+type ipAnonymizer struct {
+	mode IPMode
+	repo analyticsStore.Repository
+	now  func() time.Time
+
+	mu sync.Mutex
+	// salts caches the salts of the days seen last, by UTC date.
+	salts map[string][]byte
+}
+
+func newIPAnonymizer(mode IPMode, repo analyticsStore.Repository) *ipAnonymizer {
+	if mode == "" {
+		mode = IPModeFull
+	}
+	return &ipAnonymizer{
+		mode:  mode,
+		repo:  repo,
+		now:   time.Now,
+		salts: make(map[string][]byte),
+	}
+}
+
+// anonymize returns the form of ip to store for a click at clickedAt.
+// Addresses that do not parse are dropped in the truncated and hash modes.
+func (a *ipAnonymizer) anonymize(ctx context.Context, ip string, clickedAt time.Time) (string, error) {
+	if a.mode == IPModeFull || ip == "" {
+		return ip, nil
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", nil
+	}
+	addr = addr.Unmap()
+
+	switch a.mode {
+	case IPModeTruncated:
+		bits := 48
+		if addr.Is4() {
+			bits = 24
+		}
+		prefix, err := addr.WithZone("").Prefix(bits)
+		if err != nil {
+			return "", err
+		}
+		return prefix.Addr().String(), nil
+	case IPModeHash:
+		return a.pseudonym(ctx, addr.WithZone("").String(), clickedAt)
+	default:
+		return "", fmt.Errorf("unsupported IP mode %q", a.mode)
+	}
+}
+
+// pseudonym returns the HMAC of ip keyed with the salt of the UTC day of t, which tells
+// addresses apart within a day without revealing them, whatever the mode.
+func (a *ipAnonymizer) pseudonym(ctx context.Context, ip string, t time.Time) (string, error) {
+	salt, err := a.salt(ctx, t)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// salt returns the salt of the UTC day of t, creating it on first use across all replicas.
+// Days before the previous one have no salt anymore and use the previous day's, rather than
+// a new salt that would be kept past its day.
+func (a *ipAnonymizer) salt(ctx context.Context, t time.Time) ([]byte, error) {
+	if oldest := a.now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1); t.Before(oldest) {
+		t = oldest
+	}
+	day := t.UTC().Format(time.DateOnly)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if salt, ok := a.salts[day]; ok {
+		return salt, nil
+	}
+
+	candidate := make([]byte, saltSize)
+	if _, err := rand.Read(candidate); err != nil {
+		return nil, err
+	}
+	salt, err := a.repo.DailySalt(ctx, t, candidate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the salts of this and the previous day only, like the database.
+	previous := t.UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	for cached := range a.salts {
+		if cached != previous {
+			delete(a.salts, cached)
+		}
+	}
+	a.salts[day] = salt
+	return salt, nil
+}
+
+// AnonymizeStoredIPs rewrites the IP addresses stored by a previous, less private mode in the
+// form mode stores, e.g. after switching from full to hash, and returns the number of clicks
+// rewritten. Addresses already stored by mode are left alone. When any click was rewritten,
+// the rollups are reset so the rollup job rebuilds their IP sets from the rewritten clicks.
+func AnonymizeStoredIPs(ctx context.Context, repo analyticsStore.Repository, mode IPMode) (int, error) {
+	anonymizer := newIPAnonymizer(mode, repo)
+	if anonymizer.mode == IPModeFull {
+		return 0, nil
+	}
+
+	rewritten := 0
+	for after := ""; ; {
+		records, err := repo.ListStoredIPs(ctx, after, anonymizeBatchSize)
+		if err != nil {
+			return rewritten, err
+		}
+		if len(records) == 0 {
+			break
+		}
+		ips := make(map[string]string)
+		for _, record := range records {
+			// Hashes are no addresses and are already anonymous.
+			if _, err := netip.ParseAddr(record.IPAddress); err != nil {
+				continue
+			}
+			ip, err := anonymizer.anonymize(ctx, record.IPAddress, record.ClickedAt)
+			if err != nil {
+				return rewritten, err
+			}
+			if ip != record.IPAddress {
+				ips[record.ID] = ip
+			}
+		}
+		if err := repo.UpdateIPs(ctx, ips); err != nil {
+			return rewritten, err
+		}
+		rewritten += len(ips)
+		after = records[len(records)-1].ID
+	}
+
+	if rewritten > 0 {
+		if err := repo.ResetRollups(ctx); err != nil {
+			return rewritten, err
+		}
+	}
+	return rewritten, nil
+}
@@ -0,0 +1,178 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"url-shorterner/svc/analytics/entity"
+	analyticsStore "url-shorterner/svc/analytics/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ipStore keeps salts and stored click addresses in memory.
+type ipStore struct {
+	analyticsStore.Repository
+
+	salts     map[string][]byte
+	saltDays  []string
+	records   map[string]*entity.Record
+	resets    int
+	listLimit []int
+}
+
+func newIPStore() *ipStore {
+	return &ipStore{
+		salts:   make(map[string][]byte),
+		records: make(map[string]*entity.Record),
+	}
+}
+
+func (s *ipStore) DailySalt(_ context.Context, day time.Time, candidate []byte) ([]byte, error) {
+	key := day.UTC().Format(time.DateOnly)
+	s.saltDays = append(s.saltDays, key)
+	if salt, ok := s.salts[key]; ok {
+		return salt, nil
+	}
+	s.salts[key] = append([]byte(nil), candidate...)
+	return s.salts[key], nil
+}
+
+func (s *ipStore) ListStoredIPs(_ context.Context, after string, limit int) ([]*entity.Record, error) {
+	s.listLimit = append(s.listLimit, limit)
+	ids := make([]string, 0, len(s.records))
+	for id, record := range s.records {
+		if id > after && record.IPAddress != "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	records := make([]*entity.Record, len(ids))
+	for i, id := range ids {
+		record := *s.records[id]
+		records[i] = &record
+	}
+	return records, nil
+}
+
+func (s *ipStore) UpdateIPs(_ context.Context, ips map[string]string) error {
+	for id, ip := range ips {
+		s.records[id].IPAddress = ip
+	}
+	return nil
+}
+
+func (s *ipStore) ResetRollups(context.Context) error {
+	s.resets++
+	return nil
+}
+
+func (s *ipStore) add(ip string, clickedAt time.Time) string {
+	id := fmt.Sprintf("%08d", len(s.records))
+	s.records[id] = &entity.Record{ID: id, IPAddress: ip, ClickedAt: clickedAt}
+	return id
+}
+
+func TestAnonymizeFull(t *testing.T) {
+	anonymizer := newIPAnonymizer("", newIPStore())
+	for _, ip := range []string{"203.0.113.77", "2001:db8::1", "not-an-ip", ""} {
+		got, err := anonymizer.anonymize(context.Background(), ip, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, ip, got)
+	}
+}
+
+func TestAnonymizeTruncated(t *testing.T) {
+	anonymizer := newIPAnonymizer(IPModeTruncated, newIPStore())
+	tests := map[string]string{
+		"203.0.113.77":          "203.0.113.0",
+		"203.0.113.0":           "203.0.113.0",
+		"::ffff:203.0.113.77":   "203.0.113.0",
+		"2001:db8:abcd:12:1::1": "2001:db8:abcd::",
+		"fe80::1%eth0":          "fe80::",
+		"not-an-ip":             "",
+		"":                      "",
+	}
+	for ip, want := range tests {
+		got, err := anonymizer.anonymize(context.Background(), ip, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, want, got, ip)
+	}
+}
+
+func TestAnonymizeHash(t *testing.T) {
+	ctx := context.Background()
+	store := newIPStore()
+	anonymizer := newIPAnonymizer(IPModeHash, store)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	anonymizer.now = func() time.Time { return now }
+
+	hash := func(ip string, clickedAt time.Time) string {
+		t.Helper()
+		got, err := anonymizer.anonymize(ctx, ip, clickedAt)
+		require.NoError(t, err)
+		return got
+	}
+
+	today := hash("203.0.113.77", now)
+	assert.Len(t, today, 64)
+	assert.Equal(t, today, hash("203.0.113.77", now.Add(-time.Hour)))
+	assert.Equal(t, today, hash("::ffff:203.0.113.77", now))
+	assert.NotEqual(t, today, hash("203.0.113.78", now))
+	assert.Empty(t, hash("not-an-ip", now))
+
+	yesterday := hash("203.0.113.77", now.AddDate(0, 0, -1))
+	assert.NotEqual(t, today, yesterday)
+
+	// A late click for a day whose salt was deleted takes the previous day's salt instead of
+	// a new one.
+	assert.Equal(t, yesterday, hash("203.0.113.77", now.AddDate(0, 0, -5)))
+	assert.Equal(t, []string{"2026-10-18", "2026-10-17"}, store.saltDays)
+}
+
+func TestAnonymizeStoredIPs(t *testing.T) {
+	ctx := context.Background()
+	store := newIPStore()
+	now := time.Now().UTC()
+	for i := 0; i < anonymizeBatchSize+10; i++ {
+		store.add(fmt.Sprintf("198.51.100.%d", i%256), now)
+	}
+	full := store.add("203.0.113.77", now)
+	truncated := store.add("203.0.113.0", now)
+	hashed := store.add("3f1c2b7e9a0d4c5f6e7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e", now)
+
+	count, err := AnonymizeStoredIPs(ctx, store, IPModeFull)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Empty(t, store.listLimit)
+
+	count, err = AnonymizeStoredIPs(ctx, store, IPModeTruncated)
+	require.NoError(t, err)
+	// 198.51.100.0 is already a network address.
+	assert.Equal(t, anonymizeBatchSize+10-4+1, count)
+	assert.Equal(t, "203.0.113.0", store.records[full].IPAddress)
+	assert.Equal(t, "203.0.113.0", store.records[truncated].IPAddress)
+	assert.Equal(t, 1, store.resets)
+	assert.Len(t, store.listLimit, 3)
+
+	// Rerunning finds nothing left to rewrite and keeps the rollups.
+	count, err = AnonymizeStoredIPs(ctx, store, IPModeTruncated)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Equal(t, 1, store.resets)
+
+	count, err = AnonymizeStoredIPs(ctx, store, IPModeHash)
+	require.NoError(t, err)
+	assert.Equal(t, anonymizeBatchSize+10+2, count)
+	assert.Len(t, store.records[full].IPAddress, 64)
+	assert.Equal(t, store.records[full].IPAddress, store.records[truncated].IPAddress)
+	assert.Equal(t, "3f1c2b7e9a0d4c5f6e7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e", store.records[hashed].IPAddress)
+	assert.Equal(t, 2, store.resets)
+}
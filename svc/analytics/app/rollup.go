@@ -0,0 +1,67 @@
+// Package app provides the core business logic for analytics operations.
+package app
+
+import (
+	"context"
+	"time"
+
+	"url-shorterner/internal/log"
+	analyticsStore "url-shorterner/svc/analytics/store"
+)
+
+// rollupStep bounds the raw clicks folded into the rollups per transaction.
+const rollupStep = 24 * time.Hour
+
+// RollupJob periodically folds raw clicks into the hourly and daily rollup tables that
+// GetStats and GetTimeSeries read, so they only scan the clicks of the current hour or day.
+type RollupJob struct {
+	repo  analyticsStore.Repository
+	grace time.Duration
+}
+
+// NewRollupJob creates a new rollup job. Hours are rolled up once grace has passed after
+// their end, leaving time for in-flight click events to be recorded; clicks recorded later
+// are folded into their rolled up buckets once grace has passed after they were recorded.
+func NewRollupJob(repo analyticsStore.Repository, grace time.Duration) *RollupJob {
+	return &RollupJob{
+		repo:  repo,
+		grace: grace,
+	}
+}
+
+// Run rolls up every complete hour and returns the new watermark.
+func (j *RollupJob) Run(ctx context.Context) (time.Time, error) {
+	settled := time.Now().UTC().Add(-j.grace)
+	target := settled.Truncate(time.Hour)
+	for {
+		watermark, err := j.repo.RollUp(ctx, target, settled, rollupStep)
+		if err != nil || !watermark.Before(target) {
+			return watermark, err
+		}
+		if ctx.Err() != nil {
+			return watermark, ctx.Err()
+		}
+	}
+}
+
+// Start runs Run every interval until ctx is canceled.
+func (j *RollupJob) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := j.Run(ctx); err != nil {
+					log.Error("Analytics rollup failed: %v", err)
+				}
+			}
+		}
+	}()
+}
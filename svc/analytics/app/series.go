@@ -0,0 +1,99 @@
+// Package app provides the core business logic for analytics operations.
+package app
+
+import (
+	"strings"
+	"time"
+	// Embedded so tz works on hosts without a zoneinfo database.
+	_ "time/tzdata"
+
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/svc/analytics/entity"
+)
+
+const (
+	// defaultSeriesRange is the time range of a series query without from.
+	defaultSeriesRange = 30 * 24 * time.Hour
+	// maxSeriesPoints bounds the number of buckets of a series, e.g. about 200 days of hours.
+	maxSeriesPoints = 5000
+)
+
+// ParseSeriesQuery parses the from, to, interval and tz query parameters of a time series.
+// tz is an IANA time zone name (default UTC) in which buckets start; from and to are RFC 3339
+// timestamps or YYYY-MM-DD dates (midnight in tz). to defaults to now, from to 30 days before
+// to, and interval to day.
+func ParseSeriesQuery(from, to, interval, tz string) (entity.SeriesQuery, error) {
+	query := entity.SeriesQuery{Interval: entity.IntervalDay, To: time.Now().UTC(), Location: time.UTC}
+
+	if tz = strings.TrimSpace(tz); tz != "" {
+		location, err := time.LoadLocation(tz)
+		// "Local" is the zone of this process, which the database does not know.
+		if err != nil || tz == "Local" {
+			return query, appErrors.Invalid(appErrors.ErrCodeInvalidTimezone, map[string]interface{}{"Timezone": tz})
+		}
+		query.Location = location
+	}
+
+	if interval = strings.ToLower(strings.TrimSpace(interval)); interval != "" {
+		query.Interval = entity.Interval(interval)
+	}
+	switch query.Interval {
+	case entity.IntervalHour, entity.IntervalDay, entity.IntervalWeek:
+	default:
+		return query, appErrors.Invalid(appErrors.ErrCodeInvalidInterval, map[string]interface{}{
+			"Interval": interval,
+			"Allowed":  "hour, day, week",
+		})
+	}
+
+	var ok bool
+	if to != "" {
+		if query.To, ok = parseTime(to, query.Location); !ok {
+			return query, appErrors.Invalid(appErrors.ErrCodeInvalidTimeRange, nil)
+		}
+	}
+	query.From = query.To.Add(-defaultSeriesRange)
+	if from != "" {
+		if query.From, ok = parseTime(from, query.Location); !ok {
+			return query, appErrors.Invalid(appErrors.ErrCodeInvalidTimeRange, nil)
+		}
+	}
+	if !query.From.Before(query.To) {
+		return query, appErrors.Invalid(appErrors.ErrCodeInvalidTimeRange, nil)
+	}
+	if query.To.Sub(query.From)/bucketWidth(query.Interval) >= maxSeriesPoints {
+		return query, appErrors.Invalid(appErrors.ErrCodeSeriesTooLong, map[string]interface{}{"Max": maxSeriesPoints})
+	}
+	return query, nil
+}
+
+// parseTime parses an RFC 3339 timestamp, or a date at midnight in location.
+func parseTime(s string, location *time.Location) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), true
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, location); err == nil {
+		return t.UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// bucketWidth is the nominal width of an interval, ignoring DST changes.
+func bucketWidth(interval entity.Interval) time.Duration {
+	switch interval {
+	case entity.IntervalHour:
+		return time.Hour
+	case entity.IntervalWeek:
+		return 7 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// inLocation converts the bucket times of points to the time zone of the query.
+func inLocation(points []entity.SeriesPoint, query entity.SeriesQuery) {
+	for i := range points {
+		points[i].Time = points[i].Time.In(query.Location)
+	}
+}
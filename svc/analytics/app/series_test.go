@@ -0,0 +1,133 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/svc/analytics/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSeriesQuery(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	// The longest hour series ends one bucket short of maxSeriesPoints.
+	longestFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	longestTo := longestFrom.Add((maxSeriesPoints - 1) * time.Hour)
+
+	tests := []struct {
+		name                   string
+		from, to, interval, tz string
+		wantFrom, wantTo       time.Time
+		wantInterval           entity.Interval
+		wantTZ                 string
+	}{
+		{
+			name:         "dates in utc",
+			from:         "2024-03-01",
+			to:           "2024-03-08",
+			wantFrom:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantTo:       time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC),
+			wantInterval: entity.IntervalDay,
+			wantTZ:       "UTC",
+		},
+		{
+			name:         "dates are midnight in tz",
+			from:         "2024-03-30",
+			to:           "2024-04-01",
+			interval:     " HOUR ",
+			tz:           "Europe/Paris",
+			wantFrom:     time.Date(2024, 3, 30, 0, 0, 0, 0, paris),
+			wantTo:       time.Date(2024, 4, 1, 0, 0, 0, 0, paris),
+			wantInterval: entity.IntervalHour,
+			wantTZ:       "Europe/Paris",
+		},
+		{
+			name:         "timestamps keep their offset",
+			from:         "2024-03-01T10:00:00+02:00",
+			to:           "2024-03-01T12:30:00Z",
+			interval:     "hour",
+			tz:           "Europe/Paris",
+			wantFrom:     time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC),
+			wantTo:       time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+			wantInterval: entity.IntervalHour,
+			wantTZ:       "Europe/Paris",
+		},
+		{
+			name:         "from defaults to 30 days before to",
+			to:           "2024-03-31",
+			interval:     "week",
+			wantFrom:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantTo:       time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+			wantInterval: entity.IntervalWeek,
+			wantTZ:       "UTC",
+		},
+		{
+			name:         "just under the point limit",
+			from:         longestFrom.Format(time.RFC3339),
+			to:           longestTo.Format(time.RFC3339),
+			interval:     "hour",
+			wantFrom:     longestFrom,
+			wantTo:       longestTo,
+			wantInterval: entity.IntervalHour,
+			wantTZ:       "UTC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := ParseSeriesQuery(tt.from, tt.to, tt.interval, tt.tz)
+			require.NoError(t, err)
+			assert.True(t, tt.wantFrom.Equal(query.From), "from %s", query.From)
+			assert.True(t, tt.wantTo.Equal(query.To), "to %s", query.To)
+			assert.Equal(t, tt.wantInterval, query.Interval)
+			assert.Equal(t, tt.wantTZ, query.TimeZone())
+		})
+	}
+}
+
+func TestParseSeriesQueryDefaults(t *testing.T) {
+	before := time.Now()
+	query, err := ParseSeriesQuery("", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, entity.IntervalDay, query.Interval)
+	assert.Equal(t, "UTC", query.TimeZone())
+	assert.False(t, query.To.Before(before.Truncate(time.Second)))
+	assert.Equal(t, defaultSeriesRange, query.To.Sub(query.From))
+}
+
+func TestParseSeriesQueryErrors(t *testing.T) {
+	tooLong := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(maxSeriesPoints * time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		name                   string
+		from, to, interval, tz string
+		code                   appErrors.ErrorCode
+	}{
+		{name: "unknown interval", interval: "minute", code: appErrors.ErrCodeInvalidInterval},
+		{name: "unknown tz", tz: "Mars/Olympus", code: appErrors.ErrCodeInvalidTimezone},
+		{name: "process local tz", tz: "Local", code: appErrors.ErrCodeInvalidTimezone},
+		{name: "bad from", from: "yesterday", code: appErrors.ErrCodeInvalidTimeRange},
+		{name: "bad to", to: "2024-13-01", code: appErrors.ErrCodeInvalidTimeRange},
+		{name: "empty range", from: "2024-03-01", to: "2024-03-01", code: appErrors.ErrCodeInvalidTimeRange},
+		{name: "reversed range", from: "2024-03-02", to: "2024-03-01", code: appErrors.ErrCodeInvalidTimeRange},
+		{
+			name:     "too many points",
+			from:     "2024-01-01T00:00:00Z",
+			to:       tooLong,
+			interval: "hour",
+			code:     appErrors.ErrCodeSeriesTooLong,
+		},
+		{name: "too many days", from: "2000-01-01", to: "2024-01-01", code: appErrors.ErrCodeSeriesTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSeriesQuery(tt.from, tt.to, tt.interval, tt.tz)
+			var invalid *appErrors.InvalidError
+			require.ErrorAs(t, err, &invalid)
+			assert.Equal(t, tt.code, invalid.Code)
+		})
+	}
+}
@@ -3,49 +3,248 @@ package app
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
 	"time"
 
+	"url-shorterner/internal/cache"
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/internal/geoip"
+	"url-shorterner/internal/log"
+	"url-shorterner/internal/referrer"
+	"url-shorterner/internal/useragent"
 	"url-shorterner/internal/uuid"
 	"url-shorterner/svc/analytics/entity"
+	"url-shorterner/svc/analytics/events"
 	analyticsStore "url-shorterner/svc/analytics/store"
 )
 
 // Service defines the interface for analytics operations.
 type Service interface {
-	RecordClick(ctx context.Context, shortCode, ipAddress, userAgent, referer string) error
+	RecordClick(ctx context.Context, event events.ClickEvent) error
 	GetAnalytics(ctx context.Context, shortCode string, limit int) ([]*entity.Record, error)
-	GetStats(ctx context.Context, shortCode string) (*entity.Stats, error)
+	GetStats(ctx context.Context, shortCode string, includeBots bool) (*entity.Stats, error)
+	GetTagStats(ctx context.Context, tags []string, includeBots bool) ([]*entity.TagStats, error)
+	GetCampaignStats(ctx context.Context, campaignID string, query entity.SeriesQuery) (*entity.CampaignStats, error)
+	GetTimeSeries(ctx context.Context, shortCode string, query entity.SeriesQuery) (*entity.TimeSeries, error)
+	GetBreakdown(ctx context.Context, shortCode, by string, includeBots bool) ([]*entity.BreakdownItem, error)
+	GetReferrers(ctx context.Context, shortCode string, limit int, includeBots bool) (*entity.Referrers, error)
+	StreamClicks(ctx context.Context, shortCode string, includeBots bool) (<-chan entity.LiveEvent, error)
+	ExportClicks(ctx context.Context, query entity.ExportQuery, emit func(record *entity.Record) error) error
 }
 
+// maxUTMLength matches the analytics.utm_* columns.
+const maxUTMLength = 255
+
 type service struct {
-	repo analyticsStore.Repository
-	dao  analyticsStore.DAO
+	repo      analyticsStore.Repository
+	dao       analyticsStore.DAO
+	geo       *geoip.DB
+	referrers *referrer.Classifier
+	ips       *ipAnonymizer
+	visitors  *cache.VisitorCache
+	bots      BotOptions
+	live      LiveOptions
 }
 
 // NewService creates a new analytics service instance.
-func NewService(repo analyticsStore.Repository, dao analyticsStore.DAO) Service {
+// geo resolves the location of recorded clicks; nil leaves it unknown.
+// referrers classifies their traffic source; nil uses the built-in rules.
+// ipMode selects how their IP addresses are stored; empty means IPModeFull.
+// visitors estimates unique visitors; nil disables the estimates.
+// bots configures bot detection beyond user agent patterns.
+// live configures the live click feeds.
+func NewService(
+	repo analyticsStore.Repository,
+	dao analyticsStore.DAO,
+	geo *geoip.DB,
+	referrers *referrer.Classifier,
+	ipMode IPMode,
+	visitors *cache.VisitorCache,
+	bots BotOptions,
+	live LiveOptions,
+) Service {
 	return &service{
-		repo: repo,
-		dao:  dao,
+		repo:      repo,
+		dao:       dao,
+		geo:       geo,
+		referrers: referrers,
+		ips:       newIPAnonymizer(ipMode, repo),
+		visitors:  visitors,
+		bots:      bots,
+		live:      live,
 	}
 }
 
-func (s *service) RecordClick(ctx context.Context, shortCode, ipAddress, userAgent, referer string) error {
+func (s *service) RecordClick(ctx context.Context, event events.ClickEvent) error {
+	clickedAt := event.Timestamp
+	if clickedAt.IsZero() {
+		clickedAt = time.Now().UTC()
+	}
+	agent := useragent.Parse(event.UserAgent)
+	location := s.geo.Lookup(event.IPAddress)
+	referrerHost, source := s.referrers.Classify(event.Referer, event.UTM.Medium)
+	botReason := s.bots.botReason(ctx, event, clickedAt, agent, s.ips)
+	// Enrichment above needs the full address; only the anonymized form is stored.
+	ipAddress, err := s.ips.anonymize(ctx, event.IPAddress, clickedAt)
+	if err != nil {
+		return err
+	}
 	record := &entity.Record{
-		ID:        uuid.Generate(),
-		ShortCode: shortCode,
-		IPAddress: ipAddress,
-		UserAgent: userAgent,
-		Referer:   referer,
-		ClickedAt: time.Now().UTC(),
+		ID:             uuid.Generate(),
+		ShortCode:      event.ShortCode,
+		IPAddress:      ipAddress,
+		UserAgent:      event.UserAgent,
+		Browser:        agent.Browser,
+		BrowserVersion: agent.BrowserVersion,
+		OS:             agent.OS,
+		Device:         agent.Device,
+		IsBot:          botReason != "",
+		BotReason:      botReason,
+		Country:        location.Country,
+		Region:         location.Region,
+		City:           location.City,
+		ASN:            location.ASN,
+		ASOrg:          location.ASOrg,
+		Referer:        event.Referer,
+		ReferrerHost:   referrerHost,
+		Source:         source,
+		UTMSource:      truncate(event.UTM.Source, maxUTMLength),
+		UTMMedium:      truncate(event.UTM.Medium, maxUTMLength),
+		UTMCampaign:    truncate(event.UTM.Campaign, maxUTMLength),
+		UTMTerm:        truncate(event.UTM.Term, maxUTMLength),
+		UTMContent:     truncate(event.UTM.Content, maxUTMLength),
+		ClickedAt:      clickedAt,
+	}
+	if event.Version > 0 {
+		record.URLVersion = &event.Version
 	}
-	return s.repo.CreateAnalytics(ctx, record)
+	if err := s.repo.CreateAnalytics(ctx, record); err != nil {
+		return err
+	}
+
+	if s.visitors != nil && !record.IsBot {
+		// The estimates are best effort; the click itself is recorded.
+		if err := s.visitors.AddVisitor(ctx, event.ShortCode, visitorID(event), clickedAt); err != nil {
+			log.Error("Failed to count visitor of %s: %v", event.ShortCode, err)
+		}
+	}
+	s.live.publish(ctx, record)
+	return nil
+}
+
+// visitorID identifies the visitor of a click by IP address and user agent. Only a digest
+// is sent to the sketches, which keep no elements anyway.
+func visitorID(event events.ClickEvent) string {
+	sum := sha256.Sum256([]byte(event.IPAddress + "\n" + event.UserAgent))
+	return hex.EncodeToString(sum[:16])
 }
 
 func (s *service) GetAnalytics(ctx context.Context, shortCode string, limit int) ([]*entity.Record, error) {
 	return s.dao.GetAnalyticsByShortCode(ctx, shortCode, limit)
 }
 
-func (s *service) GetStats(ctx context.Context, shortCode string) (*entity.Stats, error) {
-	return s.dao.GetAnalyticsStats(ctx, shortCode)
+func (s *service) GetStats(ctx context.Context, shortCode string, includeBots bool) (*entity.Stats, error) {
+	stats, err := s.dao.GetAnalyticsStats(ctx, shortCode, includeBots)
+	if err != nil {
+		return nil, err
+	}
+	if s.visitors != nil {
+		if visitors, err := s.visitors.CountVisitors(ctx, shortCode); err != nil {
+			log.Error("Failed to estimate visitors of %s: %v", shortCode, err)
+		} else {
+			stats.UniqueVisitors = &visitors
+		}
+	}
+	return stats, nil
+}
+
+// GetTagStats returns click statistics per tag; tags are matched case-insensitively.
+func (s *service) GetTagStats(ctx context.Context, tags []string, includeBots bool) ([]*entity.TagStats, error) {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			names = append(names, tag)
+		}
+	}
+	return s.dao.GetTagStats(ctx, names, includeBots)
+}
+
+func (s *service) GetCampaignStats(ctx context.Context, campaignID string, query entity.SeriesQuery) (*entity.CampaignStats, error) {
+	stats, err := s.dao.GetCampaignStats(ctx, campaignID, query)
+	if err != nil {
+		return nil, err
+	}
+	inLocation(stats.Series, query)
+	return stats, nil
+}
+
+// GetTimeSeries returns the clicks of a short code per bucket with zero-filled gaps.
+func (s *service) GetTimeSeries(ctx context.Context, shortCode string, query entity.SeriesQuery) (*entity.TimeSeries, error) {
+	points, err := s.dao.GetClickSeries(ctx, shortCode, query)
+	if err != nil {
+		return nil, err
+	}
+	inLocation(points, query)
+	series := &entity.TimeSeries{
+		ShortCode: shortCode,
+		Interval:  query.Interval,
+		TimeZone:  query.TimeZone(),
+		From:      query.From.In(query.Location),
+		To:        query.To.In(query.Location),
+		Points:    points,
+	}
+	// The sketches are kept per UTC day, so days in another time zone cannot be counted.
+	if s.visitors != nil && query.TimeZone() == "UTC" {
+		if visitors, err := s.visitors.CountVisitorsBetween(ctx, shortCode, query.From, query.To); err != nil {
+			log.Error("Failed to estimate visitors of %s: %v", shortCode, err)
+		} else {
+			series.UniqueVisitors = &visitors
+		}
+	}
+	return series, nil
+}
+
+// GetBreakdown returns the clicks of a short code grouped by a user agent, location or
+// referrer dimension.
+func (s *service) GetBreakdown(ctx context.Context, shortCode, by string, includeBots bool) ([]*entity.BreakdownItem, error) {
+	dimension := entity.Dimension(strings.ToLower(strings.TrimSpace(by)))
+	switch dimension {
+	case entity.DimensionBrowser, entity.DimensionOS, entity.DimensionDevice,
+		entity.DimensionCountry, entity.DimensionRegion, entity.DimensionCity, entity.DimensionASN,
+		entity.DimensionSource, entity.DimensionReferrer,
+		entity.DimensionUTMSource, entity.DimensionUTMMedium, entity.DimensionUTMCampaign:
+	default:
+		return nil, appErrors.Invalid(appErrors.ErrCodeInvalidGroupBy, map[string]interface{}{
+			"By":      by,
+			"Allowed": "browser, os, device, country, region, city, asn, source, referrer, utm_source, utm_medium, utm_campaign",
+		})
+	}
+	return s.dao.GetBreakdown(ctx, shortCode, dimension, 0, includeBots)
+}
+
+// GetReferrers returns all traffic sources of a short code and its top limit referrer hosts.
+func (s *service) GetReferrers(ctx context.Context, shortCode string, limit int, includeBots bool) (*entity.Referrers, error) {
+	sources, err := s.dao.GetBreakdown(ctx, shortCode, entity.DimensionSource, 0, includeBots)
+	if err != nil {
+		return nil, err
+	}
+	referrers, err := s.dao.GetBreakdown(ctx, shortCode, entity.DimensionReferrer, limit, includeBots)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.Referrers{
+		ShortCode: shortCode,
+		Sources:   sources,
+		Referrers: referrers,
+	}, nil
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
 }
@@ -13,15 +13,72 @@ type Record struct {
 	// The short code that was clicked
 	ShortCode string
 
+	// Version of the link that served the click (null if unknown)
+	URLVersion *int
+
 	// IP address of the user who clicked
 	IPAddress string
 
 	// User agent string from the HTTP request
 	UserAgent string
 
+	// Browser family parsed from the user agent, or the bot name
+	Browser string
+
+	// Browser version parsed from the user agent
+	BrowserVersion string
+
+	// Operating system family parsed from the user agent
+	OS string
+
+	// Device class: desktop, mobile, tablet, bot or other
+	Device string
+
+	// Whether the click came from a bot
+	IsBot bool
+
+	// Why the click was flagged as a bot: user_agent, ip_range or burst (empty for humans)
+	BotReason string
+
+	// ISO country code resolved from the IP address
+	Country string
+
+	// Region (first subdivision) resolved from the IP address
+	Region string
+
+	// City resolved from the IP address
+	City string
+
+	// Autonomous system number of the IP address (0 if unknown)
+	ASN uint32
+
+	// Organization of the autonomous system
+	ASOrg string
+
 	// Referer header from the HTTP request
 	Referer string
 
+	// Host of the referer, without "www." (empty for direct traffic)
+	ReferrerHost string
+
+	// Traffic source: direct, search, social, email, internal or referral
+	Source string
+
+	// utm_source parameter of the redirect request
+	UTMSource string
+
+	// utm_medium parameter of the redirect request
+	UTMMedium string
+
+	// utm_campaign parameter of the redirect request
+	UTMCampaign string
+
+	// utm_term parameter of the redirect request
+	UTMTerm string
+
+	// utm_content parameter of the redirect request
+	UTMContent string
+
 	// Timestamp when the click occurred
 	ClickedAt time.Time
 }
@@ -36,7 +93,242 @@ type Stats struct {
 	// Number of unique IP addresses
 	UniqueIPs int
 
+	// Estimated number of unique human visitors (IP address and user agent), bots never being
+	// counted, null if unavailable
+	UniqueVisitors *int64
+
 	// Timestamp of the last click (null if no clicks)
 	LastClick *time.Time
 }
 
+// TagStats represents aggregated click statistics of the links carrying a tag
+//
+// swagger:model TagStats
+type TagStats struct {
+	// Tag name
+	Tag string `json:"tag"`
+
+	// Number of links carrying the tag
+	Links int `json:"links"`
+
+	// Total number of clicks on those links
+	TotalClicks int `json:"total_clicks"`
+
+	// Number of unique IP addresses across those links
+	UniqueIPs int `json:"unique_ips"`
+
+	// Timestamp of the last click (null if no clicks)
+	LastClick *time.Time `json:"last_click"`
+}
+
+// Dimension is a click attribute that analytics can be broken down by.
+type Dimension string
+
+const (
+	// DimensionBrowser groups clicks by browser family.
+	DimensionBrowser Dimension = "browser"
+	// DimensionOS groups clicks by operating system family.
+	DimensionOS Dimension = "os"
+	// DimensionDevice groups clicks by device class.
+	DimensionDevice Dimension = "device"
+	// DimensionCountry groups clicks by ISO country code.
+	DimensionCountry Dimension = "country"
+	// DimensionRegion groups clicks by country and region.
+	DimensionRegion Dimension = "region"
+	// DimensionCity groups clicks by country and city.
+	DimensionCity Dimension = "city"
+	// DimensionASN groups clicks by autonomous system.
+	DimensionASN Dimension = "asn"
+	// DimensionSource groups clicks by traffic source.
+	DimensionSource Dimension = "source"
+	// DimensionReferrer groups clicks by referrer host; clicks without one are omitted.
+	DimensionReferrer Dimension = "referrer"
+	// DimensionUTMSource groups clicks by utm_source; clicks without one are omitted.
+	DimensionUTMSource Dimension = "utm_source"
+	// DimensionUTMMedium groups clicks by utm_medium; clicks without one are omitted.
+	DimensionUTMMedium Dimension = "utm_medium"
+	// DimensionUTMCampaign groups clicks by utm_campaign; clicks without one are omitted.
+	DimensionUTMCampaign Dimension = "utm_campaign"
+)
+
+// BreakdownItem is the number of clicks with one value of a dimension
+//
+// swagger:model BreakdownItem
+type BreakdownItem struct {
+	// Value of the dimension ("unknown" for clicks recorded before it was captured or unresolved)
+	Value string `json:"value"`
+
+	// Country of a region or city, organization of an autonomous system, or source of a
+	// referrer host (omitted otherwise)
+	Context string `json:"context,omitempty"`
+
+	// Number of clicks
+	Clicks int `json:"clicks"`
+
+	// Number of unique IP addresses
+	UniqueIPs int `json:"unique_ips"`
+}
+
+// Referrers are the top traffic sources and referrer hosts of a link
+//
+// swagger:model Referrers
+type Referrers struct {
+	// The short code of the link
+	ShortCode string `json:"short_code"`
+
+	// Clicks per traffic source, most clicked first
+	Sources []*BreakdownItem `json:"sources"`
+
+	// Clicks per referrer host with its source as context, most clicked first
+	Referrers []*BreakdownItem `json:"referrers"`
+}
+
+// Interval is the bucket width of a click time series.
+type Interval string
+
+const (
+	// IntervalHour buckets clicks per hour.
+	IntervalHour Interval = "hour"
+	// IntervalDay buckets clicks per day.
+	IntervalDay Interval = "day"
+	// IntervalWeek buckets clicks per ISO week, starting on Monday.
+	IntervalWeek Interval = "week"
+)
+
+// SeriesQuery selects the clicks in [From, To) bucketed by Interval.
+type SeriesQuery struct {
+	From     time.Time
+	To       time.Time
+	Interval Interval
+	// Location is the time zone in which buckets start; nil means UTC.
+	Location *time.Location
+	// IncludeBots counts clicks flagged as bots.
+	IncludeBots bool
+}
+
+// TimeZone returns the IANA name of the time zone of the query.
+func (q SeriesQuery) TimeZone() string {
+	if q.Location == nil {
+		return "UTC"
+	}
+	return q.Location.String()
+}
+
+// ExportQuery selects the raw clicks of an export.
+type ExportQuery struct {
+	// ShortCode limits the export to one link; empty exports all links.
+	ShortCode string
+	// From is the first click time included; zero leaves the range open.
+	From time.Time
+	// To is the click time the range ends before; zero leaves the range open.
+	To time.Time
+}
+
+// SeriesPoint is the number of clicks in one time bucket
+//
+// swagger:model SeriesPoint
+type SeriesPoint struct {
+	// Start of the bucket
+	Time time.Time `json:"time"`
+
+	// Number of clicks in the bucket
+	Clicks int `json:"clicks"`
+
+	// Number of unique IP addresses in the bucket
+	UniqueIPs int `json:"unique_ips"`
+}
+
+// CampaignStats represents click statistics rolled up across the links of a campaign
+//
+// swagger:model CampaignStats
+type CampaignStats struct {
+	// Number of links in the campaign
+	Links int `json:"links"`
+
+	// Total number of clicks in the selected range
+	TotalClicks int `json:"total_clicks"`
+
+	// Number of unique IP addresses in the selected range across all links of the campaign
+	UniqueIPs int `json:"unique_ips"`
+
+	// Timestamp of the last click in the selected range (null if no clicks)
+	LastClick *time.Time `json:"last_click"`
+
+	// Clicks per bucket, oldest first; buckets without clicks are omitted
+	Series []SeriesPoint `json:"series"`
+}
+
+// TimeSeries represents the clicks of a link per time bucket
+//
+// swagger:model TimeSeries
+type TimeSeries struct {
+	// The short code of the link
+	ShortCode string `json:"short_code"`
+
+	// Bucket width: hour, day or week
+	Interval Interval `json:"interval"`
+
+	// Time zone in which buckets start
+	TimeZone string `json:"tz"`
+
+	// Start of the range (inclusive)
+	From time.Time `json:"from"`
+
+	// End of the range (exclusive)
+	To time.Time `json:"to"`
+
+	// Estimated number of unique human visitors on the UTC days overlapping the range, bots never
+	// being counted, null if unavailable or if the time zone is not UTC, since the estimates are
+	// kept per UTC day
+	UniqueVisitors *int64 `json:"unique_visitors"`
+
+	// Clicks per bucket, oldest first; buckets without clicks have zero counts
+	Points []SeriesPoint `json:"points"`
+}
+
+// LiveClick is a click as streamed by the live feed of a link
+//
+// swagger:model LiveClick
+type LiveClick struct {
+	// Timestamp when the click occurred
+	Timestamp time.Time `json:"timestamp"`
+
+	// ISO 3166-1 alpha-2 country code (empty if unknown)
+	Country string `json:"country"`
+
+	// Host of the referer, without "www." (empty for direct traffic)
+	ReferrerHost string `json:"referrer_host"`
+
+	// Traffic source: direct, search, social, email, internal or referral
+	Source string `json:"source"`
+
+	// Device class: desktop, mobile, tablet, bot or other
+	Device string `json:"device"`
+
+	// Whether the click is flagged as a bot
+	IsBot bool `json:"is_bot"`
+}
+
+// LiveSummary counts the clicks streamed by a live feed
+//
+// swagger:model LiveSummary
+type LiveSummary struct {
+	// Timestamp of the summary
+	Timestamp time.Time `json:"timestamp"`
+
+	// Clicks of the link: its recorded clicks when the feed was opened plus the clicks streamed
+	// since. Clicks recorded while the feed opens may be counted twice.
+	TotalClicks int64 `json:"total_clicks"`
+
+	// Number of clicks streamed since the feed was opened
+	Clicks int64 `json:"clicks"`
+
+	// Number of clicks streamed since the previous summary
+	IntervalClicks int64 `json:"interval_clicks"`
+}
+
+// LiveEvent is an event of a live feed; exactly one of Click and Summary is set.
+type LiveEvent struct {
+	Click   *LiveClick
+	Summary *LiveSummary
+}
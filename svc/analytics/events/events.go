@@ -1,13 +1,40 @@
 // Package events defines event types for the analytics domain.
 package events
 
-import "time"
+import (
+	"net/url"
+	"time"
+)
 
 // ClickEvent represents a click event for analytics tracking.
 type ClickEvent struct {
 	ShortCode string
+	// Version is the link version that served the click; 0 if unknown
+	Version   int
 	IPAddress string
 	UserAgent string
 	Referer   string
+	// UTM holds the utm_* parameters of the redirect request
+	UTM       UTM
 	Timestamp time.Time
 }
+
+// UTM holds the campaign tracking parameters of a request; absent parameters are empty.
+type UTM struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
+}
+
+// UTMFromQuery returns the utm_* parameters of a query string.
+func UTMFromQuery(query url.Values) UTM {
+	return UTM{
+		Source:   query.Get("utm_source"),
+		Medium:   query.Get("utm_medium"),
+		Campaign: query.Get("utm_campaign"),
+		Term:     query.Get("utm_term"),
+		Content:  query.Get("utm_content"),
+	}
+}
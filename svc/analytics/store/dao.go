@@ -3,6 +3,9 @@ package store
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"url-shorterner/svc/analytics/entity"
@@ -11,10 +14,37 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// streamFetchSize is the number of rows fetched from a server-side cursor per round trip.
+const streamFetchSize = 500
+
+// streamIdleTimeout ends a cursor's transaction when its client takes longer than this to take
+// a batch of rows, so slow downloads do not hold a connection and a snapshot indefinitely.
+const streamIdleTimeout = time.Minute
+
+// streamStatementTimeout bounds each FETCH from a cursor.
+const streamStatementTimeout = time.Minute
+
 // DAO defines the data access interface for analytics read operations.
 type DAO interface {
 	GetAnalyticsByShortCode(ctx context.Context, shortCode string, limit int) ([]*entity.Record, error)
-	GetAnalyticsStats(ctx context.Context, shortCode string) (*entity.Stats, error)
+	// GetAnalyticsStats aggregates the clicks of a short code, without bots unless includeBots.
+	GetAnalyticsStats(ctx context.Context, shortCode string, includeBots bool) (*entity.Stats, error)
+	// GetTagStats aggregates clicks per tag, limited to the given tags unless tags is empty,
+	// reading rolled up clicks like GetAnalyticsStats.
+	GetTagStats(ctx context.Context, tags []string, includeBots bool) ([]*entity.TagStats, error)
+	// GetCampaignStats aggregates the clicks of all links of a campaign per bucket, reading
+	// complete buckets from the rollups like GetClickSeries.
+	GetCampaignStats(ctx context.Context, campaignID string, query entity.SeriesQuery) (*entity.CampaignStats, error)
+	// GetBreakdown returns the clicks of a short code grouped by dimension, most clicked first,
+	// at most limit values unless limit is 0. Complete days are read from the breakdown rollups.
+	GetBreakdown(ctx context.Context, shortCode string, dimension entity.Dimension, limit int, includeBots bool) ([]*entity.BreakdownItem, error)
+	// GetClickSeries returns the clicks of a short code per bucket, including empty buckets.
+	GetClickSeries(ctx context.Context, shortCode string, query entity.SeriesQuery) ([]entity.SeriesPoint, error)
+	// LinkExists reports whether a link has shortCode.
+	LinkExists(ctx context.Context, shortCode string) (bool, error)
+	// StreamRecords calls fn for every click matching query through a server-side cursor. The
+	// cursor fails once fn takes longer than a minute to consume a batch of rows.
+	StreamRecords(ctx context.Context, query entity.ExportQuery, fn func(record *entity.Record) error) error
 }
 
 type dao struct {
@@ -26,9 +56,60 @@ func NewDAO(db *pgxpool.Pool) DAO {
 	return &dao{db: db}
 }
 
+// recordColumns are the analytics columns read by scanRecord.
+const recordColumns = `
+	id, short_code, url_version, ip_address, user_agent,
+	COALESCE(browser, ''), COALESCE(browser_version, ''), COALESCE(os, ''), COALESCE(device, ''), is_bot, COALESCE(bot_reason, ''),
+	COALESCE(country, ''), COALESCE(region, ''), COALESCE(city, ''), COALESCE(asn, 0), COALESCE(as_org, ''),
+	referer, COALESCE(referrer_host, ''), COALESCE(source, ''),
+	COALESCE(utm_source, ''), COALESCE(utm_medium, ''), COALESCE(utm_campaign, ''),
+	COALESCE(utm_term, ''), COALESCE(utm_content, ''), clicked_at`
+
+func scanRecord(row pgx.Row) (*entity.Record, error) {
+	var record entity.Record
+	err := row.Scan(
+		&record.ID,
+		&record.ShortCode,
+		&record.URLVersion,
+		&record.IPAddress,
+		&record.UserAgent,
+		&record.Browser,
+		&record.BrowserVersion,
+		&record.OS,
+		&record.Device,
+		&record.IsBot,
+		&record.BotReason,
+		&record.Country,
+		&record.Region,
+		&record.City,
+		&record.ASN,
+		&record.ASOrg,
+		&record.Referer,
+		&record.ReferrerHost,
+		&record.Source,
+		&record.UTMSource,
+		&record.UTMMedium,
+		&record.UTMCampaign,
+		&record.UTMTerm,
+		&record.UTMContent,
+		&record.ClickedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (d *dao) LinkExists(ctx context.Context, shortCode string) (bool, error) {
+	var exists bool
+	err := d.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM urls WHERE short_code = @short_code)`,
+		pgx.NamedArgs{"short_code": shortCode}).Scan(&exists)
+	return exists, err
+}
+
 func (d *dao) GetAnalyticsByShortCode(ctx context.Context, shortCode string, limit int) ([]*entity.Record, error) {
 	query := `
-		SELECT id, short_code, ip_address, user_agent, referer, clicked_at
+		SELECT` + recordColumns + `
 		FROM analytics
 		WHERE short_code = @short_code
 		ORDER BY clicked_at DESC
@@ -47,35 +128,126 @@ func (d *dao) GetAnalyticsByShortCode(ctx context.Context, shortCode string, lim
 
 	records := make([]*entity.Record, 0, limit)
 	for rows.Next() {
-		var record entity.Record
-		err := rows.Scan(
-			&record.ID,
-			&record.ShortCode,
-			&record.IPAddress,
-			&record.UserAgent,
-			&record.Referer,
-			&record.ClickedAt,
-		)
+		record, err := scanRecord(rows)
 		if err != nil {
 			return nil, err
 		}
-		records = append(records, &record)
+		records = append(records, record)
 	}
 
 	return records, rows.Err()
 }
 
-func (d *dao) GetAnalyticsStats(ctx context.Context, shortCode string) (*entity.Stats, error) {
-	query := `
-		SELECT 
-			COUNT(*) as total_clicks,
-			COUNT(DISTINCT ip_address) as unique_ips,
-			MAX(clicked_at) as last_click
+// StreamRecords calls fn for every click matching query in click order, reading through a
+// server-side cursor so memory use does not grow with the table. Returning an error from fn
+// stops the stream.
+func (d *dao) StreamRecords(ctx context.Context, query entity.ExportQuery, fn func(record *entity.Record) error) error {
+	declare := `
+		DECLARE export_clicks NO SCROLL CURSOR FOR
+		SELECT` + recordColumns + `
 		FROM analytics
-		WHERE short_code = @short_code
+		WHERE (@short_code::text = '' OR short_code = @short_code)
+			AND (@from::timestamptz IS NULL OR clicked_at >= @from)
+			AND (@to::timestamptz IS NULL OR clicked_at < @to)
+		ORDER BY clicked_at, id
 	`
 	args := pgx.NamedArgs{
-		"short_code": shortCode,
+		"short_code": query.ShortCode,
+		"from":       nullTime(query.From),
+		"to":         nullTime(query.To),
+	}
+
+	// Cursors only live inside a transaction; read-only keeps it safe on replicas.
+	return pgx.BeginTxFunc(ctx, d.db, pgx.TxOptions{AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		for _, set := range []string{
+			fmt.Sprintf("SET LOCAL idle_in_transaction_session_timeout = %d", streamIdleTimeout.Milliseconds()),
+			fmt.Sprintf("SET LOCAL statement_timeout = %d", streamStatementTimeout.Milliseconds()),
+		} {
+			if _, err := tx.Exec(ctx, set); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(ctx, declare, args); err != nil {
+			return err
+		}
+		for {
+			rows, err := tx.Query(ctx, fmt.Sprintf("FETCH FORWARD %d FROM export_clicks", streamFetchSize))
+			if err != nil {
+				return err
+			}
+			n := 0
+			for rows.Next() {
+				n++
+				record, err := scanRecord(rows)
+				if err != nil {
+					rows.Close()
+					return err
+				}
+				if err := fn(record); err != nil {
+					rows.Close()
+					return err
+				}
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			if n < streamFetchSize {
+				return nil
+			}
+		}
+	})
+}
+
+// nullTime returns nil for the zero time, so it binds as NULL.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func (d *dao) GetAnalyticsStats(ctx context.Context, shortCode string, includeBots bool) (*entity.Stats, error) {
+	// Complete days come from the daily rollups, the rest of the current day from the hourly
+	// ones and clicks after the watermark from the raw table. Tail IPs already counted in
+	// analytics_unique_ips are not counted again.
+	query := fmt.Sprintf(`
+		WITH state AS (
+			SELECT rolled_up_to, date_trunc('day', rolled_up_to, 'UTC') AS rolled_up_day
+			FROM analytics_rollup_state
+		), days AS (
+			SELECT COALESCE(SUM(%[1]sclicks), 0)::bigint AS clicks, MAX(%[1]slast_click) AS last_click
+			FROM analytics_daily, state
+			WHERE short_code = @short_code AND bucket < rolled_up_day
+		), hours AS (
+			SELECT COALESCE(SUM(%[1]sclicks), 0)::bigint AS clicks, MAX(%[1]slast_click) AS last_click
+			FROM analytics_hourly, state
+			WHERE short_code = @short_code AND bucket >= rolled_up_day AND bucket < rolled_up_to
+		), tail AS (
+			SELECT
+				COUNT(*) AS clicks,
+				COUNT(DISTINCT a.ip_address) FILTER (WHERE NOT EXISTS (
+					SELECT 1 FROM analytics_unique_ips u
+					WHERE u.short_code = a.short_code AND u.ip_address = a.ip_address
+						AND (@include_bots OR u.human)
+				)) AS unique_ips,
+				MAX(a.clicked_at) AS last_click
+			FROM analytics a, state
+			WHERE a.short_code = @short_code AND a.clicked_at >= rolled_up_to
+				AND (@include_bots OR NOT a.is_bot)
+		)
+		SELECT
+			days.clicks + hours.clicks + tail.clicks as total_clicks,
+			(
+				SELECT COUNT(*) FROM analytics_unique_ips
+				WHERE short_code = @short_code AND (@include_bots OR human)
+			) + tail.unique_ips as unique_ips,
+			GREATEST(days.last_click, hours.last_click, tail.last_click) as last_click
+		FROM days, hours, tail
+	`, rollupPrefix(includeBots))
+	args := pgx.NamedArgs{
+		"short_code":   shortCode,
+		"include_bots": includeBots,
 	}
 
 	var stats entity.Stats
@@ -92,3 +264,404 @@ func (d *dao) GetAnalyticsStats(ctx context.Context, shortCode string) (*entity.
 	stats.LastClick = lastClick
 	return &stats, nil
 }
+
+func (d *dao) GetTagStats(ctx context.Context, tags []string, includeBots bool) ([]*entity.TagStats, error) {
+	// Clicks per link are read like GetAnalyticsStats; unique IPs are the distinct rolled up
+	// IPs of the tag's links plus their tail IPs.
+	query := fmt.Sprintf(`
+		WITH state AS (
+			SELECT rolled_up_to, date_trunc('day', rolled_up_to, 'UTC') AS rolled_up_day
+			FROM analytics_rollup_state
+		), links AS (
+			SELECT t.name AS tag, u.short_code
+			FROM tags t
+			JOIN url_tags ut ON ut.tag_id = t.id
+			JOIN urls u ON u.id = ut.url_id
+			WHERE cardinality(@tags::text[]) = 0 OR t.name = ANY(@tags::text[])
+		), link_clicks AS (
+			SELECT short_code, SUM(clicks) AS clicks, MAX(last_click) AS last_click
+			FROM (
+				SELECT r.short_code, r.%[1]sclicks AS clicks, r.%[1]slast_click AS last_click
+				FROM analytics_daily r, state
+				WHERE r.short_code IN (SELECT short_code FROM links) AND r.bucket < rolled_up_day
+				UNION ALL
+				SELECT r.short_code, r.%[1]sclicks, r.%[1]slast_click
+				FROM analytics_hourly r, state
+				WHERE r.short_code IN (SELECT short_code FROM links)
+					AND r.bucket >= rolled_up_day AND r.bucket < rolled_up_to
+				UNION ALL
+				SELECT a.short_code, 1, a.clicked_at
+				FROM analytics a, state
+				WHERE a.short_code IN (SELECT short_code FROM links) AND a.clicked_at >= rolled_up_to
+					AND (@include_bots OR NOT a.is_bot)
+			) c
+			GROUP BY short_code
+		), tag_ips AS (
+			SELECT tag, COUNT(DISTINCT ip_address) AS unique_ips
+			FROM (
+				SELECT l.tag, u.ip_address
+				FROM links l
+				JOIN analytics_unique_ips u ON u.short_code = l.short_code
+				WHERE @include_bots OR u.human
+				UNION ALL
+				SELECT l.tag, a.ip_address
+				FROM links l
+				JOIN analytics a ON a.short_code = l.short_code
+				CROSS JOIN state
+				WHERE a.clicked_at >= rolled_up_to AND a.ip_address IS NOT NULL
+					AND (@include_bots OR NOT a.is_bot)
+			) i
+			GROUP BY tag
+		)
+		SELECT
+			l.tag,
+			COUNT(*) as links,
+			COALESCE(SUM(c.clicks), 0)::bigint as total_clicks,
+			COALESCE(MAX(i.unique_ips), 0) as unique_ips,
+			MAX(c.last_click) as last_click
+		FROM links l
+		LEFT JOIN link_clicks c ON c.short_code = l.short_code
+		LEFT JOIN tag_ips i ON i.tag = l.tag
+		GROUP BY l.tag
+		ORDER BY total_clicks DESC, l.tag
+	`, rollupPrefix(includeBots))
+	if tags == nil {
+		tags = []string{}
+	}
+	args := pgx.NamedArgs{
+		"tags":         tags,
+		"include_bots": includeBots,
+	}
+
+	rows, err := d.db.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]*entity.TagStats, 0)
+	for rows.Next() {
+		var tag entity.TagStats
+		if err := rows.Scan(&tag.Tag, &tag.Links, &tag.TotalClicks, &tag.UniqueIPs, &tag.LastClick); err != nil {
+			return nil, err
+		}
+		stats = append(stats, &tag)
+	}
+
+	return stats, rows.Err()
+}
+
+func (d *dao) GetCampaignStats(ctx context.Context, campaignID string, series entity.SeriesQuery) (*entity.CampaignStats, error) {
+	stats := &entity.CampaignStats{Series: []entity.SeriesPoint{}}
+	args := pgx.NamedArgs{"campaign_id": campaignID}
+	if err := d.db.QueryRow(ctx, `SELECT COUNT(*) FROM urls WHERE campaign_id = @campaign_id`, args).Scan(&stats.Links); err != nil {
+		return nil, err
+	}
+
+	links := `short_code IN (SELECT short_code FROM urls WHERE campaign_id = @campaign_id)`
+	points, lastClick, err := d.clickSeries(ctx, links, args, series)
+	if err != nil {
+		return nil, err
+	}
+	for _, point := range points {
+		if point.Clicks == 0 {
+			continue
+		}
+		stats.TotalClicks += point.Clicks
+		stats.Series = append(stats.Series, point)
+	}
+	stats.LastClick = lastClick
+
+	// Bucket counts cannot be summed without counting an address once per link and bucket, and
+	// analytics_unique_ips has no click times to bound it by the range, so the distinct IPs
+	// are counted from the raw clicks. clickSeries has set the range and bot filter in args.
+	query := fmt.Sprintf(`
+		SELECT COUNT(DISTINCT ip_address)
+		FROM analytics
+		WHERE %s AND clicked_at >= @from AND clicked_at < @to
+			AND (@include_bots OR NOT is_bot)
+	`, links)
+	if err := d.db.QueryRow(ctx, query, args).Scan(&stats.UniqueIPs); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (d *dao) GetClickSeries(ctx context.Context, shortCode string, series entity.SeriesQuery) ([]entity.SeriesPoint, error) {
+	points, _, err := d.clickSeries(ctx, `short_code = @short_code`, pgx.NamedArgs{"short_code": shortCode}, series)
+	return points, err
+}
+
+// clickSeries returns the clicks per bucket, including empty buckets, of the links matching
+// the SQL condition links on short_code, with the time of the last click. Unique IPs are
+// counted per link and bucket, like the rollups they are read from. args binds the
+// parameters of links.
+func (d *dao) clickSeries(ctx context.Context, links string, args pgx.NamedArgs, series entity.SeriesQuery) ([]entity.SeriesPoint, *time.Time, error) {
+	// Whole buckets in [rolled_from, rolled_to) are read from a rollup table and the rest of
+	// the range from the raw clicks. Without a matching rollup the span is empty.
+	rolledFrom, rolledTo := series.From, series.From
+	table := "analytics_hourly"
+	if rollup, width, ok := rollupFor(series); ok {
+		var watermark time.Time
+		if err := d.db.QueryRow(ctx, `SELECT rolled_up_to FROM analytics_rollup_state`).Scan(&watermark); err != nil {
+			return nil, nil, err
+		}
+		// Daily rollups only cover days that ended before the watermark.
+		watermark = watermark.UTC().Truncate(width)
+		table = rollup
+		rolledFrom = series.From.UTC().Truncate(width)
+		if rolledFrom.Before(series.From) {
+			rolledFrom = rolledFrom.Add(width)
+		}
+		rolledTo = series.To.UTC().Truncate(width)
+		if watermark.Before(rolledTo) {
+			rolledTo = watermark
+		}
+		if rolledTo.Before(rolledFrom) {
+			rolledTo = rolledFrom
+		}
+	}
+
+	// Buckets are generated in local time so days and weeks follow DST changes in @tz,
+	// then converted back to instants for the response.
+	query := fmt.Sprintf(`
+		WITH buckets AS (
+			SELECT generate_series(
+				date_trunc(@interval::text, @from::timestamptz AT TIME ZONE @tz::text),
+				(@to::timestamptz - interval '1 microsecond') AT TIME ZONE @tz::text,
+				('1 ' || @interval::text)::interval
+			) AS bucket
+		), raw AS (
+			SELECT short_code, clicked_at, ip_address
+			FROM analytics
+			WHERE %[3]s AND clicked_at >= @from AND clicked_at < @rolled_from
+				AND (@include_bots OR NOT is_bot)
+			UNION ALL
+			SELECT short_code, clicked_at, ip_address
+			FROM analytics
+			WHERE %[3]s AND clicked_at >= @rolled_to AND clicked_at < @to
+				AND (@include_bots OR NOT is_bot)
+		), clicks AS (
+			SELECT
+				bucket AT TIME ZONE @tz::text AS bucket,
+				%[2]sclicks AS clicks,
+				%[2]sunique_ips AS unique_ips,
+				%[2]slast_click AS last_click
+			FROM %[1]s
+			WHERE %[3]s AND bucket >= @rolled_from AND bucket < @rolled_to
+			UNION ALL
+			SELECT
+				date_trunc(@interval::text, clicked_at AT TIME ZONE @tz::text),
+				COUNT(*),
+				COUNT(DISTINCT ip_address),
+				MAX(clicked_at)
+			FROM raw
+			GROUP BY short_code, 1
+		)
+		SELECT
+			b.bucket AT TIME ZONE @tz::text,
+			COALESCE(SUM(c.clicks), 0)::bigint,
+			COALESCE(SUM(c.unique_ips), 0)::bigint,
+			MAX(c.last_click)
+		FROM buckets b
+		LEFT JOIN clicks c ON c.bucket = b.bucket
+		GROUP BY b.bucket
+		ORDER BY b.bucket
+	`, table, rollupPrefix(series.IncludeBots), links)
+	args["interval"] = string(series.Interval)
+	args["tz"] = series.TimeZone()
+	args["from"] = series.From
+	args["to"] = series.To
+	args["rolled_from"] = rolledFrom
+	args["rolled_to"] = rolledTo
+	args["include_bots"] = series.IncludeBots
+
+	rows, err := d.db.Query(ctx, query, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	points := make([]entity.SeriesPoint, 0)
+	var last *time.Time
+	for rows.Next() {
+		var point entity.SeriesPoint
+		var lastClick *time.Time
+		if err := rows.Scan(&point.Time, &point.Clicks, &point.UniqueIPs, &lastClick); err != nil {
+			return nil, nil, err
+		}
+		if lastClick != nil && (last == nil || lastClick.After(*last)) {
+			last = lastClick
+		}
+		points = append(points, point)
+	}
+	return points, last, rows.Err()
+}
+
+// breakdownColumn is the SQL selecting the value and context of a dimension for one click.
+type breakdownColumn struct {
+	value   string
+	context string
+	// grouped is set when the context is part of the grouping rather than an aggregate.
+	grouped bool
+	// optional is set for dimensions most clicks lack; those clicks are omitted rather than unknown.
+	optional bool
+}
+
+var breakdownColumns = map[entity.Dimension]breakdownColumn{
+	entity.DimensionBrowser:     {value: "a.browser", context: "''"},
+	entity.DimensionOS:          {value: "a.os", context: "''"},
+	entity.DimensionDevice:      {value: "a.device", context: "''"},
+	entity.DimensionCountry:     {value: "a.country", context: "''"},
+	entity.DimensionRegion:      {value: "a.region", context: "COALESCE(a.country, '')", grouped: true},
+	entity.DimensionCity:        {value: "a.city", context: "COALESCE(a.country, '')", grouped: true},
+	entity.DimensionASN:         {value: "a.asn::text", context: "COALESCE(a.as_org, '')"},
+	entity.DimensionSource:      {value: "a.source", context: "''"},
+	entity.DimensionReferrer:    {value: "a.referrer_host", context: "COALESCE(a.source, '')", optional: true},
+	entity.DimensionUTMSource:   {value: "a.utm_source", context: "''", optional: true},
+	entity.DimensionUTMMedium:   {value: "a.utm_medium", context: "''", optional: true},
+	entity.DimensionUTMCampaign: {value: "a.utm_campaign", context: "''", optional: true},
+}
+
+// row returns the SQL of the value, context and key of the dimension for a click aliased a.
+// The value is NULL for optional dimensions the click lacks; the key is the part of the
+// context that values are grouped by.
+func (c breakdownColumn) row() string {
+	value := c.value
+	if !c.optional {
+		value = fmt.Sprintf("COALESCE(%s, 'unknown')", c.value)
+	}
+	key := "''"
+	if c.grouped {
+		key = c.context
+	}
+	return fmt.Sprintf("%s, %s, %s", value, c.context, key)
+}
+
+// breakdownRows returns a VALUES list with a (dimension, value, context, key) row per
+// dimension for a click aliased a, to be joined laterally.
+func breakdownRows() string {
+	dimensions := make([]string, 0, len(breakdownColumns))
+	for dimension := range breakdownColumns {
+		dimensions = append(dimensions, string(dimension))
+	}
+	sort.Strings(dimensions)
+	rows := make([]string, len(dimensions))
+	for i, dimension := range dimensions {
+		rows[i] = fmt.Sprintf("('%s', %s)", dimension, breakdownColumns[entity.Dimension(dimension)].row())
+	}
+	return "VALUES " + strings.Join(rows, ", ")
+}
+
+func (d *dao) GetBreakdown(ctx context.Context, shortCode string, dimension entity.Dimension, limit int, includeBots bool) ([]*entity.BreakdownItem, error) {
+	column, ok := breakdownColumns[dimension]
+	if !ok {
+		return nil, fmt.Errorf("unsupported breakdown dimension %q", dimension)
+	}
+	key := "''"
+	if column.grouped {
+		key = "context"
+	}
+	// Complete days come from the daily breakdown rollups and the rest from the raw clicks.
+	// Unique IPs come from analytics_breakdown_ips plus the tail IPs it lacks, like
+	// GetAnalyticsStats.
+	query := fmt.Sprintf(`
+		WITH state AS (
+			SELECT rolled_up_to, date_trunc('day', rolled_up_to, 'UTC') AS rolled_up_day
+			FROM analytics_rollup_state
+		), clicks AS (
+			SELECT r.value, %[3]s AS key, r.context, r.%[1]sclicks AS clicks
+			FROM analytics_daily_breakdown r, state
+			WHERE r.short_code = @short_code AND r.dimension = @dimension AND r.bucket < rolled_up_day
+			UNION ALL
+			SELECT d.value, d.key, d.context, 1
+			FROM analytics a
+			CROSS JOIN state
+			CROSS JOIN LATERAL (VALUES (%[2]s)) AS d(value, context, key)
+			WHERE a.short_code = @short_code AND a.clicked_at >= rolled_up_day
+				AND (@include_bots OR NOT a.is_bot) AND d.value IS NOT NULL
+		), ips AS (
+			SELECT value, context AS key, COUNT(*) AS unique_ips
+			FROM analytics_breakdown_ips
+			WHERE short_code = @short_code AND dimension = @dimension AND (@include_bots OR human)
+			GROUP BY 1, 2
+			UNION ALL
+			SELECT d.value, d.key, COUNT(DISTINCT a.ip_address)
+			FROM analytics a
+			CROSS JOIN state
+			CROSS JOIN LATERAL (VALUES (%[2]s)) AS d(value, context, key)
+			WHERE a.short_code = @short_code AND a.clicked_at >= rolled_up_to AND a.ip_address IS NOT NULL
+				AND (@include_bots OR NOT a.is_bot) AND d.value IS NOT NULL
+				AND NOT EXISTS (
+					SELECT 1 FROM analytics_breakdown_ips b
+					WHERE b.short_code = a.short_code AND b.dimension = @dimension AND b.value = d.value
+						AND b.context = d.key AND b.ip_address = a.ip_address AND (@include_bots OR b.human)
+				)
+			GROUP BY 1, 2
+		), grouped AS (
+			SELECT value, key, MAX(context) AS context, SUM(clicks)::bigint AS clicks
+			FROM clicks
+			GROUP BY 1, 2
+		)
+		SELECT g.value, g.context, g.clicks,
+			COALESCE((SELECT SUM(i.unique_ips) FROM ips i WHERE i.value = g.value AND i.key = g.key), 0)::bigint
+		FROM grouped g
+		WHERE g.clicks > 0
+		ORDER BY g.clicks DESC, g.value
+		LIMIT NULLIF(@limit, 0)
+	`, rollupPrefix(includeBots), column.row(), key)
+	args := pgx.NamedArgs{
+		"short_code":   shortCode,
+		"dimension":    string(dimension),
+		"limit":        limit,
+		"include_bots": includeBots,
+	}
+	rows, err := d.db.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*entity.BreakdownItem, 0)
+	for rows.Next() {
+		var item entity.BreakdownItem
+		if err := rows.Scan(&item.Value, &item.Context, &item.Clicks, &item.UniqueIPs); err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+// rollupPrefix returns the prefix of the rollup columns counting all clicks with includeBots,
+// or human clicks only.
+func rollupPrefix(includeBots bool) string {
+	if includeBots {
+		return ""
+	}
+	return "human_"
+}
+
+// rollupFor returns the rollup table whose buckets are exactly the buckets of series, with
+// their width. Unique IPs cannot be summed across buckets, so hourly series need a time zone
+// on whole-hour offsets and daily series UTC; other series are read from the raw clicks.
+func rollupFor(series entity.SeriesQuery) (string, time.Duration, bool) {
+	switch series.Interval {
+	case entity.IntervalHour:
+		location := series.Location
+		if location == nil {
+			location = time.UTC
+		}
+		for _, t := range []time.Time{series.From, series.To} {
+			if _, offset := t.In(location).Zone(); offset%3600 != 0 {
+				return "", 0, false
+			}
+		}
+		return "analytics_hourly", time.Hour, true
+	case entity.IntervalDay:
+		if series.TimeZone() == "UTC" {
+			return "analytics_daily", 24 * time.Hour, true
+		}
+	}
+	return "", 0, false
+}
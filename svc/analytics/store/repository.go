@@ -3,6 +3,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"url-shorterner/svc/analytics/entity"
 
@@ -13,6 +14,22 @@ import (
 // Repository defines the interface for analytics write operations.
 type Repository interface {
 	CreateAnalytics(ctx context.Context, record *entity.Record) error
+	// RollUp folds the raw clicks after the rollup watermark into the rollup tables, at most
+	// maxStep at a time and never past target, and returns the new watermark. target must be
+	// an hour boundary. It also recomputes the rolled up buckets of clicks recorded before
+	// settled since the previous call, which arrived after their hour was rolled up.
+	RollUp(ctx context.Context, target, settled time.Time, maxStep time.Duration) (time.Time, error)
+	// DailySalt returns the IP salt of the UTC day of day, storing candidate if it has none yet,
+	// and deletes the salts of days before the previous one.
+	DailySalt(ctx context.Context, day time.Time, candidate []byte) ([]byte, error)
+	// ListStoredIPs returns the ID, IP address and click time of at most limit clicks with an
+	// IP address, in ID order after the click with ID after, or from the first if after is empty.
+	ListStoredIPs(ctx context.Context, after string, limit int) ([]*entity.Record, error)
+	// UpdateIPs replaces the IP addresses of clicks, by click ID.
+	UpdateIPs(ctx context.Context, ips map[string]string) error
+	// ResetRollups empties the rollup tables and moves the watermark back to the first click,
+	// so the rollup job rebuilds them from the raw clicks.
+	ResetRollups(ctx context.Context) error
 }
 
 type repository struct {
@@ -26,17 +43,281 @@ func NewRepository(db *pgxpool.Pool) Repository {
 
 func (r *repository) CreateAnalytics(ctx context.Context, record *entity.Record) error {
 	query := `
-		INSERT INTO analytics (id, short_code, ip_address, user_agent, referer, clicked_at)
-		VALUES (@id, @short_code, @ip_address, @user_agent, @referer, @clicked_at)
+		INSERT INTO analytics (
+			id, short_code, url_version, ip_address, user_agent, browser, browser_version, os, device, is_bot, bot_reason,
+			country, region, city, asn, as_org, referer, referrer_host, source,
+			utm_source, utm_medium, utm_campaign, utm_term, utm_content, clicked_at
+		)
+		VALUES (
+			@id, @short_code, @url_version, @ip_address, @user_agent, @browser, @browser_version, @os, @device, @is_bot,
+			NULLIF(@bot_reason, ''),
+			NULLIF(@country, ''), NULLIF(@region, ''), NULLIF(@city, ''), NULLIF(@asn, 0), NULLIF(@as_org, ''),
+			@referer, NULLIF(@referrer_host, ''), NULLIF(@source, ''),
+			NULLIF(@utm_source, ''), NULLIF(@utm_medium, ''), NULLIF(@utm_campaign, ''),
+			NULLIF(@utm_term, ''), NULLIF(@utm_content, ''), @clicked_at
+		)
 	`
 	args := pgx.NamedArgs{
-		"id":         record.ID,
-		"short_code": record.ShortCode,
-		"ip_address": record.IPAddress,
-		"user_agent": record.UserAgent,
-		"referer":    record.Referer,
-		"clicked_at": record.ClickedAt,
+		"id":              record.ID,
+		"short_code":      record.ShortCode,
+		"url_version":     record.URLVersion,
+		"ip_address":      record.IPAddress,
+		"user_agent":      record.UserAgent,
+		"browser":         record.Browser,
+		"browser_version": record.BrowserVersion,
+		"os":              record.OS,
+		"device":          record.Device,
+		"is_bot":          record.IsBot,
+		"bot_reason":      record.BotReason,
+		"country":         record.Country,
+		"region":          record.Region,
+		"city":            record.City,
+		"asn":             int64(record.ASN),
+		"as_org":          record.ASOrg,
+		"referer":         record.Referer,
+		"referrer_host":   record.ReferrerHost,
+		"source":          record.Source,
+		"utm_source":      record.UTMSource,
+		"utm_medium":      record.UTMMedium,
+		"utm_campaign":    record.UTMCampaign,
+		"utm_term":        record.UTMTerm,
+		"utm_content":     record.UTMContent,
+		"clicked_at":      record.ClickedAt,
 	}
 	_, err := r.db.Exec(ctx, query, args)
 	return err
 }
+
+// rollupAggregates computes the counters of a rollup bucket, for all and for human clicks.
+const rollupAggregates = `
+	COUNT(*), COUNT(DISTINCT ip_address), MAX(clicked_at),
+	COUNT(*) FILTER (WHERE NOT is_bot),
+	COUNT(DISTINCT ip_address) FILTER (WHERE NOT is_bot),
+	MAX(clicked_at) FILTER (WHERE NOT is_bot)`
+
+// rollupUpdates replaces the counters of an existing rollup bucket.
+const rollupUpdates = `
+	clicks = EXCLUDED.clicks,
+	unique_ips = EXCLUDED.unique_ips,
+	last_click = EXCLUDED.last_click,
+	human_clicks = EXCLUDED.human_clicks,
+	human_unique_ips = EXCLUDED.human_unique_ips,
+	human_last_click = EXCLUDED.human_last_click`
+
+// rollupQueries recompute the rollups from the raw clicks matching a condition. hourly and
+// daily are conditions on the buckets to recompute, given as a short_code and bucket derived
+// table; clicks is a condition on the clicks whose IPs are added to the unique IP sets.
+func rollupQueries(hourly, daily, clicks string) []string {
+	return []string{`
+		WITH buckets AS (` + hourly + `)
+		INSERT INTO analytics_hourly (
+			short_code, bucket, clicks, unique_ips, last_click, human_clicks, human_unique_ips, human_last_click
+		)
+		SELECT a.short_code, b.bucket, ` + rollupAggregates + `
+		FROM analytics a
+		JOIN buckets b ON a.short_code = b.short_code
+			AND a.clicked_at >= b.bucket AND a.clicked_at < b.bucket + INTERVAL '1 hour'
+		GROUP BY 1, 2
+		ON CONFLICT (short_code, bucket) DO UPDATE SET ` + rollupUpdates + `
+	`, `
+		INSERT INTO analytics_unique_ips (short_code, ip_address, human)
+		SELECT short_code, ip_address, bool_or(NOT is_bot)
+		FROM analytics
+		WHERE ` + clicks + ` AND ip_address IS NOT NULL
+		GROUP BY 1, 2
+		ON CONFLICT (short_code, ip_address) DO UPDATE SET
+			human = analytics_unique_ips.human OR EXCLUDED.human
+	`, `
+		WITH buckets AS (` + daily + `)
+		INSERT INTO analytics_daily (
+			short_code, bucket, clicks, unique_ips, last_click, human_clicks, human_unique_ips, human_last_click
+		)
+		SELECT a.short_code, b.bucket, ` + rollupAggregates + `
+		FROM analytics a
+		JOIN buckets b ON a.short_code = b.short_code
+			AND a.clicked_at >= b.bucket AND a.clicked_at < b.bucket + INTERVAL '1 day'
+		GROUP BY 1, 2
+		ON CONFLICT (short_code, bucket) DO UPDATE SET ` + rollupUpdates + `
+	`, `
+		WITH buckets AS (` + daily + `)
+		INSERT INTO analytics_daily_breakdown (short_code, bucket, dimension, value, context, clicks, human_clicks)
+		SELECT a.short_code, b.bucket, d.dimension, d.value, d.context, COUNT(*), COUNT(*) FILTER (WHERE NOT a.is_bot)
+		FROM analytics a
+		JOIN buckets b ON a.short_code = b.short_code
+			AND a.clicked_at >= b.bucket AND a.clicked_at < b.bucket + INTERVAL '1 day'
+		CROSS JOIN LATERAL (` + breakdownRows() + `) AS d(dimension, value, context, key)
+		WHERE d.value IS NOT NULL
+		GROUP BY 1, 2, 3, 4, 5
+		ON CONFLICT (short_code, dimension, bucket, value, context) DO UPDATE SET
+			clicks = EXCLUDED.clicks,
+			human_clicks = EXCLUDED.human_clicks
+	`, `
+		INSERT INTO analytics_breakdown_ips (short_code, dimension, value, context, ip_address, human)
+		SELECT a.short_code, d.dimension, d.value, d.key, a.ip_address, bool_or(NOT a.is_bot)
+		FROM analytics a
+		CROSS JOIN LATERAL (` + breakdownRows() + `) AS d(dimension, value, context, key)
+		WHERE ` + clicks + ` AND a.ip_address IS NOT NULL AND d.value IS NOT NULL
+		GROUP BY 1, 2, 3, 4, 5
+		ON CONFLICT (short_code, dimension, value, context, ip_address) DO UPDATE SET
+			human = analytics_breakdown_ips.human OR EXCLUDED.human
+	`}
+}
+
+// lateClicks matches the clicks recorded in the settling window that were clicked before the
+// watermark, so their hour was rolled up without them.
+const lateClicks = `inserted_at >= @settled_from AND inserted_at < @settled_to AND clicked_at < @from`
+
+func (r *repository) RollUp(ctx context.Context, target, settled time.Time, maxStep time.Duration) (time.Time, error) {
+	var watermark time.Time
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		// The row lock serializes concurrent rollup jobs.
+		var from, settledFrom time.Time
+		err := tx.QueryRow(ctx, `SELECT rolled_up_to, settled_up_to FROM analytics_rollup_state FOR UPDATE`).Scan(&from, &settledFrom)
+		if err != nil {
+			return err
+		}
+		watermark = from
+		to := from.Add(maxStep).Truncate(time.Hour)
+		if target.Before(to) {
+			to = target
+		}
+		args := pgx.NamedArgs{
+			"from":         from,
+			"to":           to,
+			"day_from":     from.UTC().Truncate(24 * time.Hour),
+			"day_to":       to.UTC().Truncate(24 * time.Hour),
+			"settled_from": settledFrom,
+			"settled_to":   settled,
+		}
+
+		// Buckets are recomputed from all their raw clicks, so rerunning a range is harmless.
+		var queries []string
+		if settled.After(settledFrom) {
+			queries = append(queries, rollupQueries(`
+				SELECT DISTINCT short_code, date_trunc('hour', clicked_at, 'UTC') AS bucket
+				FROM analytics
+				WHERE `+lateClicks+`
+			`, `
+				SELECT DISTINCT short_code, date_trunc('day', clicked_at, 'UTC') AS bucket
+				FROM analytics
+				WHERE `+lateClicks+` AND clicked_at < @day_from
+			`, lateClicks)...)
+			queries = append(queries, `UPDATE analytics_rollup_state SET settled_up_to = @settled_to`)
+		}
+		if to.After(from) {
+			queries = append(queries, rollupQueries(`
+				SELECT DISTINCT short_code, date_trunc('hour', clicked_at, 'UTC') AS bucket
+				FROM analytics
+				WHERE clicked_at >= @from AND clicked_at < @to
+			`, `
+				SELECT DISTINCT short_code, date_trunc('day', clicked_at, 'UTC') AS bucket
+				FROM analytics
+				WHERE clicked_at >= @day_from AND clicked_at < @day_to
+			`, `clicked_at >= @from AND clicked_at < @to`)...)
+			queries = append(queries, `UPDATE analytics_rollup_state SET rolled_up_to = @to`)
+		}
+		for _, query := range queries {
+			if _, err := tx.Exec(ctx, query, args); err != nil {
+				return err
+			}
+		}
+		if to.After(from) {
+			watermark = to
+		}
+		return nil
+	})
+	return watermark, err
+}
+
+func (r *repository) DailySalt(ctx context.Context, day time.Time, candidate []byte) ([]byte, error) {
+	args := pgx.NamedArgs{
+		"day":  day.UTC().Format(time.DateOnly),
+		"salt": candidate,
+	}
+	if _, err := r.db.Exec(ctx, `DELETE FROM analytics_ip_salts WHERE day < @day::date - 1`, args); err != nil {
+		return nil, err
+	}
+	// Concurrent writers race on the insert; the select reads whichever salt won.
+	if _, err := r.db.Exec(ctx, `INSERT INTO analytics_ip_salts (day, salt) VALUES (@day, @salt) ON CONFLICT (day) DO NOTHING`, args); err != nil {
+		return nil, err
+	}
+	var salt []byte
+	err := r.db.QueryRow(ctx, `SELECT salt FROM analytics_ip_salts WHERE day = @day`, args).Scan(&salt)
+	return salt, err
+}
+
+func (r *repository) ListStoredIPs(ctx context.Context, after string, limit int) ([]*entity.Record, error) {
+	query := `
+		SELECT id, ip_address, clicked_at
+		FROM analytics
+		WHERE ip_address IS NOT NULL AND ip_address <> ''
+			AND (@after = '' OR id > NULLIF(@after, '')::uuid)
+		ORDER BY id
+		LIMIT @limit
+	`
+	args := pgx.NamedArgs{
+		"after": after,
+		"limit": limit,
+	}
+	rows, err := r.db.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]*entity.Record, 0, limit)
+	for rows.Next() {
+		var record entity.Record
+		if err := rows.Scan(&record.ID, &record.IPAddress, &record.ClickedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, rows.Err()
+}
+
+func (r *repository) UpdateIPs(ctx context.Context, ips map[string]string) error {
+	if len(ips) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(ips))
+	addresses := make([]string, 0, len(ips))
+	for id, ip := range ips {
+		ids = append(ids, id)
+		addresses = append(addresses, ip)
+	}
+	query := `
+		UPDATE analytics a
+		SET ip_address = NULLIF(u.ip_address, '')
+		FROM unnest(@ids::uuid[], @ip_addresses::text[]) AS u(id, ip_address)
+		WHERE a.id = u.id
+	`
+	args := pgx.NamedArgs{
+		"ids":          ids,
+		"ip_addresses": addresses,
+	}
+	_, err := r.db.Exec(ctx, query, args)
+	return err
+}
+
+func (r *repository) ResetRollups(ctx context.Context) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		// The row lock waits for a running rollup to finish.
+		if _, err := tx.Exec(ctx, `SELECT 1 FROM analytics_rollup_state FOR UPDATE`); err != nil {
+			return err
+		}
+		queries := []string{
+			`TRUNCATE analytics_hourly, analytics_daily, analytics_unique_ips,
+				analytics_daily_breakdown, analytics_breakdown_ips`,
+			`UPDATE analytics_rollup_state SET
+				rolled_up_to = (SELECT date_trunc('hour', COALESCE(MIN(clicked_at), NOW()), 'UTC') FROM analytics),
+				settled_up_to = NOW()`,
+		}
+		for _, query := range queries {
+			if _, err := tx.Exec(ctx, query); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
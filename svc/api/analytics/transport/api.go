@@ -5,7 +5,9 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
+	appErrors "url-shorterner/internal/errors"
 	"url-shorterner/svc/analytics/app"
 
 	"github.com/gin-gonic/gin"
@@ -34,7 +36,13 @@ func (a *api) GetAnalytics(c *gin.Context) {
 		}
 	}
 
-	stats, err := a.service.GetStats(c.Request.Context(), shortCode)
+	bots, err := includeBots(c)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	stats, err := a.service.GetStats(c.Request.Context(), shortCode, bots)
 	if err != nil {
 		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
 		return
@@ -47,14 +55,138 @@ func (a *api) GetAnalytics(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"short_code":   shortCode,
-		"total_clicks": stats.TotalClicks,
-		"unique_ips":   stats.UniqueIPs,
-		"last_click":   stats.LastClick,
-		"records":      records,
+		"short_code":      shortCode,
+		"total_clicks":    stats.TotalClicks,
+		"unique_ips":      stats.UniqueIPs,
+		"unique_visitors": stats.UniqueVisitors,
+		"last_click":      stats.LastClick,
+		"records":         records,
 	})
 }
 
+// GetGroupedAnalytics implements AnalyticsAPI.GetGroupedAnalytics
+// See AnalyticsAPI interface in http.go for API documentation
+func (a *api) GetGroupedAnalytics(c *gin.Context) {
+	if by := c.DefaultQuery("by", "tag"); by != "tag" {
+		c.Error(appErrors.Invalid(appErrors.ErrCodeInvalidGroupBy, map[string]interface{}{ //nolint:errcheck // Error is handled by ErrorHandler middleware
+			"By":      by,
+			"Allowed": "tag",
+		}))
+		return
+	}
+
+	bots, err := includeBots(c)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	stats, err := a.service.GetTagStats(c.Request.Context(), c.QueryArray("tag"), bots)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": stats})
+}
+
+// GetTimeSeries implements AnalyticsAPI.GetTimeSeries
+// See AnalyticsAPI interface in http.go for API documentation
+func (a *api) GetTimeSeries(c *gin.Context) {
+	query, err := app.ParseSeriesQuery(c.Query("from"), c.Query("to"), c.Query("interval"), c.Query("tz"))
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	if query.IncludeBots, err = includeBots(c); err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	series, err := a.service.GetTimeSeries(c.Request.Context(), c.Param("code"), query)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// GetBreakdown implements AnalyticsAPI.GetBreakdown
+// See AnalyticsAPI interface in http.go for API documentation
+func (a *api) GetBreakdown(c *gin.Context) {
+	bots, err := includeBots(c)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	by := c.Query("by")
+	items, err := a.service.GetBreakdown(c.Request.Context(), c.Param("code"), by, bots)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"by": by, "items": items})
+}
+
+// GetReferrers implements AnalyticsAPI.GetReferrers
+// See AnalyticsAPI interface in http.go for API documentation
+func (a *api) GetReferrers(c *gin.Context) {
+	limit := 10
+	if limitParam := c.Query("limit"); limitParam != "" {
+		if parsedLimit := parseInt(limitParam); parsedLimit > 0 && parsedLimit <= 100 {
+			limit = parsedLimit
+		}
+	}
+
+	bots, err := includeBots(c)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	referrers, err := a.service.GetReferrers(c.Request.Context(), c.Param("code"), limit, bots)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, referrers)
+}
+
+// GetLiveClicks implements AnalyticsAPI.GetLiveClicks
+// See AnalyticsAPI interface in http.go for API documentation
+func (a *api) GetLiveClicks(c *gin.Context) {
+	bots, err := includeBots(c)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	events, err := a.service.StreamClicks(c.Request.Context(), c.Param("code"), bots)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	// The stream outlives the server's write timeout.
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}) //nolint:errcheck // Writers without deadlines have none to lift
+	// Reverse proxies such as nginx must not buffer the stream.
+	c.Header("X-Accel-Buffering", "no")
+	for event := range events {
+		if event.Click != nil {
+			c.SSEvent("click", event.Click)
+		} else {
+			c.SSEvent("summary", event.Summary)
+		}
+		c.Writer.Flush()
+	}
+}
+
+// includeBots reports whether the include_bots query parameter asks to count bot clicks. Values
+// other than booleans are invalid.
+func includeBots(c *gin.Context) (bool, error) {
+	return app.ParseIncludeBots(c.Query("include_bots"))
+}
+
 func parseInt(s string) int {
 	result, err := strconv.Atoi(s)
 	if err != nil {
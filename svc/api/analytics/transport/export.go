@@ -0,0 +1,96 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"url-shorterner/internal/bulk"
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/internal/log"
+	"url-shorterner/svc/analytics/app"
+	"url-shorterner/svc/analytics/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFlushEvery is the number of rows written between flushes of an export.
+const exportFlushEvery = 100
+
+// trailerExportError reports a failure after the export body has started.
+const trailerExportError = "X-Export-Error"
+
+// ExportClicks implements AnalyticsAPI.ExportClicks
+// See AnalyticsAPI interface in http.go for API documentation
+func (a *api) ExportClicks(c *gin.Context) {
+	format := bulk.FormatCSV
+	if name := c.Query("format"); name != "" {
+		var err error
+		if format, err = bulk.ParseExportFormat(name); err != nil {
+			c.Error(appErrors.Invalid(appErrors.ErrCodeExportFormat, nil)) //nolint:errcheck // Error is handled by ErrorHandler middleware
+			return
+		}
+	}
+	query, columns, err := app.ParseExportQuery(c.Query("code"), c.Query("from"), c.Query("to"), c.Query("fields"))
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	// Large exports outlive the server's write timeout.
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}) //nolint:errcheck // Writers without deadlines have none to lift
+
+	var out bulk.Writer
+	rows := 0
+	err = a.service.ExportClicks(c.Request.Context(), query, func(record *entity.Record) error {
+		if out == nil {
+			c.Header("Trailer", trailerExportError)
+			var err error
+			if out, err = newExportWriter(c, format, columns); err != nil {
+				return err
+			}
+		}
+		if err := out.Write(app.ExportValues(record, columns)...); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushEvery == 0 {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil && out == nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	if err != nil {
+		// Headers are already sent, so the failure is reported in a trailer.
+		log.Error("Click export aborted: %v", err)
+		if closeErr := out.Close(); closeErr != nil {
+			log.Error("Failed to write click export: %v", closeErr)
+		}
+		c.Header(trailerExportError, appErrors.GetMessage(appErrors.ErrCodeInternal, appErrors.GetLanguageFromContext(c)))
+		return
+	}
+	if out == nil {
+		if out, err = newExportWriter(c, format, columns); err != nil {
+			log.Error("Failed to write click export: %v", err)
+			return
+		}
+	}
+	if err := out.Close(); err != nil {
+		log.Error("Failed to write click export: %v", err)
+	}
+	c.Writer.Flush()
+}
+
+// newExportWriter sends the headers of a click export download and returns its row writer.
+func newExportWriter(c *gin.Context, format bulk.Format, columns []string) (bulk.Writer, error) {
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="clicks-export.%s"`, format))
+	c.Status(http.StatusOK)
+	return bulk.NewTypedWriter(format, c.Writer, app.ExportColumns(columns))
+}
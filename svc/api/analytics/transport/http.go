@@ -4,7 +4,10 @@ package transport
 import (
 	"time"
 
+	"url-shorterner/internal/access"
+	"url-shorterner/internal/apikey"
 	"url-shorterner/internal/http"
+	"url-shorterner/internal/middleware"
 	"url-shorterner/internal/rate"
 	"url-shorterner/svc/analytics/app"
 	"url-shorterner/svc/analytics/entity"
@@ -28,6 +31,11 @@ type AnalyticsResponse struct {
 	// example: 10
 	UniqueIPs int `json:"unique_ips"`
 
+	// Estimated number of unique human visitors (IP address and user agent) from HyperLogLog
+	// sketches, whatever include_bots; null if unavailable
+	// example: 9
+	UniqueVisitors *int64 `json:"unique_visitors"`
+
 	// Timestamp of the last click (null if no clicks)
 	LastClick *time.Time `json:"last_click"`
 
@@ -63,6 +71,7 @@ type AnalyticsAPI interface {
 	//   This endpoint provides detailed analytics data including:
 	//   - Total number of clicks
 	//   - Number of unique IP addresses
+	//   - Estimated number of unique visitors
 	//   - Last click timestamp
 	//   - Paginated list of click records with IP, user agent, referer, and timestamp
 	//
@@ -92,13 +101,19 @@ type AnalyticsAPI interface {
 	//     minimum: 1
 	//     maximum: 1000
 	//     description: Limit number of records to return (max 1000)
+	//   - name: include_bots
+	//     in: query
+	//     required: false
+	//     type: boolean
+	//     default: false
+	//     description: Count clicks flagged as bots (user agent, crawler network or click burst); must be a boolean
 	// responses:
 	//   "200":
 	//     description: Analytics data retrieved successfully
 	//     schema:
 	//       $ref: "#/definitions/AnalyticsResponse"
 	//   "400":
-	//     description: Invalid request - short code required
+	//     description: Invalid request - short code required or invalid include_bots
 	//     schema:
 	//       $ref: "#/definitions/ErrorResponse"
 	//   "500":
@@ -108,12 +123,351 @@ type AnalyticsAPI interface {
 	// security:
 	//   - ApiKeyAuth: []
 	GetAnalytics(*gin.Context)
+
+	// GetGroupedAnalytics aggregates click analytics across links
+	//
+	// swagger:operation GET /analytics analytics getGroupedAnalytics
+	//
+	// Aggregates click analytics across links, grouped by tag.
+	//
+	// ---
+	// summary: Get analytics grouped by tag
+	// description: |
+	//   Returns the number of links, total clicks, unique IPs and last click per tag, most clicked first.
+	//   Links carrying several tags count towards each of them.
+	// tags:
+	//   - analytics
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: by
+	//     in: query
+	//     type: string
+	//     enum: [tag]
+	//     description: Grouping (default tag)
+	//   - name: tag
+	//     in: query
+	//     type: array
+	//     items:
+	//       type: string
+	//     collectionFormat: multi
+	//     description: Restrict the result to these tags (repeatable)
+	//   - name: include_bots
+	//     in: query
+	//     required: false
+	//     type: boolean
+	//     default: false
+	//     description: Count clicks flagged as bots (user agent, crawler network or click burst); must be a boolean
+	// responses:
+	//   "200":
+	//     description: Statistics per tag
+	//     schema:
+	//       type: object
+	//       properties:
+	//         tags:
+	//           type: array
+	//           items:
+	//             $ref: "#/definitions/TagStats"
+	//   "400":
+	//     description: Unsupported grouping
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	// security:
+	//   - ApiKeyAuth: []
+	GetGroupedAnalytics(*gin.Context)
+
+	// GetTimeSeries returns the clicks of a short code per time bucket
+	//
+	// swagger:operation GET /analytics/{code}/timeseries analytics getTimeSeries
+	//
+	// Returns click and unique IP counts per hour, day or week.
+	//
+	// ---
+	// summary: Get a click time series
+	// description: |
+	//   Buckets start at the beginning of the hour, day or ISO week (Monday) in `tz`, so days and weeks
+	//   follow its DST changes. Every bucket of the range is returned, with zero counts when there were no clicks.
+	//   `unique_visitors` estimates the unique visitors of the whole range by merging the daily HyperLogLog
+	//   sketches of the UTC days it overlaps. The sketches are kept per UTC day, so it is null unless `tz` is UTC,
+	//   and only count human clicks, so `include_bots` does not change it.
+	// tags:
+	//   - analytics
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: code
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Short code of the link
+	//   - name: from
+	//     in: query
+	//     type: string
+	//     description: Start of the range, RFC 3339 or YYYY-MM-DD (default 30 days before to)
+	//   - name: to
+	//     in: query
+	//     type: string
+	//     description: End of the range, RFC 3339 or YYYY-MM-DD (default now)
+	//   - name: interval
+	//     in: query
+	//     type: string
+	//     enum: [hour, day, week]
+	//     description: Bucket width of the series (default day)
+	//   - name: tz
+	//     in: query
+	//     type: string
+	//     description: IANA time zone in which buckets start (default UTC)
+	//   - name: include_bots
+	//     in: query
+	//     required: false
+	//     type: boolean
+	//     default: false
+	//     description: Count clicks flagged as bots (user agent, crawler network or click burst); must be a boolean
+	// responses:
+	//   "200":
+	//     description: Time series
+	//     schema:
+	//       $ref: "#/definitions/TimeSeries"
+	//   "400":
+	//     description: Invalid time range, interval or time zone
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	// security:
+	//   - ApiKeyAuth: []
+	GetTimeSeries(*gin.Context)
+
+	// GetBreakdown groups the clicks of a short code by browser, OS, device, location or referrer
+	//
+	// swagger:operation GET /analytics/{code}/breakdown analytics getBreakdown
+	//
+	// Returns click and unique IP counts per browser family, OS family, device class, country,
+	// region, city, autonomous system, traffic source, referrer host or utm_* parameter.
+	//
+	// ---
+	// summary: Get a click breakdown
+	// description: |
+	//   Values are parsed from the User-Agent and resolved from the GeoIP database when the click is
+	//   recorded. Bots are reported under their name as browser and `bot` as device. Regions and cities
+	//   carry their country, autonomous systems their organization, and referrer hosts their source, in
+	//   `context`. Clicks whose value is not known are `unknown`, except for `referrer` and the utm_*
+	//   dimensions, which omit clicks without one.
+	// tags:
+	//   - analytics
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: code
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Short code of the link
+	//   - name: by
+	//     in: query
+	//     required: true
+	//     type: string
+	//     enum: [browser, os, device, country, region, city, asn, source, referrer, utm_source, utm_medium, utm_campaign]
+	//     description: Dimension to group by
+	//   - name: include_bots
+	//     in: query
+	//     required: false
+	//     type: boolean
+	//     default: false
+	//     description: Count clicks flagged as bots (user agent, crawler network or click burst); must be a boolean
+	// responses:
+	//   "200":
+	//     description: Clicks per value, most clicked first
+	//     schema:
+	//       type: object
+	//       properties:
+	//         by:
+	//           type: string
+	//         items:
+	//           type: array
+	//           items:
+	//             $ref: "#/definitions/BreakdownItem"
+	//   "400":
+	//     description: Unsupported dimension
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	// security:
+	//   - ApiKeyAuth: []
+	GetBreakdown(*gin.Context)
+
+	// GetReferrers returns the top traffic sources and referrer hosts of a short code
+	//
+	// swagger:operation GET /analytics/{code}/referrers analytics getReferrers
+	//
+	// Returns click and unique IP counts per traffic source and for the most clicked referrer hosts.
+	//
+	// ---
+	// summary: Get top referrers
+	// description: |
+	//   The referrer host is taken from the Referer header without "www." and classified when the
+	//   click is recorded as `search`, `social`, `email` or `internal` by the referrer rules, `referral`
+	//   for other hosts, or `direct` without a referrer. Clicks without a referrer whose utm_medium is
+	//   `search`, `social` or `email` take that source. Referrer hosts carry their source in `context`.
+	// tags:
+	//   - analytics
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: code
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Short code of the link
+	//   - name: limit
+	//     in: query
+	//     required: false
+	//     type: integer
+	//     minimum: 1
+	//     maximum: 100
+	//     default: 10
+	//     description: Maximum number of referrer hosts
+	//   - name: include_bots
+	//     in: query
+	//     required: false
+	//     type: boolean
+	//     default: false
+	//     description: Count clicks flagged as bots (user agent, crawler network or click burst); must be a boolean
+	// responses:
+	//   "200":
+	//     description: Sources and referrer hosts, most clicked first
+	//     schema:
+	//       $ref: "#/definitions/Referrers"
+	//   "400":
+	//     description: Invalid include_bots
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	// security:
+	//   - ApiKeyAuth: []
+	GetReferrers(*gin.Context)
+
+	// GetLiveClicks streams the clicks of a short code as they are recorded
+	//
+	// swagger:operation GET /analytics/{code}/live analytics getLiveClicks
+	//
+	// Streams clicks as Server-Sent Events with periodic count summaries.
+	//
+	// ---
+	// summary: Stream live clicks
+	// description: |
+	//   A `text/event-stream` of `click` events, one per click recorded by any analytics instance
+	//   while the stream is open, with timestamp, country, referrer host, source and device, and
+	//   `summary` events with the link's total clicks and the streamed clicks, sent when the
+	//   stream opens and then every ANALYTICS_LIVE_SUMMARY_SECONDS. Past clicks are not
+	//   replayed, and clicks published while a client reconnects are missed. The stream ends
+	//   when the client disconnects.
+	// tags:
+	//   - analytics
+	// produces:
+	//   - text/event-stream
+	// parameters:
+	//   - name: code
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Short code of the link
+	//   - name: include_bots
+	//     in: query
+	//     required: false
+	//     type: boolean
+	//     default: false
+	//     description: Stream and count clicks flagged as bots (user agent, crawler network or click burst)
+	// responses:
+	//   "200":
+	//     description: Event stream; `click` events carry a LiveClick and `summary` events a LiveSummary
+	//     schema:
+	//       $ref: "#/definitions/LiveClick"
+	//   "400":
+	//     description: Invalid include_bots
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "404":
+	//     description: No link has this short code
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	// security:
+	//   - ApiKeyAuth: []
+	GetLiveClicks(*gin.Context)
+
+	// ExportClicks streams raw click records as a CSV, JSONL or Parquet download
+	//
+	// swagger:operation GET /analytics/export analytics exportClicks
+	//
+	// Exports raw click records, oldest first, optionally for one short code and a time range.
+	//
+	// ---
+	// summary: Export clicks
+	// description: |
+	//   Rows are read through a database cursor and streamed as they are encoded, so exports of any
+	//   size use constant memory. Bot clicks are included; filter them with the `is_bot` field.
+	//   Parquet columns are optional and typed after their field (INT64, BOOLEAN, UTF8 strings and
+	//   microsecond UTC timestamps), even in empty exports; the file is only complete once its
+	//   footer is written at the end. Clients that take over a minute to read a batch of rows
+	//   end the export. If the export fails after the body has started, the `X-Export-Error`
+	//   trailer carries the error. Exports include IP addresses and user agents, so they require
+	//   an API key.
+	// tags:
+	//   - analytics
+	// produces:
+	//   - text/csv
+	//   - application/x-ndjson
+	//   - application/vnd.apache.parquet
+	// parameters:
+	//   - name: format
+	//     in: query
+	//     type: string
+	//     enum: [csv, jsonl, parquet]
+	//     default: csv
+	//     description: File format
+	//   - name: code
+	//     in: query
+	//     type: string
+	//     description: Only export the clicks of this short code (default all links)
+	//   - name: from
+	//     in: query
+	//     type: string
+	//     description: Start of the range (inclusive), RFC 3339 or YYYY-MM-DD in UTC (default unbounded)
+	//   - name: to
+	//     in: query
+	//     type: string
+	//     description: End of the range (exclusive), RFC 3339 or YYYY-MM-DD in UTC (default unbounded)
+	//   - name: fields
+	//     in: query
+	//     type: string
+	//     description: |
+	//       Comma-separated columns in output order (default all): id, short_code, url_version, clicked_at,
+	//       ip_address, user_agent, browser, browser_version, os, device, is_bot, bot_reason, country,
+	//       region, city, asn, as_org, referer, referrer_host, source, utm_source, utm_medium,
+	//       utm_campaign, utm_term, utm_content
+	// responses:
+	//   "200":
+	//     description: Export file
+	//   "400":
+	//     description: Unsupported format, unknown field or invalid time range
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "401":
+	//     description: Missing or unknown API key
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	// security:
+	//   - ApiKeyAuth: []
+	ExportClicks(*gin.Context)
 }
 
-// SetupRouter registers analytics API routes on the provided router.
-func SetupRouter(router *gin.Engine, service app.Service, limiter rate.Limiter) {
-	apiGroup := http.Router(router, "/", limiter)
+// SetupRouter registers analytics API routes on the provided router. Raw click exports require
+// one of keys.
+func SetupRouter(router *gin.Engine, service app.Service, limiter rate.Limiter, acl *access.List, keys *apikey.Keys) {
+	apiGroup := http.Router(router, "/", limiter, acl)
 
 	api := NewAnalyticsAPI(service)
+	apiGroup.GET("/analytics", api.GetGroupedAnalytics)
+	apiGroup.GET("/analytics/export", middleware.RequireAPIKey(keys), api.ExportClicks)
 	apiGroup.GET("/analytics/:code", api.GetAnalytics)
+	apiGroup.GET("/analytics/:code/timeseries", api.GetTimeSeries)
+	apiGroup.GET("/analytics/:code/breakdown", api.GetBreakdown)
+	apiGroup.GET("/analytics/:code/referrers", api.GetReferrers)
+	apiGroup.GET("/analytics/:code/live", api.GetLiveClicks)
 }
@@ -0,0 +1,135 @@
+// Package transport provides HTTP handler implementations for the campaigns API.
+package transport
+
+import (
+	"net/http"
+
+	analyticsApp "url-shorterner/svc/analytics/app"
+	"url-shorterner/svc/campaigns/app"
+
+	"github.com/gin-gonic/gin"
+)
+
+type api struct {
+	service app.Service
+}
+
+// NewCampaignsAPI creates a new campaigns API handler instance.
+func NewCampaignsAPI(service app.Service) CampaignsAPI {
+	return &api{service: service}
+}
+
+// CreateCampaign implements CampaignsAPI.CreateCampaign
+// See CampaignsAPI interface in http.go for API documentation
+func (a *api) CreateCampaign(c *gin.Context) {
+	var req CampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	campaign, err := a.service.CreateCampaign(c.Request.Context(), req.Name, req.Description)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.Header("Location", "/campaigns/"+campaign.ID)
+	c.JSON(http.StatusCreated, campaign)
+}
+
+// ListCampaigns implements CampaignsAPI.ListCampaigns
+// See CampaignsAPI interface in http.go for API documentation
+func (a *api) ListCampaigns(c *gin.Context) {
+	campaigns, err := a.service.ListCampaigns(c.Request.Context())
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"campaigns": campaigns})
+}
+
+// GetCampaign implements CampaignsAPI.GetCampaign
+// See CampaignsAPI interface in http.go for API documentation
+func (a *api) GetCampaign(c *gin.Context) {
+	campaign, err := a.service.GetCampaign(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
+
+// UpdateCampaign implements CampaignsAPI.UpdateCampaign
+// See CampaignsAPI interface in http.go for API documentation
+func (a *api) UpdateCampaign(c *gin.Context) {
+	var req CampaignUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	campaign, err := a.service.UpdateCampaign(c.Request.Context(), c.Param("id"), req.Name, req.Description)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
+
+// DeleteCampaign implements CampaignsAPI.DeleteCampaign
+// See CampaignsAPI interface in http.go for API documentation
+func (a *api) DeleteCampaign(c *gin.Context) {
+	if err := a.service.DeleteCampaign(c.Request.Context(), c.Param("id")); err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AddLinks implements CampaignsAPI.AddLinks
+// See CampaignsAPI interface in http.go for API documentation
+func (a *api) AddLinks(c *gin.Context) {
+	var req AddLinksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	result, err := a.service.AddLinks(c.Request.Context(), c.Param("id"), req.ShortCodes)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// RemoveLink implements CampaignsAPI.RemoveLink
+// See CampaignsAPI interface in http.go for API documentation
+func (a *api) RemoveLink(c *gin.Context) {
+	if err := a.service.RemoveLink(c.Request.Context(), c.Param("id"), c.Param("code")); err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetCampaignAnalytics implements CampaignsAPI.GetCampaignAnalytics
+// See CampaignsAPI interface in http.go for API documentation
+func (a *api) GetCampaignAnalytics(c *gin.Context) {
+	query, err := analyticsApp.ParseSeriesQuery(c.Query("from"), c.Query("to"), c.Query("interval"), c.Query("tz"))
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	if query.IncludeBots, err = analyticsApp.ParseIncludeBots(c.Query("include_bots")); err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	stats, err := a.service.GetAnalytics(c.Request.Context(), c.Param("id"), query)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,350 @@
+// Package transport provides HTTP transport layer for the campaigns API.
+package transport
+
+import (
+	"url-shorterner/internal/access"
+	"url-shorterner/internal/http"
+	"url-shorterner/internal/rate"
+	"url-shorterner/svc/campaigns/app"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CampaignRequest represents the request body for creating a campaign
+//
+// swagger:model CampaignRequest
+type CampaignRequest struct {
+	// Name of the campaign
+	// required: true
+	// example: Spring sale
+	Name string `json:"name" binding:"required"`
+
+	// Free-form notes (optional)
+	Description string `json:"description,omitempty"`
+}
+
+// CampaignUpdateRequest represents the request body for updating a campaign; omitted fields are unchanged
+//
+// swagger:model CampaignUpdateRequest
+type CampaignUpdateRequest struct {
+	// New name of the campaign
+	Name *string `json:"name,omitempty"`
+
+	// New description of the campaign
+	Description *string `json:"description,omitempty"`
+}
+
+// AddLinksRequest represents the request body for adding links to a campaign
+//
+// swagger:model AddLinksRequest
+type AddLinksRequest struct {
+	// Short codes of the links to add, at most 1000
+	// required: true
+	// example: ["abc123", "spring-sale"]
+	ShortCodes []string `json:"short_codes" binding:"required"`
+}
+
+// ErrorResponse represents an error response
+//
+// swagger:model ErrorResponse
+type ErrorResponse struct {
+	// Error message describing what went wrong
+	// example: error message
+	Error string `json:"error"`
+}
+
+// CampaignsAPI defines the HTTP interface for campaign endpoints.
+type CampaignsAPI interface {
+	// CreateCampaign creates a campaign
+	//
+	// swagger:operation POST /campaigns campaigns createCampaign
+	//
+	// Creates an empty campaign that links can be added to.
+	//
+	// ---
+	// summary: Create a campaign
+	// tags:
+	//   - campaigns
+	// consumes:
+	//   - application/json
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: body
+	//     in: body
+	//     required: true
+	//     schema:
+	//       $ref: "#/definitions/CampaignRequest"
+	// responses:
+	//   "201":
+	//     description: Campaign created
+	//     schema:
+	//       $ref: "#/definitions/Campaign"
+	//   "400":
+	//     description: Invalid name or description
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	CreateCampaign(*gin.Context)
+
+	// ListCampaigns lists all campaigns
+	//
+	// swagger:operation GET /campaigns campaigns listCampaigns
+	//
+	// Lists all campaigns with their number of links, newest first.
+	//
+	// ---
+	// summary: List campaigns
+	// tags:
+	//   - campaigns
+	// produces:
+	//   - application/json
+	// responses:
+	//   "200":
+	//     description: Campaigns
+	//     schema:
+	//       type: object
+	//       properties:
+	//         campaigns:
+	//           type: array
+	//           items:
+	//             $ref: "#/definitions/Campaign"
+	ListCampaigns(*gin.Context)
+
+	// GetCampaign returns a campaign
+	//
+	// swagger:operation GET /campaigns/{id} campaigns getCampaign
+	//
+	// Returns a campaign with its number of links.
+	//
+	// ---
+	// summary: Get a campaign
+	// tags:
+	//   - campaigns
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: id
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Campaign ID
+	// responses:
+	//   "200":
+	//     description: Campaign
+	//     schema:
+	//       $ref: "#/definitions/Campaign"
+	//   "404":
+	//     description: Campaign not found
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	GetCampaign(*gin.Context)
+
+	// UpdateCampaign renames or redescribes a campaign
+	//
+	// swagger:operation PATCH /campaigns/{id} campaigns updateCampaign
+	//
+	// Changes the name or description of a campaign.
+	//
+	// ---
+	// summary: Update a campaign
+	// tags:
+	//   - campaigns
+	// consumes:
+	//   - application/json
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: id
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Campaign ID
+	//   - name: body
+	//     in: body
+	//     required: true
+	//     schema:
+	//       $ref: "#/definitions/CampaignUpdateRequest"
+	// responses:
+	//   "200":
+	//     description: Updated campaign
+	//     schema:
+	//       $ref: "#/definitions/Campaign"
+	//   "400":
+	//     description: Invalid name or description
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "404":
+	//     description: Campaign not found
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	UpdateCampaign(*gin.Context)
+
+	// DeleteCampaign deletes a campaign
+	//
+	// swagger:operation DELETE /campaigns/{id} campaigns deleteCampaign
+	//
+	// Deletes a campaign. Its links are kept and no longer belong to a campaign.
+	//
+	// ---
+	// summary: Delete a campaign
+	// tags:
+	//   - campaigns
+	// parameters:
+	//   - name: id
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Campaign ID
+	// responses:
+	//   "204":
+	//     description: Campaign deleted
+	//   "404":
+	//     description: Campaign not found
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	DeleteCampaign(*gin.Context)
+
+	// AddLinks adds links to a campaign
+	//
+	// swagger:operation POST /campaigns/{id}/links campaigns addCampaignLinks
+	//
+	// Moves links into the campaign; a link belongs to at most one campaign.
+	//
+	// ---
+	// summary: Add links to a campaign
+	// tags:
+	//   - campaigns
+	// consumes:
+	//   - application/json
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: id
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Campaign ID
+	//   - name: body
+	//     in: body
+	//     required: true
+	//     schema:
+	//       $ref: "#/definitions/AddLinksRequest"
+	// responses:
+	//   "200":
+	//     description: Added and unknown short codes
+	//     schema:
+	//       $ref: "#/definitions/AddLinksResult"
+	//   "400":
+	//     description: Too many short codes
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "404":
+	//     description: Campaign not found
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	AddLinks(*gin.Context)
+
+	// RemoveLink removes a link from a campaign
+	//
+	// swagger:operation DELETE /campaigns/{id}/links/{code} campaigns removeCampaignLink
+	//
+	// Takes a link out of the campaign; the link itself is kept.
+	//
+	// ---
+	// summary: Remove a link from a campaign
+	// tags:
+	//   - campaigns
+	// parameters:
+	//   - name: id
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Campaign ID
+	//   - name: code
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Short code of the link
+	// responses:
+	//   "204":
+	//     description: Link removed
+	//   "404":
+	//     description: Link is not in the campaign
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	RemoveLink(*gin.Context)
+
+	// GetCampaignAnalytics rolls up the analytics of all links of a campaign
+	//
+	// swagger:operation GET /campaigns/{id}/analytics campaigns getCampaignAnalytics
+	//
+	// Aggregates clicks across all links of a campaign.
+	//
+	// ---
+	// summary: Get campaign analytics
+	// description: |
+	//   Returns the total clicks, unique IPs, last click and a time series of the clicks on all links
+	//   of the campaign within [from, to). Buckets without clicks are omitted.
+	// tags:
+	//   - campaigns
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: id
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Campaign ID
+	//   - name: from
+	//     in: query
+	//     type: string
+	//     description: Start of the range, RFC 3339 or YYYY-MM-DD (default 30 days before to)
+	//   - name: to
+	//     in: query
+	//     type: string
+	//     description: End of the range, RFC 3339 or YYYY-MM-DD (default now)
+	//   - name: interval
+	//     in: query
+	//     type: string
+	//     enum: [hour, day, week]
+	//     description: Bucket width of the series (default day)
+	//   - name: tz
+	//     in: query
+	//     type: string
+	//     description: IANA time zone in which buckets start (default UTC)
+	//   - name: include_bots
+	//     in: query
+	//     required: false
+	//     type: boolean
+	//     default: false
+	//     description: Count clicks flagged as bots (user agent, crawler network or click burst)
+	// responses:
+	//   "200":
+	//     description: Campaign analytics
+	//     schema:
+	//       $ref: "#/definitions/CampaignStats"
+	//   "400":
+	//     description: Invalid time range, interval, time zone or include_bots
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "404":
+	//     description: Campaign not found
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	GetCampaignAnalytics(*gin.Context)
+}
+
+// SetupRouter registers campaigns API routes on the provided router.
+func SetupRouter(router *gin.Engine, service app.Service, limiter rate.Limiter, acl *access.List) {
+	apiGroup := http.Router(router, "/", limiter, acl)
+
+	api := NewCampaignsAPI(service)
+	apiGroup.POST("/campaigns", api.CreateCampaign)
+	apiGroup.GET("/campaigns", api.ListCampaigns)
+	apiGroup.GET("/campaigns/:id", api.GetCampaign)
+	apiGroup.PATCH("/campaigns/:id", api.UpdateCampaign)
+	apiGroup.DELETE("/campaigns/:id", api.DeleteCampaign)
+	apiGroup.POST("/campaigns/:id/links", api.AddLinks)
+	apiGroup.DELETE("/campaigns/:id/links/:code", api.RemoveLink)
+	apiGroup.GET("/campaigns/:id/analytics", api.GetCampaignAnalytics)
+}
@@ -0,0 +1,175 @@
+// Package transport provides HTTP handler implementations for the jobs API.
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-shorterner/internal/apikey"
+	"url-shorterner/internal/bulk"
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/internal/log"
+	"url-shorterner/svc/jobs/app"
+	"url-shorterner/svc/jobs/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+type api struct {
+	service   app.Service
+	maxUpload int64
+	keys      *apikey.Keys
+}
+
+// NewJobsAPI creates a new jobs API handler instance.
+// keys are required to download the results of export jobs.
+func NewJobsAPI(service app.Service, maxUpload int64, keys *apikey.Keys) JobsAPI {
+	return &api{
+		service:   service,
+		maxUpload: maxUpload,
+		keys:      keys,
+	}
+}
+
+// SubmitShorten implements JobsAPI.SubmitShorten
+// See JobsAPI interface in http.go for API documentation
+func (a *api) SubmitShorten(c *gin.Context) {
+	format, ok := queryFormat(c, bulk.FormatJSONL)
+	if !ok {
+		c.Error(appErrors.Invalid(appErrors.ErrCodeImportFormat, nil)) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	a.limitBody(c)
+	var req ShortenJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(a.uploadError(err)) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	job, err := a.service.SubmitShorten(c.Request.Context(), req.Items, format, appErrors.GetLanguageFromContext(c))
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	accepted(c, job)
+}
+
+// SubmitImport implements JobsAPI.SubmitImport
+// See JobsAPI interface in http.go for API documentation
+func (a *api) SubmitImport(c *gin.Context) {
+	format, ok := queryFormat(c, "")
+	if c.Query("format") == "" {
+		format, ok = bulk.FormatFromContentType(c.ContentType())
+	}
+	if !ok {
+		c.Error(appErrors.Invalid(appErrors.ErrCodeImportFormat, nil)) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	reportAll := c.Query("report") == "all"
+
+	// Large uploads outlive the server's read timeout, and the response is only written once they are stored.
+	rc := http.NewResponseController(c.Writer)
+	rc.SetReadDeadline(time.Time{})  //nolint:errcheck // Readers without deadlines have none to lift
+	rc.SetWriteDeadline(time.Time{}) //nolint:errcheck // Writers without deadlines have none to lift
+
+	a.limitBody(c)
+	job, err := a.service.SubmitImport(c.Request.Context(), format, c.Request.Body, reportAll, appErrors.GetLanguageFromContext(c))
+	if err != nil {
+		c.Error(a.uploadError(err)) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	accepted(c, job)
+}
+
+// SubmitExport implements JobsAPI.SubmitExport
+// See JobsAPI interface in http.go for API documentation
+func (a *api) SubmitExport(c *gin.Context) {
+	format, ok := queryFormat(c, bulk.FormatCSV)
+	if !ok {
+		c.Error(appErrors.Invalid(appErrors.ErrCodeImportFormat, nil)) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	withStats, _ := strconv.ParseBool(c.Query("stats")) //nolint:errcheck // Invalid values disable stats
+
+	job, err := a.service.SubmitExport(c.Request.Context(), format, withStats, appErrors.GetLanguageFromContext(c))
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	accepted(c, job)
+}
+
+// GetJob implements JobsAPI.GetJob
+// See JobsAPI interface in http.go for API documentation
+func (a *api) GetJob(c *gin.Context) {
+	job, err := a.service.GetJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// GetJobResult implements JobsAPI.GetJobResult
+// See JobsAPI interface in http.go for API documentation
+func (a *api) GetJobResult(c *gin.Context) {
+	job, result, err := a.service.OpenResult(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	// Exports hold every link, so their results are protected like GET /links/export.
+	if job.Type == entity.JobTypeExport {
+		if _, ok := a.keys.Lookup(apikey.FromRequest(c.Request)); !ok {
+			c.Error(appErrors.Unauthorized(appErrors.ErrCodeAPIKeyRequired)) //nolint:errcheck // Error is handled by ErrorHandler middleware
+			return
+		}
+	}
+
+	format := bulk.Format(job.ResultFormat)
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`, job.Type, job.ID, format))
+	c.Status(http.StatusOK)
+	// Large results outlive the server's write timeout.
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}) //nolint:errcheck // Writers without deadlines have none to lift
+	if _, err := io.Copy(c.Writer, result); err != nil {
+		log.Error("Failed to stream result of job %s: %v", job.ID, err)
+	}
+}
+
+// limitBody bounds the request body to the configured upload size.
+func (a *api) limitBody(c *gin.Context) {
+	if a.maxUpload > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, a.maxUpload)
+	}
+}
+
+// uploadError reports bodies cut off by limitBody as ErrCodeUploadTooLarge.
+func (a *api) uploadError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return appErrors.Invalid(appErrors.ErrCodeUploadTooLarge, map[string]interface{}{"Max": maxErr.Limit})
+	}
+	return err
+}
+
+// queryFormat reads the "format" query parameter, returning fallback if it is absent.
+func queryFormat(c *gin.Context, fallback bulk.Format) (bulk.Format, bool) {
+	name := c.Query("format")
+	if name == "" {
+		return fallback, fallback != ""
+	}
+	format, err := bulk.ParseFormat(name)
+	return format, err == nil
+}
+
+func accepted(c *gin.Context, job *entity.Job) {
+	c.Header("Location", "/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
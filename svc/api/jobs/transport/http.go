@@ -0,0 +1,243 @@
+// Package transport provides HTTP transport layer for the jobs API.
+package transport
+
+import (
+	"url-shorterner/internal/access"
+	"url-shorterner/internal/apikey"
+	"url-shorterner/internal/http"
+	"url-shorterner/internal/middleware"
+	"url-shorterner/internal/rate"
+	"url-shorterner/svc/jobs/app"
+	shortenerApp "url-shorterner/svc/shortener/app"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShortenJobRequest represents the request body for an asynchronous batch shorten
+//
+// swagger:model ShortenJobRequest
+type ShortenJobRequest struct {
+	// List of URLs to shorten; there is no per-request item limit
+	// required: true
+	Items []shortenerApp.BatchItem `json:"items" binding:"required"`
+}
+
+// ErrorResponse represents an error response
+//
+// swagger:model ErrorResponse
+type ErrorResponse struct {
+	// Error message describing what went wrong
+	// example: error message
+	Error string `json:"error"`
+}
+
+// JobsAPI defines the HTTP interface for asynchronous bulk job endpoints.
+type JobsAPI interface {
+	// SubmitShorten queues a batch shorten of any size
+	//
+	// swagger:operation POST /jobs/shorten jobs submitShortenJob
+	//
+	// Queues a best-effort batch shorten and returns the job.
+	//
+	// ---
+	// summary: Submit a shorten job
+	// description: |
+	//   Queues a best-effort shorten of the items, processed in batches of BATCH_MAX_SIZE by the job workers.
+	//   The result lists every item with columns `item`, `url`, `short`, `code`, `error`.
+	// tags:
+	//   - jobs
+	// consumes:
+	//   - application/json
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: body
+	//     in: body
+	//     required: true
+	//     schema:
+	//       $ref: "#/definitions/ShortenJobRequest"
+	//   - name: format
+	//     in: query
+	//     type: string
+	//     enum: [csv, jsonl]
+	//     description: Result format (default jsonl)
+	// responses:
+	//   "202":
+	//     description: Job queued
+	//     schema:
+	//       $ref: "#/definitions/Job"
+	//   "400":
+	//     description: Invalid request or upload too large
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	SubmitShorten(*gin.Context)
+
+	// SubmitImport queues an import of a CSV or JSONL upload
+	//
+	// swagger:operation POST /jobs/import jobs submitImportJob
+	//
+	// Stores the upload and queues its import.
+	//
+	// ---
+	// summary: Submit an import job
+	// description: |
+	//   Accepts the same files as `POST /links/import`, up to JOB_MAX_UPLOAD_BYTES.
+	//   The result is the import report; by default only failed records are listed.
+	// tags:
+	//   - jobs
+	// consumes:
+	//   - text/csv
+	//   - application/x-ndjson
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: format
+	//     in: query
+	//     type: string
+	//     enum: [csv, jsonl]
+	//     description: Upload format (defaults to the Content-Type)
+	//   - name: report
+	//     in: query
+	//     type: string
+	//     enum: [errors, all]
+	//     description: Report only failed records (default) or all records
+	// responses:
+	//   "202":
+	//     description: Job queued
+	//     schema:
+	//       $ref: "#/definitions/Job"
+	//   "400":
+	//     description: Unsupported format or upload too large
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	SubmitImport(*gin.Context)
+
+	// SubmitExport queues an export of all links
+	//
+	// swagger:operation POST /jobs/export jobs submitExportJob
+	//
+	// Queues an export with the columns of `GET /links/export`.
+	//
+	// ---
+	// summary: Submit an export job
+	// tags:
+	//   - jobs
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: format
+	//     in: query
+	//     type: string
+	//     enum: [csv, jsonl]
+	//     description: Result format (default csv)
+	//   - name: stats
+	//     in: query
+	//     type: boolean
+	//     description: Include aggregate click stats
+	// responses:
+	//   "202":
+	//     description: Job queued
+	//     schema:
+	//       $ref: "#/definitions/Job"
+	//   "400":
+	//     description: Unsupported format
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "401":
+	//     description: Missing or unknown API key
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	// security:
+	//   - ApiKeyAuth: []
+	SubmitExport(*gin.Context)
+
+	// GetJob returns the status and progress of a job
+	//
+	// swagger:operation GET /jobs/{id} jobs getJob
+	//
+	// Returns the status, progress counts and first errors of a job.
+	//
+	// ---
+	// summary: Get a job
+	// tags:
+	//   - jobs
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: id
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Job ID
+	// responses:
+	//   "200":
+	//     description: Job
+	//     schema:
+	//       $ref: "#/definitions/Job"
+	//   "404":
+	//     description: Job not found
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	GetJob(*gin.Context)
+
+	// GetJobResult downloads the result file of a finished job
+	//
+	// swagger:operation GET /jobs/{id}/result jobs getJobResult
+	//
+	// Streams the result file of a finished job.
+	//
+	// ---
+	// summary: Download a job result
+	// description: |
+	//   Available once the job succeeded or failed; failed jobs keep the results written before the failure.
+	//   Results of export jobs hold every link and require an API key, like `GET /links/export`; import
+	//   and shorten results only hold what their submitter sent and are available to anyone with the job ID.
+	// tags:
+	//   - jobs
+	// produces:
+	//   - text/csv
+	//   - application/x-ndjson
+	// parameters:
+	//   - name: id
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Job ID
+	// responses:
+	//   "200":
+	//     description: Result file
+	//   "401":
+	//     description: Missing or unknown API key for the result of an export job
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "404":
+	//     description: Job not found
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "409":
+	//     description: Job not finished yet
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	GetJobResult(*gin.Context)
+}
+
+// SetupRouter registers jobs API routes on the provided router.
+// maxUpload bounds the request body of submissions, in bytes. Exports and their results
+// require one of keys.
+func SetupRouter(
+	router *gin.Engine,
+	service app.Service,
+	maxUpload int64,
+	limiter rate.Limiter,
+	acl *access.List,
+	keys *apikey.Keys,
+) {
+	apiGroup := http.Router(router, "/", limiter, acl)
+
+	api := NewJobsAPI(service, maxUpload, keys)
+	apiGroup.POST("/jobs/shorten", api.SubmitShorten)
+	apiGroup.POST("/jobs/import", api.SubmitImport)
+	apiGroup.POST("/jobs/export", middleware.RequireAPIKey(keys), api.SubmitExport)
+	apiGroup.GET("/jobs/:id", api.GetJob)
+	apiGroup.GET("/jobs/:id/result", api.GetJobResult)
+}
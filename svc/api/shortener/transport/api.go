@@ -4,7 +4,12 @@ package transport
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
+	"url-shorterner/internal/apikey"
+	"url-shorterner/internal/clientip"
+	appErrors "url-shorterner/internal/errors"
+	analyticsEvents "url-shorterner/svc/analytics/events"
 	"url-shorterner/svc/shortener/app"
 
 	"github.com/gin-gonic/gin"
@@ -30,7 +35,7 @@ func (a *api) Shorten(c *gin.Context) {
 		return
 	}
 
-	resp, err := a.service.Shorten(c.Request.Context(), req.URL, req.ExpiresIn, req.Alias)
+	resp, err := a.service.Shorten(c.Request.Context(), req.URL, req.ExpiresIn, req.Alias, req.Owner, req.LinkDetails)
 	if err != nil {
 		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
 		return
@@ -48,11 +53,12 @@ func (a *api) ShortenBatch(c *gin.Context) {
 		return
 	}
 
-	results, err := a.service.ShortenBatch(c.Request.Context(), req.Items)
+	results, err := a.service.ShortenBatch(c.Request.Context(), req.Items, req.Mode)
 	if err != nil {
 		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
 		return
 	}
+	localizeBatchErrors(c, results)
 
 	c.JSON(http.StatusOK, gin.H{"results": results})
 }
@@ -67,9 +73,10 @@ func (a *api) Redirect(c *gin.Context) {
 	}
 
 	clickInfo := &app.ClickInfo{
-		IPAddress: c.ClientIP(),
+		IPAddress: clientip.FromContext(c),
 		UserAgent: c.GetHeader("User-Agent"),
 		Referer:   c.GetHeader("Referer"),
+		UTM:       analyticsEvents.UTMFromQuery(c.Request.URL.Query()),
 	}
 
 	originalURL, err := a.service.GetOriginalURL(c.Request.Context(), shortCode, clickInfo)
@@ -80,3 +87,119 @@ func (a *api) Redirect(c *gin.Context) {
 
 	c.Redirect(http.StatusMovedPermanently, originalURL)
 }
+
+// ListLinks implements ShortenerAPI.ListLinks
+// See ShortenerAPI interface in http.go for API documentation
+func (a *api) ListLinks(c *gin.Context) {
+	filter := app.LinkFilter{
+		Tags:   c.QueryArray("tag"),
+		Cursor: c.Query("cursor"),
+	}
+	if owner, ok := c.GetQuery("owner"); ok {
+		filter.Owner = &owner
+	}
+	if campaign, ok := c.GetQuery("campaign"); ok {
+		filter.CampaignID = &campaign
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit")) //nolint:errcheck // Invalid values select the default page size
+
+	page, err := a.service.ListLinks(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// ListTags implements ShortenerAPI.ListTags
+// See ShortenerAPI interface in http.go for API documentation
+func (a *api) ListTags(c *gin.Context) {
+	tags, err := a.service.ListTags(c.Request.Context())
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// GetLink implements ShortenerAPI.GetLink
+// See ShortenerAPI interface in http.go for API documentation
+func (a *api) GetLink(c *gin.Context) {
+	link, err := a.service.GetLink(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+// UpdateLink implements ShortenerAPI.UpdateLink
+// See ShortenerAPI interface in http.go for API documentation
+func (a *api) UpdateLink(c *gin.Context) {
+	var req app.LinkUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	link, err := a.service.UpdateLink(c.Request.Context(), c.Param("code"), req, actor(c))
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+// ListLinkVersions implements ShortenerAPI.ListLinkVersions
+// See ShortenerAPI interface in http.go for API documentation
+func (a *api) ListLinkVersions(c *gin.Context) {
+	versions, err := a.service.ListLinkVersions(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+// RollbackLink implements ShortenerAPI.RollbackLink
+// See ShortenerAPI interface in http.go for API documentation
+func (a *api) RollbackLink(c *gin.Context) {
+	var req RollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+
+	link, err := a.service.RollbackLink(c.Request.Context(), c.Param("code"), req.Version, actor(c))
+	if err != nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+// actor identifies who changes a link: the name of the API key the request authenticated with.
+func actor(c *gin.Context) string {
+	return apikey.FromContext(c)
+}
+
+// localizeBatchErrors translates per-item errors like ErrorHandler does for request errors.
+func localizeBatchErrors(c *gin.Context, results []app.BatchResult) {
+	lang := appErrors.GetLanguageFromContext(c)
+	for i := range results {
+		if results[i].Err == nil {
+			continue
+		}
+		results[i].Code, results[i].Error = localizeError(results[i].Err, results[i].Code, lang)
+	}
+}
+
+// localizeError returns the code and translated message of err, hiding internal errors.
+// fallbackCode is kept when err carries no code.
+func localizeError(err error, fallbackCode string, lang appErrors.Language) (string, string) {
+	code, message := appErrors.LocalizeForClient(err, lang)
+	if code == "" {
+		return fallbackCode, message
+	}
+	return string(code), message
+}
@@ -0,0 +1,198 @@
+// Package transport provides HTTP handler implementations for the shortener API.
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-shorterner/internal/bulk"
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/internal/log"
+	"url-shorterner/svc/shortener/app"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bulkFlushEvery is the number of rows written between flushes of a streamed response.
+const bulkFlushEvery = 100
+
+// Summary and error trailers, set once the body has been written.
+const (
+	trailerImportTotal   = "X-Import-Total"
+	trailerImportCreated = "X-Import-Created"
+	trailerImportFailed  = "X-Import-Failed"
+	trailerImportError   = "X-Import-Error"
+	trailerExportError   = "X-Export-Error"
+)
+
+// ImportLinks implements ShortenerAPI.ImportLinks
+// See ShortenerAPI interface in http.go for API documentation
+func (a *api) ImportLinks(c *gin.Context) {
+	format, ok := requestFormat(c, "")
+	if !ok {
+		c.Error(appErrors.Invalid(appErrors.ErrCodeImportFormat, nil)) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	reportAll := c.Query("report") == "all"
+	lang := appErrors.GetLanguageFromContext(c)
+
+	// The report is streamed while the upload is still being read, which HTTP/1 only allows in full duplex.
+	// Large uploads outlive the server's read and write timeouts.
+	rc := http.NewResponseController(c.Writer)
+	_ = rc.EnableFullDuplex()        //nolint:errcheck // HTTP/2 is always full duplex
+	rc.SetReadDeadline(time.Time{})  //nolint:errcheck // Readers without deadlines have none to lift
+	rc.SetWriteDeadline(time.Time{}) //nolint:errcheck // Writers without deadlines have none to lift
+
+	var report *streamWriter
+	start := func() error {
+		if report != nil {
+			return nil
+		}
+		c.Header("Trailer", trailerImportTotal+", "+trailerImportCreated+", "+trailerImportFailed+", "+trailerImportError)
+		var err error
+		report, err = newStreamWriter(c, format, "import-report", app.ImportReportColumns)
+		return err
+	}
+
+	opts := app.ImportOptions{}
+	summary, err := a.service.Import(c.Request.Context(), format, c.Request.Body, opts, func(result app.ImportResult) error {
+		if result.Err == nil && !reportAll {
+			return nil
+		}
+		if err := start(); err != nil {
+			return err
+		}
+		code, message := result.Code, result.Error
+		if result.Err != nil {
+			code, message = localizeError(result.Err, result.Code, lang)
+		}
+		return report.Write(result.Line, result.URL, result.Alias, result.Short, code, message)
+	})
+	if err != nil && report == nil && (summary == nil || summary.Total == 0) {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	if startErr := start(); startErr != nil {
+		log.Error("Failed to write import report: %v", startErr)
+		return
+	}
+	if flushErr := report.Close(); flushErr != nil {
+		log.Error("Failed to write import report: %v", flushErr)
+	}
+
+	if err != nil {
+		log.Error("Import aborted: %v", err)
+		_, message := localizeError(err, "", lang)
+		c.Header(trailerImportError, message)
+	}
+	if summary != nil {
+		c.Header(trailerImportTotal, strconv.Itoa(summary.Total))
+		c.Header(trailerImportCreated, strconv.Itoa(summary.Created))
+		c.Header(trailerImportFailed, strconv.Itoa(summary.Failed))
+	}
+}
+
+// ExportLinks implements ShortenerAPI.ExportLinks
+// See ShortenerAPI interface in http.go for API documentation
+func (a *api) ExportLinks(c *gin.Context) {
+	format, ok := requestFormat(c, bulk.FormatCSV)
+	if !ok {
+		c.Error(appErrors.Invalid(appErrors.ErrCodeImportFormat, nil)) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	withStats, _ := strconv.ParseBool(c.Query("stats")) //nolint:errcheck // Invalid values disable stats
+
+	columns := app.ExportColumns(withStats)
+
+	// Large exports outlive the server's write timeout.
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}) //nolint:errcheck // Writers without deadlines have none to lift
+
+	var out *streamWriter
+	err := a.service.Export(c.Request.Context(), withStats, func(link app.ExportedURL) error {
+		if out == nil {
+			c.Header("Trailer", trailerExportError)
+			var err error
+			if out, err = newStreamWriter(c, format, "links-export", columns); err != nil {
+				return err
+			}
+		}
+		return out.Write(link.Values()...)
+	})
+	if err != nil && out == nil {
+		c.Error(err) //nolint:errcheck // Error is handled by ErrorHandler middleware
+		return
+	}
+	if err != nil {
+		// Headers are already sent, so the failure is reported in a trailer.
+		log.Error("Export aborted: %v", err)
+		if flushErr := out.Close(); flushErr != nil {
+			log.Error("Failed to write export: %v", flushErr)
+		}
+		c.Header(trailerExportError, appErrors.GetMessage(appErrors.ErrCodeInternal, appErrors.GetLanguageFromContext(c)))
+		return
+	}
+	if out == nil {
+		if out, err = newStreamWriter(c, format, "links-export", columns); err != nil {
+			log.Error("Failed to write export: %v", err)
+			return
+		}
+	}
+	if err := out.Close(); err != nil {
+		log.Error("Failed to write export: %v", err)
+	}
+}
+
+// requestFormat reads the format from the "format" query parameter, then the Content-Type.
+func requestFormat(c *gin.Context, fallback bulk.Format) (bulk.Format, bool) {
+	if name := c.Query("format"); name != "" {
+		format, err := bulk.ParseFormat(name)
+		return format, err == nil
+	}
+	if format, ok := bulk.FormatFromContentType(c.ContentType()); ok {
+		return format, true
+	}
+	return fallback, fallback != ""
+}
+
+// streamWriter writes rows of a downloadable file, flushing periodically.
+type streamWriter struct {
+	c      *gin.Context
+	writer bulk.Writer
+	rows   int
+}
+
+func newStreamWriter(c *gin.Context, format bulk.Format, name string, columns []string) (*streamWriter, error) {
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	c.Status(http.StatusOK)
+
+	writer, err := bulk.NewWriter(format, c.Writer, columns)
+	if err != nil {
+		return nil, err
+	}
+	return &streamWriter{c: c, writer: writer}, nil
+}
+
+func (s *streamWriter) Write(values ...interface{}) error {
+	if err := s.writer.Write(values...); err != nil {
+		return err
+	}
+	s.rows++
+	if s.rows%bulkFlushEvery == 0 {
+		if err := s.writer.Flush(); err != nil {
+			return err
+		}
+		s.c.Writer.Flush()
+	}
+	return nil
+}
+
+func (s *streamWriter) Close() error {
+	if err := s.writer.Close(); err != nil {
+		return err
+	}
+	s.c.Writer.Flush()
+	return nil
+}
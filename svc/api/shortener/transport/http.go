@@ -2,7 +2,10 @@
 package transport
 
 import (
+	"url-shorterner/internal/access"
+	"url-shorterner/internal/apikey"
 	"url-shorterner/internal/http"
+	"url-shorterner/internal/middleware"
 	"url-shorterner/internal/rate"
 	"url-shorterner/svc/shortener/app"
 
@@ -25,6 +28,12 @@ type ShortenRequest struct {
 	// Custom alias for the shortened URL (optional, must be unique)
 	// example: my-custom-alias
 	Alias *string `json:"alias,omitempty"`
+
+	// Owner of the link (optional); part of the hash input for deterministic codes
+	// example: team-marketing
+	Owner *string `json:"owner,omitempty"`
+
+	app.LinkDetails
 }
 
 // BatchShortenRequest represents the request body for batch URL shortening
@@ -34,6 +43,20 @@ type BatchShortenRequest struct {
 	// List of URLs to shorten
 	// required: true
 	Items []app.BatchItem `json:"items" binding:"required"`
+
+	// Processing mode: "best_effort" (default, items are independent) or "atomic" (all or nothing)
+	// example: atomic
+	Mode app.BatchMode `json:"mode,omitempty"`
+}
+
+// RollbackRequest represents the request body for rolling a link back
+//
+// swagger:model RollbackRequest
+type RollbackRequest struct {
+	// The version to restore
+	// required: true
+	// example: 2
+	Version int `json:"version" binding:"required,min=1"`
 }
 
 // ErrorResponse represents an error response
@@ -66,6 +89,7 @@ type ShortenerAPI interface {
 	//   - Automatic short code generation if no alias provided
 	//   - Custom alias support (must be unique)
 	//   - Optional expiration time
+	//   - Optional title, description, tags and JSON object metadata
 	//   - URL validation and format checking
 	// tags:
 	//   - shortener
@@ -115,9 +139,10 @@ type ShortenerAPI interface {
 	//   Create shortened URLs for multiple URLs in a single request.
 	//
 	//   **Features:**
-	//   - Batch processing of multiple URLs
-	//   - Independent processing (one failure doesn't affect others)
-	//   - Detailed error reporting per URL
+	//   - Batch processing of multiple URLs, up to BATCH_MAX_SIZE items
+	//   - `best_effort` mode (default): items are processed concurrently and independently
+	//   - `atomic` mode: all items are created in one transaction, or none (`ERR_BATCH_ABORTED`)
+	//   - Detailed error reporting per URL, with an error `code` and a translated `error`
 	//   - Same validation rules as single URL endpoint
 	// tags:
 	//   - shortener
@@ -203,14 +228,364 @@ type ShortenerAPI interface {
 	//     schema:
 	//       $ref: "#/definitions/ErrorResponse"
 	Redirect(*gin.Context)
+
+	// ImportLinks creates links from an uploaded CSV or JSONL file
+	//
+	// swagger:operation POST /links/import shortener importLinks
+	//
+	// Creates links from a streamed CSV or JSONL upload and streams back a report.
+	//
+	// ---
+	// summary: Import links
+	// description: |
+	//   Creates links from a CSV (header row with `url`, `alias`, `expires_at`, `title`, `description`, `tags`,
+	//   `metadata`) or JSONL upload.
+	//
+	//   **Behavior:**
+	//   - Records are validated like `POST /shorten/batch` and created in batches of BATCH_MAX_SIZE
+	//   - `expires_at` is RFC 3339 or YYYY-MM-DD; CSV tags are separated by `;`
+	//   - The response is a downloadable report in the upload format with columns
+	//     `line`, `url`, `alias`, `short`, `code`, `error`; by default only failed records are listed
+	//   - Totals are sent in the `X-Import-Total`, `X-Import-Created` and `X-Import-Failed` trailers
+	// tags:
+	//   - shortener
+	// consumes:
+	//   - text/csv
+	//   - application/x-ndjson
+	// produces:
+	//   - text/csv
+	//   - application/x-ndjson
+	// parameters:
+	//   - name: format
+	//     in: query
+	//     type: string
+	//     enum: [csv, jsonl]
+	//     description: Upload format (defaults to the Content-Type)
+	//   - name: report
+	//     in: query
+	//     type: string
+	//     enum: [errors, all]
+	//     description: Report only failed records (default) or all records
+	// responses:
+	//   "200":
+	//     description: Import report
+	//   "400":
+	//     description: Unsupported format or invalid CSV header
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	ImportLinks(*gin.Context)
+
+	// ExportLinks streams all links as CSV or JSONL
+	//
+	// swagger:operation GET /links/export shortener exportLinks
+	//
+	// Streams all links, optionally with aggregate click stats.
+	//
+	// ---
+	// summary: Export links
+	// description: |
+	//   Streams every link in creation order as a downloadable CSV or JSONL file.
+	//
+	//   **Columns:** `short_code`, `short_url`, `url`, `canonical_url`, `owner`, `title`, `description`, `tags`,
+	//   `metadata`, `expires_at`, `created_at`, `blocked_at`, plus `total_clicks`, `unique_ips` and `last_click` with `stats=true`.
+	//   Stats count human clicks only, like `GET /analytics/{code}`.
+	// tags:
+	//   - shortener
+	// produces:
+	//   - text/csv
+	//   - application/x-ndjson
+	// parameters:
+	//   - name: format
+	//     in: query
+	//     type: string
+	//     enum: [csv, jsonl]
+	//     description: Output format (default csv)
+	//   - name: stats
+	//     in: query
+	//     type: boolean
+	//     description: Include aggregate click stats
+	// responses:
+	//   "200":
+	//     description: Export file
+	//   "400":
+	//     description: Unsupported format
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "401":
+	//     description: Missing or unknown API key
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	// security:
+	//   - ApiKeyAuth: []
+	ExportLinks(*gin.Context)
+
+	// ListLinks lists links, newest first, optionally filtered by tags and owner
+	//
+	// swagger:operation GET /links shortener listLinks
+	//
+	// Lists links with their details, newest first.
+	//
+	// ---
+	// summary: List links
+	// description: |
+	//   Returns a page of links. Pass `next_cursor` of a page as `cursor` to fetch the next one.
+	//   With several `tag` parameters only links carrying all of them are returned.
+	// tags:
+	//   - shortener
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: tag
+	//     in: query
+	//     type: array
+	//     items:
+	//       type: string
+	//     collectionFormat: multi
+	//     description: Tag the links must carry (repeatable)
+	//   - name: owner
+	//     in: query
+	//     type: string
+	//     description: Owner of the links
+	//   - name: campaign
+	//     in: query
+	//     type: string
+	//     description: ID of the campaign the links belong to
+	//   - name: limit
+	//     in: query
+	//     type: integer
+	//     description: Page size (default 50, at most 200)
+	//   - name: cursor
+	//     in: query
+	//     type: string
+	//     description: Cursor returned as next_cursor by the previous page
+	// responses:
+	//   "200":
+	//     description: Page of links
+	//     schema:
+	//       $ref: "#/definitions/LinkPage"
+	//   "400":
+	//     description: Invalid tags or cursor
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "404":
+	//     description: Campaign not found
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	ListLinks(*gin.Context)
+
+	// ListTags lists the tags in use
+	//
+	// swagger:operation GET /links/tags shortener listTags
+	//
+	// Lists every tag in use with its number of links, most used first.
+	//
+	// ---
+	// summary: List tags
+	// tags:
+	//   - shortener
+	// produces:
+	//   - application/json
+	// responses:
+	//   "200":
+	//     description: Tags with their link counts
+	//     schema:
+	//       type: object
+	//       properties:
+	//         tags:
+	//           type: array
+	//           items:
+	//             $ref: "#/definitions/TagCount"
+	ListTags(*gin.Context)
+
+	// GetLink returns a link with its details
+	//
+	// swagger:operation GET /links/{code} shortener getLink
+	//
+	// Returns the destination, title, description, tags and metadata of a link.
+	//
+	// ---
+	// summary: Get a link
+	// tags:
+	//   - shortener
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: code
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Short code of the link
+	// responses:
+	//   "200":
+	//     description: Link
+	//     schema:
+	//       $ref: "#/definitions/Link"
+	//   "404":
+	//     description: Link not found
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	GetLink(*gin.Context)
+
+	// UpdateLink changes a link
+	//
+	// swagger:operation PATCH /links/{code} shortener updateLink
+	//
+	// Changes the destination, expiry, title, description, tags or metadata of a link
+	// and records the result as a new version.
+	//
+	// ---
+	// summary: Update a link
+	// description: |
+	//   Omitted fields are left unchanged. `tags` replaces all tags; `metadata` replaces the whole object.
+	//   Requires an API key; the change is attributed to the key's name. A blocked link stays blocked
+	//   when its destination changes.
+	// tags:
+	//   - shortener
+	// consumes:
+	//   - application/json
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: code
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Short code of the link
+	//   - name: body
+	//     in: body
+	//     required: true
+	//     schema:
+	//       $ref: "#/definitions/LinkUpdate"
+	// responses:
+	//   "200":
+	//     description: Updated link
+	//     schema:
+	//       $ref: "#/definitions/Link"
+	//   "400":
+	//     description: Invalid details
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "401":
+	//     description: Missing or unknown API key
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "403":
+	//     description: Destination is blocked
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "404":
+	//     description: Link not found
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	// security:
+	//   - ApiKeyAuth: []
+	UpdateLink(*gin.Context)
+
+	// ListLinkVersions returns the version history of a link
+	//
+	// swagger:operation GET /links/{code}/versions shortener listLinkVersions
+	//
+	// Returns every recorded version of a link, newest first, with the clicks each version served.
+	//
+	// ---
+	// summary: List link versions
+	// tags:
+	//   - shortener
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: code
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Short code of the link
+	// responses:
+	//   "200":
+	//     description: Versions of the link
+	//     schema:
+	//       type: object
+	//       properties:
+	//         versions:
+	//           type: array
+	//           items:
+	//             $ref: "#/definitions/LinkVersion"
+	//   "404":
+	//     description: Link not found
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	ListLinkVersions(*gin.Context)
+
+	// RollbackLink restores an earlier version of a link
+	//
+	// swagger:operation POST /links/{code}/rollback shortener rollbackLink
+	//
+	// Restores the destination, expiry and settings of an earlier version.
+	//
+	// ---
+	// summary: Roll back a link
+	// description: |
+	//   Requires an API key; the rollback is attributed to the key's name and recorded as a new
+	//   version, so it can itself be rolled back. The restored destination is validated again and
+	//   rejected if it has been blocked since, and a version whose expiry has passed cannot be restored.
+	// tags:
+	//   - shortener
+	// consumes:
+	//   - application/json
+	// produces:
+	//   - application/json
+	// parameters:
+	//   - name: code
+	//     in: path
+	//     required: true
+	//     type: string
+	//     description: Short code of the link
+	//   - name: body
+	//     in: body
+	//     required: true
+	//     schema:
+	//       $ref: "#/definitions/RollbackRequest"
+	// responses:
+	//   "200":
+	//     description: Restored link
+	//     schema:
+	//       $ref: "#/definitions/Link"
+	//   "400":
+	//     description: The version's expiry has passed
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "401":
+	//     description: Missing or unknown API key
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "403":
+	//     description: Destination is blocked
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	//   "404":
+	//     description: Link or version not found
+	//     schema:
+	//       $ref: "#/definitions/ErrorResponse"
+	// security:
+	//   - ApiKeyAuth: []
+	RollbackLink(*gin.Context)
 }
 
 // SetupRouter registers shortener API routes on the provided router.
-func SetupRouter(router *gin.Engine, service app.Service, limiter rate.Limiter) {
-	apiGroup := http.Router(router, "/", limiter)
+// Exporting, changing and rolling back links requires one of keys; the key's name is recorded as
+// the actor of changes.
+func SetupRouter(router *gin.Engine, service app.Service, limiter rate.Limiter, acl *access.List, keys *apikey.Keys) {
+	apiGroup := http.Router(router, "/", limiter, acl)
 
 	api := NewShortenerAPI(service)
 	apiGroup.POST("/shorten", api.Shorten)
 	apiGroup.POST("/shorten/batch", api.ShortenBatch)
+	apiGroup.POST("/links/import", api.ImportLinks)
+	apiGroup.GET("/links/export", middleware.RequireAPIKey(keys), api.ExportLinks)
+	apiGroup.GET("/links", api.ListLinks)
+	apiGroup.GET("/links/tags", api.ListTags)
+	apiGroup.GET("/links/:code", api.GetLink)
+	apiGroup.PATCH("/links/:code", middleware.RequireAPIKey(keys), api.UpdateLink)
+	apiGroup.GET("/links/:code/versions", api.ListLinkVersions)
+	apiGroup.POST("/links/:code/rollback", middleware.RequireAPIKey(keys), api.RollbackLink)
 	apiGroup.GET("/:code", api.Redirect)
 }
@@ -0,0 +1,220 @@
+// Package app provides the business logic for campaigns.
+package app
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/internal/storage"
+	"url-shorterner/internal/uuid"
+	analyticsApp "url-shorterner/svc/analytics/app"
+	analyticsEntity "url-shorterner/svc/analytics/entity"
+	"url-shorterner/svc/campaigns/entity"
+	campaignsStore "url-shorterner/svc/campaigns/store"
+)
+
+const (
+	// maxNameLength matches the campaigns.name column.
+	maxNameLength = 255
+	// maxDescriptionLength bounds campaign descriptions.
+	maxDescriptionLength = 2000
+	// maxLinksPerRequest bounds the short codes added to a campaign in one request.
+	maxLinksPerRequest = 1000
+)
+
+// Service defines the interface for campaign operations.
+type Service interface {
+	CreateCampaign(ctx context.Context, name, description string) (*entity.Campaign, error)
+	GetCampaign(ctx context.Context, id string) (*entity.Campaign, error)
+	ListCampaigns(ctx context.Context) ([]*entity.Campaign, error)
+	UpdateCampaign(ctx context.Context, id string, name, description *string) (*entity.Campaign, error)
+	DeleteCampaign(ctx context.Context, id string) error
+	// AddLinks moves links into the campaign; a link belongs to at most one campaign.
+	AddLinks(ctx context.Context, id string, shortCodes []string) (*AddLinksResult, error)
+	RemoveLink(ctx context.Context, id, shortCode string) error
+	// GetAnalytics rolls up the clicks of all links of the campaign.
+	GetAnalytics(ctx context.Context, id string, query analyticsEntity.SeriesQuery) (*analyticsEntity.CampaignStats, error)
+}
+
+// AddLinksResult reports which short codes were added to a campaign
+//
+// swagger:model AddLinksResult
+type AddLinksResult struct {
+	// Short codes now in the campaign
+	Added []string `json:"added"`
+
+	// Short codes that do not exist
+	Missing []string `json:"missing"`
+}
+
+type service struct {
+	repo      campaignsStore.Repository
+	dao       campaignsStore.DAO
+	analytics analyticsApp.Service
+}
+
+// NewService creates a new campaigns service instance.
+func NewService(repo campaignsStore.Repository, dao campaignsStore.DAO, analytics analyticsApp.Service) Service {
+	return &service{
+		repo:      repo,
+		dao:       dao,
+		analytics: analytics,
+	}
+}
+
+func (s *service) CreateCampaign(ctx context.Context, name, description string) (*entity.Campaign, error) {
+	name, err := normalizeName(name)
+	if err != nil {
+		return nil, err
+	}
+	description, err = normalizeDescription(description)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	campaign := &entity.Campaign{
+		ID:          uuid.Generate(),
+		Name:        name,
+		Description: description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.CreateCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+func (s *service) GetCampaign(ctx context.Context, id string) (*entity.Campaign, error) {
+	if !uuid.Valid(id) {
+		return nil, appErrors.NotFound(appErrors.ResourceCampaign)
+	}
+	campaign, err := s.dao.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return campaign, nil
+}
+
+func (s *service) ListCampaigns(ctx context.Context) ([]*entity.Campaign, error) {
+	return s.dao.ListCampaigns(ctx)
+}
+
+func (s *service) UpdateCampaign(ctx context.Context, id string, name, description *string) (*entity.Campaign, error) {
+	if !uuid.Valid(id) {
+		return nil, appErrors.NotFound(appErrors.ResourceCampaign)
+	}
+	var update entity.CampaignUpdate
+	if name != nil {
+		n, err := normalizeName(*name)
+		if err != nil {
+			return nil, err
+		}
+		update.Name = &n
+	}
+	if description != nil {
+		d, err := normalizeDescription(*description)
+		if err != nil {
+			return nil, err
+		}
+		update.Description = &d
+	}
+
+	campaign, err := s.repo.UpdateCampaign(ctx, id, update)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return campaign, nil
+}
+
+func (s *service) DeleteCampaign(ctx context.Context, id string) error {
+	if !uuid.Valid(id) {
+		return appErrors.NotFound(appErrors.ResourceCampaign)
+	}
+	return notFound(s.repo.DeleteCampaign(ctx, id))
+}
+
+func (s *service) AddLinks(ctx context.Context, id string, shortCodes []string) (*AddLinksResult, error) {
+	if !uuid.Valid(id) {
+		return nil, appErrors.NotFound(appErrors.ResourceCampaign)
+	}
+	if len(shortCodes) > maxLinksPerRequest {
+		return nil, appErrors.Invalid(appErrors.ErrCodeBatchTooLarge, map[string]interface{}{"Max": maxLinksPerRequest})
+	}
+
+	codes := make([]string, 0, len(shortCodes))
+	for _, code := range shortCodes {
+		if code = strings.TrimSpace(code); code != "" && !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+
+	added, err := s.repo.AddLinks(ctx, id, codes)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	result := &AddLinksResult{Added: make([]string, 0, len(added)), Missing: []string{}}
+	for _, code := range codes {
+		if slices.Contains(added, code) {
+			result.Added = append(result.Added, code)
+		} else {
+			result.Missing = append(result.Missing, code)
+		}
+	}
+	return result, nil
+}
+
+func (s *service) RemoveLink(ctx context.Context, id, shortCode string) error {
+	if !uuid.Valid(id) {
+		return appErrors.NotFound(appErrors.ResourceCampaign)
+	}
+	return notFoundAs(s.repo.RemoveLink(ctx, id, shortCode), appErrors.ResourceURL)
+}
+
+func (s *service) GetAnalytics(
+	ctx context.Context,
+	id string,
+	query analyticsEntity.SeriesQuery,
+) (*analyticsEntity.CampaignStats, error) {
+	if _, err := s.GetCampaign(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.analytics.GetCampaignStats(ctx, id, query)
+}
+
+// normalizeName trims a campaign name and checks its length.
+func normalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return "", appErrors.Invalid(appErrors.ErrCodeInvalidCampaign, map[string]interface{}{"Max": maxNameLength})
+	}
+	return name, nil
+}
+
+// normalizeDescription trims a campaign description and checks its length.
+func normalizeDescription(description string) (string, error) {
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		return "", appErrors.Invalid(appErrors.ErrCodeDescriptionTooLong, map[string]interface{}{"Max": maxDescriptionLength})
+	}
+	return description, nil
+}
+
+func notFound(err error) error {
+	return notFoundAs(err, appErrors.ResourceCampaign)
+}
+
+// notFoundAs maps storage.ErrNotFound to a not found error for resource.
+func notFoundAs(err error, resource string) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return appErrors.NotFound(resource)
+	}
+	return err
+}
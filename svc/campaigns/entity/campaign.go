@@ -0,0 +1,23 @@
+// Package entity defines domain entities for the campaigns service.
+package entity
+
+import "time"
+
+// Campaign is a folder grouping links whose analytics are rolled up together.
+//
+// swagger:model Campaign
+type Campaign struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Links is the number of links in the campaign.
+	Links     int64     `json:"links"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CampaignUpdate changes a campaign; nil fields are left unchanged.
+type CampaignUpdate struct {
+	Name        *string
+	Description *string
+}
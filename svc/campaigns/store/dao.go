@@ -0,0 +1,80 @@
+// Package store provides DAO implementations for the campaigns domain.
+package store
+
+import (
+	"context"
+	"errors"
+
+	"url-shorterner/internal/storage"
+	"url-shorterner/svc/campaigns/entity"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DAO defines the data access interface for campaign read operations.
+type DAO interface {
+	GetCampaign(ctx context.Context, id string) (*entity.Campaign, error)
+	// ListCampaigns returns all campaigns, newest first.
+	ListCampaigns(ctx context.Context) ([]*entity.Campaign, error)
+}
+
+// campaignColumns are the columns scanned by scanCampaign, selected from campaigns aliased as c.
+const campaignColumns = `
+	c.id, c.name, c.description,
+	(SELECT COUNT(*) FROM urls u WHERE u.campaign_id = c.id),
+	c.created_at, c.updated_at
+`
+
+type dao struct {
+	db *pgxpool.Pool
+}
+
+// NewDAO creates a new campaigns DAO instance.
+func NewDAO(db *pgxpool.Pool) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) GetCampaign(ctx context.Context, id string) (*entity.Campaign, error) {
+	query := `SELECT ` + campaignColumns + ` FROM campaigns c WHERE c.id = @id`
+	campaign, err := scanCampaign(d.db.QueryRow(ctx, query, pgx.NamedArgs{"id": id}))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	return campaign, err
+}
+
+func (d *dao) ListCampaigns(ctx context.Context) ([]*entity.Campaign, error) {
+	query := `SELECT ` + campaignColumns + ` FROM campaigns c ORDER BY c.created_at DESC, c.id`
+	rows, err := d.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	campaigns := make([]*entity.Campaign, 0)
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, campaign)
+	}
+	return campaigns, rows.Err()
+}
+
+func scanCampaign(row pgx.Row) (*entity.Campaign, error) {
+	var campaign entity.Campaign
+	err := row.Scan(
+		&campaign.ID,
+		&campaign.Name,
+		&campaign.Description,
+		&campaign.Links,
+		&campaign.CreatedAt,
+		&campaign.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
@@ -0,0 +1,125 @@
+// Package store provides repository implementations for the campaigns domain.
+package store
+
+import (
+	"context"
+	"errors"
+
+	"url-shorterner/internal/storage"
+	"url-shorterner/svc/campaigns/entity"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository defines the interface for campaign write operations.
+type Repository interface {
+	CreateCampaign(ctx context.Context, campaign *entity.Campaign) error
+	// UpdateCampaign applies update and returns the updated campaign, or storage.ErrNotFound.
+	UpdateCampaign(ctx context.Context, id string, update entity.CampaignUpdate) (*entity.Campaign, error)
+	// DeleteCampaign deletes a campaign; its links are kept and leave the campaign.
+	DeleteCampaign(ctx context.Context, id string) error
+	// AddLinks moves the links with the given short codes into the campaign and returns the codes that were found.
+	AddLinks(ctx context.Context, id string, shortCodes []string) ([]string, error)
+	// RemoveLink takes a link out of the campaign, or returns storage.ErrNotFound if it is not a member.
+	RemoveLink(ctx context.Context, id, shortCode string) error
+}
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+// NewRepository creates a new campaigns repository instance.
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateCampaign(ctx context.Context, campaign *entity.Campaign) error {
+	query := `
+		INSERT INTO campaigns (id, name, description, created_at, updated_at)
+		VALUES (@id, @name, @description, @created_at, @updated_at)
+	`
+	args := pgx.NamedArgs{
+		"id":          campaign.ID,
+		"name":        campaign.Name,
+		"description": campaign.Description,
+		"created_at":  campaign.CreatedAt,
+		"updated_at":  campaign.UpdatedAt,
+	}
+	_, err := r.db.Exec(ctx, query, args)
+	return err
+}
+
+func (r *repository) UpdateCampaign(ctx context.Context, id string, update entity.CampaignUpdate) (*entity.Campaign, error) {
+	query := `
+		UPDATE campaigns c SET
+			name = COALESCE(@name, c.name),
+			description = COALESCE(@description, c.description),
+			updated_at = NOW()
+		WHERE c.id = @id
+		RETURNING ` + campaignColumns
+	args := pgx.NamedArgs{
+		"id":          id,
+		"name":        update.Name,
+		"description": update.Description,
+	}
+	campaign, err := scanCampaign(r.db.QueryRow(ctx, query, args))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	return campaign, err
+}
+
+func (r *repository) DeleteCampaign(ctx context.Context, id string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM campaigns WHERE id = @id`, pgx.NamedArgs{"id": id})
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) AddLinks(ctx context.Context, id string, shortCodes []string) ([]string, error) {
+	// Locking the campaign row keeps a concurrent delete from leaving links pointing at it.
+	var added []string
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var locked string
+		err := tx.QueryRow(ctx, `SELECT id FROM campaigns WHERE id = @id FOR SHARE`, pgx.NamedArgs{"id": id}).Scan(&locked)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE urls SET campaign_id = @id, updated_at = NOW()
+			WHERE short_code = ANY(@short_codes)
+			RETURNING short_code
+		`
+		rows, err := tx.Query(ctx, query, pgx.NamedArgs{"id": id, "short_codes": shortCodes})
+		if err != nil {
+			return err
+		}
+		added, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		return err
+	})
+	return added, err
+}
+
+func (r *repository) RemoveLink(ctx context.Context, id, shortCode string) error {
+	query := `
+		UPDATE urls SET campaign_id = NULL, updated_at = NOW()
+		WHERE short_code = @short_code AND campaign_id = @id
+	`
+	tag, err := r.db.Exec(ctx, query, pgx.NamedArgs{"id": id, "short_code": shortCode})
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Package app provides the business logic for asynchronous bulk jobs.
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"url-shorterner/internal/storage"
+	"url-shorterner/svc/jobs/entity"
+)
+
+// chunkReader reads a stored job file chunk by chunk, so large files are never held in memory.
+type chunkReader struct {
+	ctx  context.Context
+	id   string
+	kind entity.FileKind
+	read func(ctx context.Context, id string, kind entity.FileKind, seq int) ([]byte, error)
+	seq  int
+	buf  []byte
+}
+
+func newChunkReader(
+	ctx context.Context,
+	id string,
+	kind entity.FileKind,
+	read func(ctx context.Context, id string, kind entity.FileKind, seq int) ([]byte, error),
+) io.Reader {
+	return &chunkReader{ctx: ctx, id: id, kind: kind, read: read}
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for len(c.buf) == 0 {
+		data, err := c.read(c.ctx, c.id, c.kind, c.seq)
+		if errors.Is(err, storage.ErrNotFound) {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		c.buf = data
+		c.seq++
+	}
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
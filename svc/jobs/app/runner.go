@@ -0,0 +1,263 @@
+// Package app provides the business logic for asynchronous bulk jobs.
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"url-shorterner/internal/bulk"
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/svc/jobs/entity"
+	jobsStore "url-shorterner/svc/jobs/store"
+	shortenerApp "url-shorterner/svc/shortener/app"
+)
+
+const (
+	// maxJobErrors bounds the failed items listed in a job; the result file lists them all.
+	maxJobErrors = 100
+	// checkpointEvery is the number of processed items between progress checkpoints.
+	checkpointEvery = 500
+	// maxShortenItemSize bounds a single stored shorten item.
+	maxShortenItemSize = 1024 * 1024
+)
+
+// shortenResultColumns are the columns of a shorten job result.
+var shortenResultColumns = []string{"item", "url", "short", "code", "error"}
+
+// jobRun accumulates results and progress between checkpoints. Results are appended to the
+// job's result file together with the progress, so a resumed job continues the file seamlessly.
+type jobRun struct {
+	pool     *WorkerPool
+	job      *entity.Job
+	params   jobParams
+	progress entity.JobProgress
+	out      bytes.Buffer
+	writer   bulk.Writer
+	errs     []entity.JobError
+	// recorded is the number of errors already stored with the job
+	recorded int
+	// pending is the number of items processed since the last checkpoint
+	pending int
+}
+
+func parseParams(job *entity.Job) (jobParams, error) {
+	params := jobParams{Language: appErrors.DefaultLanguage}
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return params, fmt.Errorf("invalid job params: %w", err)
+	}
+	return params, nil
+}
+
+// execute runs a claimed job to completion, resuming after its checkpoint.
+func (p *WorkerPool) execute(ctx context.Context, job *entity.Job, params jobParams) error {
+	run := &jobRun{
+		pool:   p,
+		job:    job,
+		params: params,
+		progress: entity.JobProgress{
+			Total:      job.Total,
+			Processed:  job.Processed,
+			Succeeded:  job.Succeeded,
+			Failed:     job.Failed,
+			Checkpoint: job.Checkpoint,
+		},
+		recorded: len(job.Errors),
+	}
+
+	switch job.Type {
+	case entity.JobTypeShorten:
+		return run.shorten(ctx)
+	case entity.JobTypeImport:
+		return run.importLinks(ctx)
+	case entity.JobTypeExport:
+		return run.export(ctx)
+	default:
+		return fmt.Errorf("unknown job type %q", job.Type)
+	}
+}
+
+// shorten shortens the stored items in best-effort batches, checkpointing after every batch.
+func (r *jobRun) shorten(ctx context.Context) error {
+	if err := r.openWriter(shortenResultColumns); err != nil {
+		return err
+	}
+
+	input := newChunkReader(ctx, r.job.ID, entity.FileInput, r.pool.repo.ReadFileChunk)
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxShortenItemSize)
+
+	batchSize := max(r.pool.opts.BatchSize, 1)
+	batch := make([]shortenerApp.BatchItem, 0, batchSize)
+	index := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		results, err := r.pool.shortener.ShortenBatch(ctx, batch, shortenerApp.BatchModeBestEffort)
+		if err != nil {
+			return err
+		}
+		first := index - len(batch) + 1
+		for i, result := range results {
+			code, message := result.Code, result.Error
+			if result.Err != nil {
+				code, message = r.record(first+i, result.URL, result.Err, result.Code)
+			} else {
+				r.succeed()
+			}
+			if err := r.writer.Write(first+i, result.URL, result.Short, code, message); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return r.checkpoint(ctx, index)
+	}
+
+	for scanner.Scan() {
+		index++
+		if index <= r.job.Checkpoint {
+			continue
+		}
+		var item shortenerApp.BatchItem
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			return fmt.Errorf("invalid stored item %d: %w", index, err)
+		}
+		batch = append(batch, item)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	// A final checkpoint also stores the CSV header of an empty result.
+	return r.checkpoint(ctx, index)
+}
+
+// importLinks imports the stored upload, skipping the lines before the checkpoint.
+func (r *jobRun) importLinks(ctx context.Context) error {
+	if err := r.openWriter(shortenerApp.ImportReportColumns); err != nil {
+		return err
+	}
+
+	input := newChunkReader(ctx, r.job.ID, entity.FileInput, r.pool.repo.ReadFileChunk)
+	opts := shortenerApp.ImportOptions{ResumeAfter: r.job.Checkpoint}
+	lastLine := r.job.Checkpoint
+	_, err := r.pool.shortener.Import(ctx, r.params.Format, input, opts, func(result shortenerApp.ImportResult) error {
+		code, message := result.Code, result.Error
+		if result.Err != nil {
+			code, message = r.record(result.Line, result.URL, result.Err, result.Code)
+		} else {
+			r.succeed()
+		}
+		if result.Err != nil || r.params.ReportAll {
+			if err := r.writer.Write(result.Line, result.URL, result.Alias, result.Short, code, message); err != nil {
+				return err
+			}
+		}
+		lastLine = result.Line
+		return r.maybeCheckpoint(ctx, result.Line)
+	})
+	if err != nil {
+		return err
+	}
+	r.progress.Total = r.progress.Processed
+	return r.checkpoint(ctx, lastLine)
+}
+
+// export writes every link to the result. Exports cannot resume, so a retried export starts over.
+func (r *jobRun) export(ctx context.Context) error {
+	if r.job.ResultChunks > 0 || r.job.Processed > 0 {
+		if err := r.pool.repo.ResetProgress(ctx, r.job.ID, r.pool.id); err != nil {
+			return err
+		}
+		r.job.ResultChunks = 0
+		r.progress = entity.JobProgress{}
+		r.recorded = 0
+	}
+	if err := r.openWriter(shortenerApp.ExportColumns(r.params.WithStats)); err != nil {
+		return err
+	}
+
+	err := r.pool.shortener.Export(ctx, r.params.WithStats, func(link shortenerApp.ExportedURL) error {
+		if err := r.writer.Write(link.Values()...); err != nil {
+			return err
+		}
+		r.succeed()
+		return r.maybeCheckpoint(ctx, r.progress.Processed)
+	})
+	if err != nil {
+		return err
+	}
+	r.progress.Total = r.progress.Processed
+	return r.checkpoint(ctx, r.progress.Processed)
+}
+
+// openWriter creates the result writer. The CSV header is dropped when resuming a result that already has one.
+func (r *jobRun) openWriter(columns []string) error {
+	writer, err := bulk.NewWriter(bulk.Format(r.job.ResultFormat), &r.out, columns)
+	if err != nil {
+		return err
+	}
+	if r.job.ResultChunks > 0 {
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		r.out.Reset()
+	}
+	r.writer = writer
+	return nil
+}
+
+func (r *jobRun) succeed() {
+	r.progress.Processed++
+	r.progress.Succeeded++
+	r.pending++
+}
+
+// record counts a failed item and returns its code and message in the job's language.
+func (r *jobRun) record(item int, url string, err error, fallbackCode string) (string, string) {
+	r.progress.Processed++
+	r.progress.Failed++
+	r.pending++
+
+	code, message := appErrors.LocalizeForClient(err, r.params.Language)
+	if code == "" {
+		code = appErrors.ErrorCode(fallbackCode)
+	}
+	if r.recorded+len(r.errs) < maxJobErrors {
+		r.errs = append(r.errs, entity.JobError{Item: item, URL: url, Code: string(code), Error: message})
+	}
+	return string(code), message
+}
+
+func (r *jobRun) maybeCheckpoint(ctx context.Context, position int) error {
+	if r.pending < checkpointEvery && r.out.Len() < jobsStore.FileChunkSize {
+		return nil
+	}
+	return r.checkpoint(ctx, position)
+}
+
+// checkpoint saves progress, new errors and buffered results up to position.
+func (r *jobRun) checkpoint(ctx context.Context, position int) error {
+	if err := r.writer.Flush(); err != nil {
+		return err
+	}
+	r.progress.Checkpoint = position
+	if err := r.pool.repo.SaveProgress(ctx, r.job.ID, r.pool.id, r.progress, r.errs, r.out.Bytes()); err != nil {
+		return err
+	}
+	r.recorded += len(r.errs)
+	r.errs = nil
+	r.out.Reset()
+	r.pending = 0
+	return nil
+}
@@ -0,0 +1,152 @@
+// Package app provides the business logic for asynchronous bulk jobs.
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"url-shorterner/internal/bulk"
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/internal/storage"
+	"url-shorterner/internal/uuid"
+	"url-shorterner/svc/jobs/entity"
+	jobsStore "url-shorterner/svc/jobs/store"
+	shortenerApp "url-shorterner/svc/shortener/app"
+)
+
+// Service defines the interface for submitting and inspecting jobs.
+type Service interface {
+	// SubmitShorten queues a best-effort shorten of items; the result lists every item.
+	SubmitShorten(ctx context.Context, items []shortenerApp.BatchItem, format bulk.Format, lang appErrors.Language) (*entity.Job, error)
+	// SubmitImport stores the upload read from r and queues its import.
+	SubmitImport(ctx context.Context, format bulk.Format, r io.Reader, reportAll bool, lang appErrors.Language) (*entity.Job, error)
+	// SubmitExport queues an export of all links.
+	SubmitExport(ctx context.Context, format bulk.Format, withStats bool, lang appErrors.Language) (*entity.Job, error)
+	GetJob(ctx context.Context, id string) (*entity.Job, error)
+	// OpenResult returns a finished job and a reader over its result file.
+	OpenResult(ctx context.Context, id string) (*entity.Job, io.Reader, error)
+}
+
+// jobParams are the type-specific options stored with a job.
+type jobParams struct {
+	Format    bulk.Format `json:"format"`
+	WithStats bool        `json:"with_stats,omitempty"`
+	ReportAll bool        `json:"report_all,omitempty"`
+	// Language is the language of the submitting request, used for error messages.
+	Language appErrors.Language `json:"language,omitempty"`
+}
+
+type service struct {
+	repo    jobsStore.Repository
+	dao     jobsStore.DAO
+	workers *WorkerPool
+}
+
+// NewService creates a new jobs service instance. Submitted jobs wake workers, if this replica runs any.
+func NewService(repo jobsStore.Repository, dao jobsStore.DAO, workers *WorkerPool) Service {
+	return &service{
+		repo:    repo,
+		dao:     dao,
+		workers: workers,
+	}
+}
+
+func (s *service) SubmitShorten(
+	ctx context.Context,
+	items []shortenerApp.BatchItem,
+	format bulk.Format,
+	lang appErrors.Language,
+) (*entity.Job, error) {
+	// Items are stored as JSONL so the worker can stream them and resume by index.
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			return nil, err
+		}
+	}
+	job := newJob(entity.JobTypeShorten, format)
+	job.Total = len(items)
+	return s.submit(ctx, job, jobParams{Format: format, Language: lang}, &input)
+}
+
+func (s *service) SubmitImport(
+	ctx context.Context,
+	format bulk.Format,
+	r io.Reader,
+	reportAll bool,
+	lang appErrors.Language,
+) (*entity.Job, error) {
+	job := newJob(entity.JobTypeImport, format)
+	return s.submit(ctx, job, jobParams{Format: format, ReportAll: reportAll, Language: lang}, r)
+}
+
+func (s *service) SubmitExport(
+	ctx context.Context,
+	format bulk.Format,
+	withStats bool,
+	lang appErrors.Language,
+) (*entity.Job, error) {
+	job := newJob(entity.JobTypeExport, format)
+	return s.submit(ctx, job, jobParams{Format: format, WithStats: withStats, Language: lang}, nil)
+}
+
+func (s *service) submit(ctx context.Context, job *entity.Job, params jobParams, input io.Reader) (*entity.Job, error) {
+	var err error
+	if job.Params, err = json.Marshal(params); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateJob(ctx, job, input); err != nil {
+		return nil, err
+	}
+	if s.workers != nil {
+		s.workers.Wake()
+	}
+	return job, nil
+}
+
+func (s *service) GetJob(ctx context.Context, id string) (*entity.Job, error) {
+	if !uuid.Valid(id) {
+		return nil, appErrors.NotFound(appErrors.ResourceJob)
+	}
+	job, err := s.dao.GetJob(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, appErrors.NotFound(appErrors.ResourceJob)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if job.Errors == nil {
+		job.Errors = []entity.JobError{}
+	}
+	return job, nil
+}
+
+func (s *service) OpenResult(ctx context.Context, id string) (*entity.Job, io.Reader, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Failed jobs keep the results written up to their last checkpoint.
+	if !job.Finished() {
+		return nil, nil, appErrors.Conflict(appErrors.ErrCodeJobNotReady, map[string]interface{}{"Status": string(job.Status)})
+	}
+	return job, newChunkReader(ctx, job.ID, entity.FileResult, s.dao.ReadFileChunk), nil
+}
+
+func newJob(jobType entity.JobType, format bulk.Format) *entity.Job {
+	now := time.Now().UTC()
+	return &entity.Job{
+		ID:           uuid.Generate(),
+		Type:         jobType,
+		Status:       entity.JobStatusQueued,
+		ResultFormat: string(format),
+		Errors:       []entity.JobError{},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
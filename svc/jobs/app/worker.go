@@ -0,0 +1,237 @@
+// Package app provides the business logic for asynchronous bulk jobs.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/internal/log"
+	"url-shorterner/internal/storage"
+	"url-shorterner/internal/uuid"
+	"url-shorterner/svc/jobs/entity"
+	jobsStore "url-shorterner/svc/jobs/store"
+	shortenerApp "url-shorterner/svc/shortener/app"
+)
+
+const (
+	// maintenanceInterval is how often abandoned jobs are failed and expired jobs deleted.
+	maintenanceInterval = time.Minute
+	// releaseTimeout bounds handing a job back to the queue on shutdown.
+	releaseTimeout = 5 * time.Second
+)
+
+// WorkerOptions configures the job worker pool.
+type WorkerOptions struct {
+	// Workers is the number of jobs run concurrently by this replica; 0 disables job execution.
+	Workers int
+	// PollInterval is how often idle workers look for queued jobs.
+	PollInterval time.Duration
+	// Lease is how long a job stays claimed without a heartbeat before another worker may take it over.
+	Lease time.Duration
+	// MaxAttempts is the number of times a job is claimed before it is failed as abandoned.
+	MaxAttempts int
+	// Retention is how long finished jobs and their files are kept.
+	Retention time.Duration
+	// BatchSize is the number of items shortened per batch, at most the shortener's batch limit.
+	BatchSize int
+}
+
+// WorkerPool runs queued jobs. Jobs are leased in Postgres, so any replica can run them and a job
+// whose worker died is taken over, resuming from its last checkpoint, once the lease expires.
+type WorkerPool struct {
+	repo      jobsStore.Repository
+	shortener shortenerApp.Service
+	opts      WorkerOptions
+	id        string
+	wake      chan struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWorkerPool creates a worker pool; call Start to run it.
+func NewWorkerPool(repo jobsStore.Repository, shortener shortenerApp.Service, opts WorkerOptions) *WorkerPool {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	return &WorkerPool{
+		repo:      repo,
+		shortener: shortener,
+		opts:      opts,
+		id:        fmt.Sprintf("%s-%s", host, uuid.Generate()),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Start runs the workers until ctx is canceled or Stop is called.
+func (p *WorkerPool) Start(ctx context.Context) {
+	if p.opts.Workers <= 0 {
+		return
+	}
+	ctx, p.cancel = context.WithCancel(ctx)
+
+	for i := 0; i < p.opts.Workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.work(ctx)
+		}()
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.maintain(ctx)
+	}()
+}
+
+// Stop stops the workers and waits for them; running jobs are put back in the queue.
+func (p *WorkerPool) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+}
+
+// Wake makes an idle worker look for jobs immediately.
+func (p *WorkerPool) Wake() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *WorkerPool) work(ctx context.Context) {
+	ticker := time.NewTicker(p.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		// Drain the queue before going idle.
+		for ctx.Err() == nil {
+			job, err := p.repo.ClaimJob(ctx, p.id, p.opts.Lease, p.opts.MaxAttempts)
+			if errors.Is(err, storage.ErrNotFound) {
+				break
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Error("Failed to claim job: %v", err)
+				}
+				break
+			}
+			p.run(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// run executes a claimed job while renewing its lease, then records the outcome.
+func (p *WorkerPool) run(ctx context.Context, job *entity.Job) {
+	log.Info("Running %s job %s (attempt %d)", job.Type, job.ID, job.Attempts)
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	var leaseLost bool
+	var heartbeat sync.WaitGroup
+	heartbeat.Add(1)
+	go func() {
+		defer heartbeat.Done()
+		ticker := time.NewTicker(p.opts.Lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+				err := p.repo.ExtendLease(jobCtx, job.ID, p.id, p.opts.Lease)
+				if errors.Is(err, jobsStore.ErrLeaseLost) {
+					leaseLost = true
+					cancel()
+					return
+				}
+				if err != nil && jobCtx.Err() == nil {
+					log.Warn("Failed to extend lease of job %s: %v", job.ID, err)
+				}
+			}
+		}
+	}()
+
+	params, err := parseParams(job)
+	if err == nil {
+		err = p.executeRecovered(jobCtx, job, params)
+	}
+	cancel()
+	heartbeat.Wait()
+
+	switch {
+	case leaseLost || errors.Is(err, jobsStore.ErrLeaseLost):
+		log.Warn("Lost lease of job %s; another worker took it over", job.ID)
+	case ctx.Err() != nil:
+		releaseCtx, cancelRelease := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+		defer cancelRelease()
+		if err := p.repo.ReleaseJob(releaseCtx, job.ID, p.id); err != nil {
+			log.Error("Failed to release job %s: %v", job.ID, err)
+		}
+	case err != nil:
+		log.Error("Job %s failed: %v", job.ID, err)
+		_, message := appErrors.LocalizeForClient(err, params.Language)
+		p.finish(ctx, job, entity.JobStatusFailed, message)
+	default:
+		log.Info("Job %s succeeded", job.ID)
+		p.finish(ctx, job, entity.JobStatusSucceeded, "")
+	}
+}
+
+// executeRecovered runs execute, turning a panic into an error so a poison job is failed
+// instead of crashing the worker and being taken over again once its lease expires.
+func (p *WorkerPool) executeRecovered(ctx context.Context, job *entity.Job, params jobParams) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("Job %s panicked: %v\n%s", job.ID, r, debug.Stack())
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return p.execute(ctx, job, params)
+}
+
+func (p *WorkerPool) finish(ctx context.Context, job *entity.Job, status entity.JobStatus, message string) {
+	if err := p.repo.FinishJob(ctx, job.ID, p.id, status, message); err != nil {
+		log.Error("Failed to finish job %s: %v", job.ID, err)
+	}
+}
+
+// maintain periodically fails jobs abandoned by crashed workers and deletes expired jobs.
+func (p *WorkerPool) maintain(ctx context.Context) {
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		message := appErrors.GetMessage(appErrors.ErrCodeJobAbandoned, appErrors.DefaultLanguage)
+		if n, err := p.repo.FailAbandonedJobs(ctx, p.opts.MaxAttempts, message); err != nil {
+			log.Error("Failed to fail abandoned jobs: %v", err)
+		} else if n > 0 {
+			log.Warn("Failed %d abandoned jobs", n)
+		}
+
+		if p.opts.Retention > 0 {
+			if _, err := p.repo.DeleteFinishedJobs(ctx, time.Now().Add(-p.opts.Retention)); err != nil {
+				log.Error("Failed to delete expired jobs: %v", err)
+			}
+		}
+	}
+}
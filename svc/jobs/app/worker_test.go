@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"url-shorterner/internal/storage"
+	"url-shorterner/svc/jobs/entity"
+	jobsStore "url-shorterner/svc/jobs/store"
+	shortenerApp "url-shorterner/svc/shortener/app"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// finishRecorder is a job repository that serves one input chunk and records how jobs finish.
+type finishRecorder struct {
+	jobsStore.Repository
+	input    []byte
+	finished map[string]entity.JobStatus
+}
+
+func (r *finishRecorder) ExtendLease(context.Context, string, string, time.Duration) error {
+	return nil
+}
+
+func (r *finishRecorder) ReadFileChunk(_ context.Context, _ string, _ entity.FileKind, seq int) ([]byte, error) {
+	if seq > 0 {
+		return nil, storage.ErrNotFound
+	}
+	return r.input, nil
+}
+
+func (r *finishRecorder) FinishJob(_ context.Context, id, _ string, status entity.JobStatus, _ string) error {
+	r.finished[id] = status
+	return nil
+}
+
+// panickingShortener panics on every batch.
+type panickingShortener struct {
+	shortenerApp.Service
+}
+
+func (panickingShortener) ShortenBatch(context.Context, []shortenerApp.BatchItem, shortenerApp.BatchMode) ([]shortenerApp.BatchResult, error) {
+	panic("boom")
+}
+
+func TestWorkerPoolFailsPanickingJob(t *testing.T) {
+	repo := &finishRecorder{
+		input:    []byte(`{"url":"https://example.com/"}` + "\n"),
+		finished: make(map[string]entity.JobStatus),
+	}
+	pool := NewWorkerPool(repo, panickingShortener{}, WorkerOptions{Workers: 1, Lease: time.Minute, BatchSize: 10})
+
+	job := &entity.Job{
+		ID:           "job-1",
+		Type:         entity.JobTypeShorten,
+		Params:       []byte(`{}`),
+		ResultFormat: "csv",
+	}
+	require.NotPanics(t, func() { pool.run(context.Background(), job) })
+	assert.Equal(t, entity.JobStatusFailed, repo.finished[job.ID])
+}
@@ -0,0 +1,97 @@
+// Package entity defines domain entities for the jobs service.
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JobType identifies the bulk operation a job runs.
+type JobType string
+
+const (
+	// JobTypeShorten shortens a list of batch items.
+	JobTypeShorten JobType = "shorten"
+	// JobTypeImport imports links from an uploaded CSV or JSONL file.
+	JobTypeImport JobType = "import"
+	// JobTypeExport exports all links to a CSV or JSONL file.
+	JobTypeExport JobType = "export"
+)
+
+// JobStatus is the lifecycle state of a job.
+type JobStatus string
+
+const (
+	// JobStatusQueued jobs wait for a worker.
+	JobStatusQueued JobStatus = "queued"
+	// JobStatusRunning jobs are leased by a worker.
+	JobStatusRunning JobStatus = "running"
+	// JobStatusSucceeded jobs finished; their result is available for download.
+	JobStatusSucceeded JobStatus = "succeeded"
+	// JobStatusFailed jobs stopped with Error set.
+	JobStatusFailed JobStatus = "failed"
+)
+
+// FileKind distinguishes the stored files of a job.
+type FileKind string
+
+const (
+	// FileInput is the uploaded input of shorten and import jobs.
+	FileInput FileKind = "input"
+	// FileResult is the downloadable result.
+	FileResult FileKind = "result"
+)
+
+// Job is a persisted bulk operation.
+//
+// swagger:model Job
+type Job struct {
+	ID     string    `json:"id"`
+	Type   JobType   `json:"type"`
+	Status JobStatus `json:"status"`
+	// Params holds the type-specific options given at submission.
+	Params json.RawMessage `json:"-"`
+	// ResultFormat is the format of the result file (csv or jsonl).
+	ResultFormat string `json:"result_format"`
+	// Total is the number of items to process; 0 while unknown (imports and exports).
+	Total     int `json:"total"`
+	Processed int `json:"processed"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	// Checkpoint is the last input position whose results are persisted; a reclaimed job resumes after it.
+	Checkpoint int `json:"-"`
+	// ResultChunks is the number of result chunks written so far.
+	ResultChunks int `json:"-"`
+	// Errors lists the first failed items.
+	Errors []JobError `json:"errors"`
+	// Error is set when the whole job failed.
+	Error      string     `json:"error,omitempty"`
+	Attempts   int        `json:"attempts"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// Finished reports whether the job reached a final status.
+func (j *Job) Finished() bool {
+	return j.Status == JobStatusSucceeded || j.Status == JobStatusFailed
+}
+
+// JobError describes a failed item of a job.
+type JobError struct {
+	// Item is the input line for imports and the 1-based item index for shorten jobs.
+	Item  int    `json:"item"`
+	URL   string `json:"url"`
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// JobProgress is the progress saved at a checkpoint.
+type JobProgress struct {
+	Total      int
+	Processed  int
+	Succeeded  int
+	Failed     int
+	Checkpoint int
+}
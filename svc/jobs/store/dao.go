@@ -0,0 +1,35 @@
+// Package store provides DAO implementations for the jobs domain.
+package store
+
+import (
+	"context"
+
+	"url-shorterner/svc/jobs/entity"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DAO defines the data access interface for job read operations.
+type DAO interface {
+	GetJob(ctx context.Context, id string) (*entity.Job, error)
+	ReadFileChunk(ctx context.Context, id string, kind entity.FileKind, seq int) ([]byte, error)
+}
+
+type dao struct {
+	db *pgxpool.Pool
+}
+
+// NewDAO creates a new jobs DAO instance.
+func NewDAO(db *pgxpool.Pool) DAO {
+	return &dao{db: db}
+}
+
+func (d *dao) GetJob(ctx context.Context, id string) (*entity.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = @id`
+	return scanJob(d.db.QueryRow(ctx, query, pgx.NamedArgs{"id": id}))
+}
+
+func (d *dao) ReadFileChunk(ctx context.Context, id string, kind entity.FileKind, seq int) ([]byte, error) {
+	return readFileChunk(ctx, d.db, id, kind, seq)
+}
@@ -0,0 +1,354 @@
+// Package store provides repository implementations for the jobs domain.
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"url-shorterner/internal/storage"
+	"url-shorterner/svc/jobs/entity"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FileChunkSize is the size of the chunks uploaded inputs are stored in.
+const FileChunkSize = 1024 * 1024
+
+// ErrLeaseLost is returned when a worker writes to a job it no longer holds.
+var ErrLeaseLost = errors.New("job lease lost")
+
+// Repository defines the interface for job write operations.
+// Workers identify themselves with a worker ID; writes to a job leased by another worker fail with ErrLeaseLost.
+type Repository interface {
+	// CreateJob inserts a queued job and stores input, if not nil, as its input file.
+	CreateJob(ctx context.Context, job *entity.Job, input io.Reader) error
+	// ClaimJob leases the oldest queued job, or a running job whose lease expired, to worker.
+	// It returns storage.ErrNotFound when there is nothing to run.
+	ClaimJob(ctx context.Context, worker string, lease time.Duration, maxAttempts int) (*entity.Job, error)
+	ExtendLease(ctx context.Context, id, worker string, lease time.Duration) error
+	// SaveProgress stores progress, appends errs to the job errors and chunk to the result file in one transaction.
+	SaveProgress(ctx context.Context, id, worker string, progress entity.JobProgress, errs []entity.JobError, chunk []byte) error
+	// ResetProgress discards the progress and result of a job that cannot resume.
+	ResetProgress(ctx context.Context, id, worker string) error
+	FinishJob(ctx context.Context, id, worker string, status entity.JobStatus, message string) error
+	// ReleaseJob puts a job back in the queue without counting the attempt, e.g. on shutdown.
+	ReleaseJob(ctx context.Context, id, worker string) error
+	// FailAbandonedJobs fails running jobs whose lease expired after maxAttempts attempts.
+	FailAbandonedJobs(ctx context.Context, maxAttempts int, message string) (int64, error)
+	// DeleteFinishedJobs deletes jobs, with their files, that finished before the given time.
+	DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error)
+	ReadFileChunk(ctx context.Context, id string, kind entity.FileKind, seq int) ([]byte, error)
+}
+
+// querier is satisfied by both the pool and a transaction.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// jobColumns are the columns scanned by scanJob.
+const jobColumns = `
+	id, type, status, params, result_format, total, processed, succeeded, failed, checkpoint, result_chunks,
+	errors, error, attempts, created_at, updated_at, started_at, finished_at
+`
+
+type repository struct {
+	db querier
+}
+
+// NewRepository creates a new jobs repository instance.
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateJob(ctx context.Context, job *entity.Job, input io.Reader) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		query := `
+			INSERT INTO jobs (id, type, status, params, result_format, total, created_at, updated_at)
+			VALUES (@id, @type, @status, @params::jsonb, @result_format, @total, @created_at, @updated_at)
+		`
+		args := pgx.NamedArgs{
+			"id":            job.ID,
+			"type":          job.Type,
+			"status":        job.Status,
+			"params":        string(job.Params),
+			"result_format": job.ResultFormat,
+			"total":         job.Total,
+			"created_at":    job.CreatedAt,
+			"updated_at":    job.UpdatedAt,
+		}
+		if _, err := tx.Exec(ctx, query, args); err != nil {
+			return err
+		}
+		if input == nil {
+			return nil
+		}
+
+		buf := make([]byte, FileChunkSize)
+		for seq := 0; ; seq++ {
+			n, err := io.ReadFull(input, buf)
+			if n > 0 {
+				if err := insertFileChunk(ctx, tx, job.ID, entity.FileInput, seq, buf[:n]); err != nil {
+					return err
+				}
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+}
+
+func (r *repository) ClaimJob(ctx context.Context, worker string, lease time.Duration, maxAttempts int) (*entity.Job, error) {
+	// SKIP LOCKED lets concurrent workers claim different jobs without waiting on each other.
+	query := `
+		UPDATE jobs SET
+			status = 'running',
+			attempts = attempts + 1,
+			locked_by = @worker,
+			lease_until = NOW() + make_interval(secs => @lease_seconds),
+			started_at = COALESCE(started_at, NOW()),
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (status = 'queued' OR (status = 'running' AND lease_until < NOW()))
+				AND attempts < @max_attempts
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns
+	args := pgx.NamedArgs{
+		"worker":        worker,
+		"lease_seconds": lease.Seconds(),
+		"max_attempts":  maxAttempts,
+	}
+	return scanJob(r.db.QueryRow(ctx, query, args))
+}
+
+func (r *repository) ExtendLease(ctx context.Context, id, worker string, lease time.Duration) error {
+	query := `
+		UPDATE jobs SET lease_until = NOW() + make_interval(secs => @lease_seconds)
+		WHERE id = @id AND locked_by = @worker AND status = 'running'
+	`
+	args := pgx.NamedArgs{
+		"id":            id,
+		"worker":        worker,
+		"lease_seconds": lease.Seconds(),
+	}
+	return leasedExec(ctx, r.db, query, args)
+}
+
+func (r *repository) SaveProgress(
+	ctx context.Context,
+	id, worker string,
+	progress entity.JobProgress,
+	errs []entity.JobError,
+	chunk []byte,
+) error {
+	if errs == nil {
+		errs = []entity.JobError{}
+	}
+	errsJSON, err := json.Marshal(errs)
+	if err != nil {
+		return err
+	}
+	chunks := 0
+	if len(chunk) > 0 {
+		chunks = 1
+	}
+
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		query := `
+			UPDATE jobs SET
+				total = @total,
+				processed = @processed,
+				succeeded = @succeeded,
+				failed = @failed,
+				checkpoint = @checkpoint,
+				errors = errors || @errors::jsonb,
+				result_chunks = result_chunks + @chunks,
+				updated_at = NOW()
+			WHERE id = @id AND locked_by = @worker AND status = 'running'
+			RETURNING result_chunks
+		`
+		args := pgx.NamedArgs{
+			"id":         id,
+			"worker":     worker,
+			"total":      progress.Total,
+			"processed":  progress.Processed,
+			"succeeded":  progress.Succeeded,
+			"failed":     progress.Failed,
+			"checkpoint": progress.Checkpoint,
+			"errors":     string(errsJSON),
+			"chunks":     chunks,
+		}
+		var resultChunks int
+		if err := tx.QueryRow(ctx, query, args).Scan(&resultChunks); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrLeaseLost
+			}
+			return err
+		}
+		if chunks == 0 {
+			return nil
+		}
+		return insertFileChunk(ctx, tx, id, entity.FileResult, resultChunks-1, chunk)
+	})
+}
+
+func (r *repository) ResetProgress(ctx context.Context, id, worker string) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		query := `
+			UPDATE jobs SET
+				processed = 0, succeeded = 0, failed = 0, checkpoint = 0, result_chunks = 0,
+				errors = '[]', updated_at = NOW()
+			WHERE id = @id AND locked_by = @worker AND status = 'running'
+		`
+		if err := leasedExec(ctx, tx, query, pgx.NamedArgs{"id": id, "worker": worker}); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `DELETE FROM job_files WHERE job_id = @id AND kind = @kind`,
+			pgx.NamedArgs{"id": id, "kind": entity.FileResult})
+		return err
+	})
+}
+
+func (r *repository) FinishJob(ctx context.Context, id, worker string, status entity.JobStatus, message string) error {
+	query := `
+		UPDATE jobs SET
+			status = @status, error = @error, locked_by = NULL, lease_until = NULL,
+			finished_at = NOW(), updated_at = NOW()
+		WHERE id = @id AND locked_by = @worker AND status = 'running'
+	`
+	args := pgx.NamedArgs{
+		"id":     id,
+		"worker": worker,
+		"status": status,
+		"error":  message,
+	}
+	return leasedExec(ctx, r.db, query, args)
+}
+
+func (r *repository) ReleaseJob(ctx context.Context, id, worker string) error {
+	query := `
+		UPDATE jobs SET
+			status = 'queued', attempts = GREATEST(attempts - 1, 0), locked_by = NULL, lease_until = NULL, updated_at = NOW()
+		WHERE id = @id AND locked_by = @worker AND status = 'running'
+	`
+	return leasedExec(ctx, r.db, query, pgx.NamedArgs{"id": id, "worker": worker})
+}
+
+func (r *repository) FailAbandonedJobs(ctx context.Context, maxAttempts int, message string) (int64, error) {
+	query := `
+		UPDATE jobs SET
+			status = 'failed', error = @error, locked_by = NULL, lease_until = NULL,
+			finished_at = NOW(), updated_at = NOW()
+		WHERE status = 'running' AND lease_until < NOW() AND attempts >= @max_attempts
+	`
+	tag, err := r.db.Exec(ctx, query, pgx.NamedArgs{"max_attempts": maxAttempts, "error": message})
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *repository) DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM jobs WHERE finished_at IS NOT NULL AND finished_at < @before`
+	tag, err := r.db.Exec(ctx, query, pgx.NamedArgs{"before": before})
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *repository) ReadFileChunk(ctx context.Context, id string, kind entity.FileKind, seq int) ([]byte, error) {
+	return readFileChunk(ctx, r.db, id, kind, seq)
+}
+
+func insertFileChunk(ctx context.Context, db querier, id string, kind entity.FileKind, seq int, data []byte) error {
+	query := `
+		INSERT INTO job_files (job_id, kind, seq, data)
+		VALUES (@job_id, @kind, @seq, @data)
+	`
+	args := pgx.NamedArgs{
+		"job_id": id,
+		"kind":   kind,
+		"seq":    seq,
+		"data":   data,
+	}
+	_, err := db.Exec(ctx, query, args)
+	return err
+}
+
+// leasedExec runs a job update guarded by the worker's lease, returning ErrLeaseLost if no row matched.
+func leasedExec(ctx context.Context, db querier, query string, args pgx.NamedArgs) error {
+	tag, err := db.Exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func readFileChunk(ctx context.Context, db querier, id string, kind entity.FileKind, seq int) ([]byte, error) {
+	query := `SELECT data FROM job_files WHERE job_id = @job_id AND kind = @kind AND seq = @seq`
+	args := pgx.NamedArgs{
+		"job_id": id,
+		"kind":   kind,
+		"seq":    seq,
+	}
+	var data []byte
+	if err := db.QueryRow(ctx, query, args).Scan(&data); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func scanJob(row pgx.Row) (*entity.Job, error) {
+	var job entity.Job
+	var params []byte
+	err := row.Scan(
+		&job.ID,
+		&job.Type,
+		&job.Status,
+		&params,
+		&job.ResultFormat,
+		&job.Total,
+		&job.Processed,
+		&job.Succeeded,
+		&job.Failed,
+		&job.Checkpoint,
+		&job.ResultChunks,
+		&job.Errors,
+		&job.Error,
+		&job.Attempts,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+	job.Params = params
+	return &job, nil
+}
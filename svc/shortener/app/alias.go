@@ -0,0 +1,117 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	appErrors "url-shorterner/internal/errors"
+)
+
+// defaultReservedAliases are paths reserved regardless of which routes are registered.
+var defaultReservedAliases = []string{
+	"admin", "api", "assets", "docs", "export", "favicon.ico", "health", "healthz",
+	"login", "logout", "robots.txt", "static", "swagger",
+}
+
+// WordList reports whether an alias contains a disallowed word.
+type WordList interface {
+	Contains(alias string) bool
+}
+
+// AliasPolicy configures which custom aliases are accepted.
+type AliasPolicy struct {
+	MinLength int
+	MaxLength int
+	// Charset lists the allowed characters; ranges such as "a-z" are expanded.
+	// A "-" at the start or end is taken literally.
+	Charset string
+}
+
+// AliasValidator validates user-supplied custom aliases.
+type AliasValidator struct {
+	policy   AliasPolicy
+	allowed  [256]bool
+	reserved map[string]struct{}
+	words    WordList
+}
+
+// NewAliasValidator creates a new alias validator. words may be nil.
+func NewAliasValidator(policy AliasPolicy, words WordList) (*AliasValidator, error) {
+	v := &AliasValidator{
+		policy:   policy,
+		reserved: make(map[string]struct{}),
+		words:    words,
+	}
+	if err := v.setCharset(policy.Charset); err != nil {
+		return nil, err
+	}
+	v.Reserve(defaultReservedAliases...)
+	return v, nil
+}
+
+// Reserve adds names that can never be used as aliases, e.g. first path segments of registered routes.
+// It must be called before the validator is used concurrently.
+func (v *AliasValidator) Reserve(names ...string) {
+	for _, name := range names {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			v.reserved[name] = struct{}{}
+		}
+	}
+}
+
+// Validate checks alias against the length, charset, reserved and word list rules.
+func (v *AliasValidator) Validate(alias string) error {
+	if len(alias) < v.policy.MinLength || len(alias) > v.policy.MaxLength {
+		return appErrors.Invalid(appErrors.ErrCodeAliasLength, map[string]interface{}{
+			"Min": v.policy.MinLength,
+			"Max": v.policy.MaxLength,
+		})
+	}
+	for i := 0; i < len(alias); i++ {
+		if !v.allowed[alias[i]] {
+			return appErrors.Invalid(appErrors.ErrCodeAliasCharset, map[string]interface{}{"Charset": v.policy.Charset})
+		}
+	}
+	if v.IsReserved(alias) {
+		return appErrors.Invalid(appErrors.ErrCodeAliasReserved, nil)
+	}
+	if v.words != nil && v.words.Contains(alias) {
+		return appErrors.Invalid(appErrors.ErrCodeAliasOffensive, nil)
+	}
+	return nil
+}
+
+// IsReserved reports whether name collides with a reserved path.
+func (v *AliasValidator) IsReserved(name string) bool {
+	_, ok := v.reserved[strings.ToLower(name)]
+	return ok
+}
+
+func (v *AliasValidator) setCharset(charset string) error {
+	if charset == "" {
+		return fmt.Errorf("alias charset must not be empty")
+	}
+	for i := 0; i < len(charset); i++ {
+		if charset[i] >= 0x80 {
+			return fmt.Errorf("alias charset must be ASCII")
+		}
+		if i+2 < len(charset) && charset[i+1] == '-' {
+			lo, hi := charset[i], charset[i+2]
+			if lo > hi {
+				return fmt.Errorf("invalid alias charset range %c-%c", lo, hi)
+			}
+			for c := int(lo); c <= int(hi); c++ {
+				v.allowed[c] = true
+			}
+			i += 2
+			continue
+		}
+		v.allowed[charset[i]] = true
+	}
+	// Path separators and URL delimiters would break routing no matter what is configured.
+	for _, c := range "/?#%" {
+		v.allowed[c] = false
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"testing"
+
+	appErrors "url-shorterner/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWordList map[string]bool
+
+func (f fakeWordList) Contains(alias string) bool {
+	return f[alias]
+}
+
+func TestSetCharset(t *testing.T) {
+	tests := []struct {
+		charset string
+		allowed string
+		denied  string
+		wantErr bool
+	}{
+		{charset: "a-z", allowed: "amz", denied: "AZ09-"},
+		{charset: "a-zA-Z0-9", allowed: "aZ09", denied: "-_."},
+		{charset: "a-z0-9-", allowed: "a9-", denied: "A_"},
+		{charset: "-a-c", allowed: "-abc", denied: "d"},
+		{charset: "a-", allowed: "a-", denied: "b"},
+		{charset: "_.~", allowed: "_.~", denied: "a-"},
+		{charset: "a-a", allowed: "a", denied: "b"},
+		// Path separators and URL delimiters are never allowed.
+		{charset: "!-~", allowed: "!~aZ", denied: "/?#%"},
+		{charset: "", wantErr: true},
+		{charset: "z-a", wantErr: true},
+		{charset: "a-zé", wantErr: true},
+	}
+	for _, tt := range tests {
+		v := &AliasValidator{}
+		err := v.setCharset(tt.charset)
+		if tt.wantErr {
+			assert.Error(t, err, tt.charset)
+			continue
+		}
+		require.NoError(t, err, tt.charset)
+		for i := 0; i < len(tt.allowed); i++ {
+			assert.True(t, v.allowed[tt.allowed[i]], "%q in %q", tt.allowed[i], tt.charset)
+		}
+		for i := 0; i < len(tt.denied); i++ {
+			assert.False(t, v.allowed[tt.denied[i]], "%q in %q", tt.denied[i], tt.charset)
+		}
+	}
+}
+
+func TestAliasValidate(t *testing.T) {
+	v, err := NewAliasValidator(AliasPolicy{MinLength: 3, MaxLength: 10, Charset: "a-zA-Z0-9_-"}, fakeWordList{"rude": true})
+	require.NoError(t, err)
+	v.Reserve(" Links ", "")
+
+	tests := []struct {
+		alias string
+		code  appErrors.ErrorCode
+	}{
+		{"my-link", ""},
+		{"My_Link_2", ""},
+		{"ab", appErrors.ErrCodeAliasLength},
+		{"abcdefghijk", appErrors.ErrCodeAliasLength},
+		{"my.link", appErrors.ErrCodeAliasCharset},
+		{"my%2flink", appErrors.ErrCodeAliasCharset},
+		{"api", appErrors.ErrCodeAliasReserved},
+		{"Admin", appErrors.ErrCodeAliasReserved},
+		{"LINKS", appErrors.ErrCodeAliasReserved},
+		{"rude", appErrors.ErrCodeAliasOffensive},
+	}
+	for _, tt := range tests {
+		err := v.Validate(tt.alias)
+		if tt.code == "" {
+			assert.NoError(t, err, tt.alias)
+			continue
+		}
+		var invalid *appErrors.InvalidError
+		if assert.ErrorAs(t, err, &invalid, tt.alias) {
+			assert.Equal(t, tt.code, invalid.GetCode(), tt.alias)
+		}
+	}
+}
+
+func TestIsReserved(t *testing.T) {
+	v, err := NewAliasValidator(AliasPolicy{MinLength: 1, MaxLength: 10, Charset: "a-z"}, nil)
+	require.NoError(t, err)
+	assert.True(t, v.IsReserved("swagger"))
+	assert.True(t, v.IsReserved("Robots.TXT"))
+	assert.False(t, v.IsReserved("swaggers"))
+	assert.False(t, v.IsReserved(""))
+}
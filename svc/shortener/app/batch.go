@@ -0,0 +1,227 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/internal/log"
+	"url-shorterner/svc/shortener/entity"
+	shortenerStore "url-shorterner/svc/shortener/store"
+)
+
+// BatchMode selects how ShortenBatch handles failures.
+type BatchMode string
+
+const (
+	// BatchModeBestEffort shortens items concurrently and independently; failed items are reported per item.
+	BatchModeBestEffort BatchMode = "best_effort"
+	// BatchModeAtomic creates all items in one transaction, or none if any item fails.
+	BatchModeAtomic BatchMode = "atomic"
+)
+
+// BatchOptions limits batch requests.
+type BatchOptions struct {
+	// MaxSize is the maximum number of items per batch.
+	MaxSize int
+	// Concurrency is the number of items shortened in parallel in best-effort mode.
+	Concurrency int
+}
+
+// errBatchAborted marks atomic batches rolled back because of another item's failure.
+var errBatchAborted = appErrors.Conflict(appErrors.ErrCodeBatchAborted, nil)
+
+// batchEntry tracks an item of an atomic batch through code assignment.
+type batchEntry struct {
+	index   int
+	url     *entity.URL
+	alias   bool
+	attempt int
+}
+
+func (s *service) ShortenBatch(ctx context.Context, items []BatchItem, mode BatchMode) ([]BatchResult, error) {
+	if s.batch.MaxSize > 0 && len(items) > s.batch.MaxSize {
+		return nil, appErrors.Invalid(appErrors.ErrCodeBatchTooLarge, map[string]interface{}{"Max": s.batch.MaxSize})
+	}
+
+	switch mode {
+	case "", BatchModeBestEffort:
+		return s.shortenBatchBestEffort(ctx, items), nil
+	case BatchModeAtomic:
+		return s.shortenBatchAtomic(ctx, items)
+	default:
+		return nil, appErrors.Invalid(appErrors.ErrCodeBatchMode, map[string]interface{}{"Mode": string(mode)})
+	}
+}
+
+// shortenBatchBestEffort shortens items with bounded concurrency, keeping results in item order.
+func (s *service) shortenBatchBestEffort(ctx context.Context, items []BatchItem) []BatchResult {
+	results := make([]BatchResult, len(items))
+	concurrency := max(s.batch.Concurrency, 1)
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp, err := s.shortenItem(ctx, item)
+			if err != nil {
+				results[i] = failedResult(item, err)
+				return
+			}
+			results[i] = BatchResult{URL: item.URL, Short: resp.ShortURL}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// shortenBatchAtomic validates every item, then inserts them all in one transaction using
+// multi-row inserts. Taken generated codes are regenerated inside the transaction; a taken
+// alias or any invalid item aborts the whole batch.
+func (s *service) shortenBatchAtomic(ctx context.Context, items []BatchItem) ([]BatchResult, error) {
+	results := make([]BatchResult, len(items))
+	entries := make([]*batchEntry, 0, len(items))
+	failed := false
+	for i, item := range items {
+		urlEntity, err := s.newURL(ctx, item)
+		if err != nil {
+			results[i] = failedResult(item, err)
+			failed = true
+			continue
+		}
+		entries = append(entries, &batchEntry{index: i, url: urlEntity, alias: urlEntity.ShortCode != ""})
+	}
+	if failed {
+		return abortBatch(items, results), nil
+	}
+
+	var stored map[int]*entity.URL
+	err := s.repo.WithTx(ctx, func(repo shortenerStore.Repository) error {
+		var err error
+		stored, err = s.insertBatch(ctx, repo, entries, results, items)
+		return err
+	})
+	if errors.Is(err, errBatchAborted) {
+		return abortBatch(items, results), nil
+	}
+	if err != nil {
+		log.Error("Atomic batch insert failed: %v", err)
+		return nil, appErrors.Invalid(appErrors.ErrCodeInternal, map[string]interface{}{"Message": "failed to create URLs"})
+	}
+
+	for _, entry := range entries {
+		urlEntity := stored[entry.index]
+		s.remember(ctx, urlEntity)
+		results[entry.index] = BatchResult{URL: items[entry.index].URL, Short: s.response(urlEntity).ShortURL}
+	}
+	return results, nil
+}
+
+// insertBatch inserts entries through repo, retrying only the rows whose code was taken.
+// It returns the stored URL per item index; an alias conflict is recorded in results and
+// errBatchAborted is returned so the transaction is rolled back.
+func (s *service) insertBatch(
+	ctx context.Context,
+	repo shortenerStore.Repository,
+	entries []*batchEntry,
+	results []BatchResult,
+	items []BatchItem,
+) (map[int]*entity.URL, error) {
+	stored := make(map[int]*entity.URL, len(entries))
+	pending := entries
+	for round := 0; len(pending) > 0; round++ {
+		if round > maxCodeAttempts {
+			return nil, errors.New("short code generation exhausted")
+		}
+
+		urls := make([]*entity.URL, 0, len(pending))
+		for _, entry := range pending {
+			if !entry.alias {
+				if err := s.assignBatchCode(ctx, entry); err != nil {
+					return nil, err
+				}
+			}
+			urls = append(urls, entry.url)
+		}
+
+		inserted, err := repo.CreateURLs(ctx, urls)
+		if err != nil {
+			return nil, err
+		}
+
+		var retry []*batchEntry
+		for _, entry := range pending {
+			if inserted[entry.url.ID] {
+				stored[entry.index] = entry.url
+				continue
+			}
+			if entry.alias {
+				results[entry.index] = failedResult(items[entry.index], appErrors.Conflict(appErrors.ErrCodeAliasExists, nil))
+				return nil, errBatchAborted
+			}
+			if s.codes.Mode() == CodeModeDeterministic {
+				existing, err := repo.GetURLByShortCode(ctx, entry.url.ShortCode)
+				if err != nil {
+					return nil, err
+				}
+				if s.reusable(existing, entry.url) {
+					stored[entry.index] = existing
+					continue
+				}
+			}
+			entry.attempt++
+			retry = append(retry, entry)
+		}
+		pending = retry
+	}
+	return stored, nil
+}
+
+// assignBatchCode sets the next candidate code for a generated-code entry, skipping reserved names.
+func (s *service) assignBatchCode(ctx context.Context, entry *batchEntry) error {
+	for ; entry.attempt < maxCodeAttempts; entry.attempt++ {
+		code, err := s.codes.Generate(ctx, CodeInput{
+			URL:     entry.url.CanonicalURL,
+			Owner:   entry.url.Owner,
+			Attempt: entry.attempt,
+		})
+		if err != nil {
+			return err
+		}
+		if !s.aliases.IsReserved(code) {
+			entry.url.ShortCode = code
+			return nil
+		}
+	}
+	return errors.New("short code generation exhausted")
+}
+
+// abortBatch fills in the results of items that did not fail themselves but were rolled back.
+func abortBatch(items []BatchItem, results []BatchResult) []BatchResult {
+	for i := range results {
+		if results[i].Code == "" {
+			results[i] = failedResult(items[i], errBatchAborted)
+		}
+	}
+	return results
+}
+
+func failedResult(item BatchItem, err error) BatchResult {
+	code := appErrors.ErrCodeInternal
+	if c, ok := appErrors.GetErrorCode(appErrors.ConvertError(err)); ok {
+		code = c
+	}
+	return BatchResult{
+		URL:   item.URL,
+		Code:  string(code),
+		Error: err.Error(),
+		Err:   err,
+	}
+}
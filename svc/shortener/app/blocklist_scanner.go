@@ -0,0 +1,100 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"context"
+	"time"
+
+	"url-shorterner/internal/blocklist"
+	"url-shorterner/internal/cache"
+	"url-shorterner/internal/log"
+	shortenerStore "url-shorterner/svc/shortener/store"
+)
+
+// blocklistScanBatchSize is the number of URLs fetched per page during a rescan.
+const blocklistScanBatchSize = 1000
+
+// BlocklistScanner periodically rescans stored links and flags those whose
+// destination has since appeared on the abuse blocklist.
+type BlocklistScanner struct {
+	repo       shortenerStore.Repository
+	dao        shortenerStore.DAO
+	urlCache   *cache.URLCache
+	blocklist  *blocklist.List
+	normalizer *URLNormalizer
+}
+
+// NewBlocklistScanner creates a new blocklist scanner instance.
+func NewBlocklistScanner(
+	repo shortenerStore.Repository,
+	dao shortenerStore.DAO,
+	urlCache *cache.URLCache,
+	blocklist *blocklist.List,
+	normalizer *URLNormalizer,
+) *BlocklistScanner {
+	return &BlocklistScanner{
+		repo:       repo,
+		dao:        dao,
+		urlCache:   urlCache,
+		blocklist:  blocklist,
+		normalizer: normalizer,
+	}
+}
+
+// Scan checks the canonical URL of every unflagged link against the blocklist, as links are
+// checked when they are created, and returns how many were flagged.
+// Flagged links are evicted from the cache so redirects hit the database and are refused.
+func (s *BlocklistScanner) Scan(ctx context.Context) (int, error) {
+	flagged := 0
+	afterID := ""
+	for {
+		urls, err := s.dao.ListUnblockedURLs(ctx, afterID, blocklistScanBatchSize)
+		if err != nil {
+			return flagged, err
+		}
+
+		for _, u := range urls {
+			source, blocked := s.blocklist.Match(s.normalizer.canonicalOf(u))
+			if !blocked {
+				continue
+			}
+			if err := s.repo.BlockURL(ctx, u.ShortCode, source, time.Now().UTC()); err != nil {
+				return flagged, err
+			}
+			_ = s.urlCache.DeleteURL(ctx, u.ShortCode)
+			flagged++
+		}
+
+		if len(urls) < blocklistScanBatchSize {
+			return flagged, nil
+		}
+		afterID = urls[len(urls)-1].ID
+	}
+}
+
+// Start runs Scan every interval until ctx is canceled.
+func (s *BlocklistScanner) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				flagged, err := s.Scan(ctx)
+				if err != nil {
+					log.Error("Blocklist rescan failed: %v", err)
+					continue
+				}
+				if flagged > 0 {
+					log.Warn("Blocklist rescan flagged %d links", flagged)
+				}
+			}
+		}
+	}()
+}
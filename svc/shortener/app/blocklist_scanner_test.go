@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"url-shorterner/internal/blocklist"
+	"url-shorterner/internal/cache"
+	"url-shorterner/svc/shortener/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocklistScannerScan(t *testing.T) {
+	// More links than one page, so the scan has to continue after the last ID.
+	urls := make([]*entity.URL, 0, blocklistScanBatchSize+4)
+	for i := 0; i < blocklistScanBatchSize; i++ {
+		urls = append(urls, &entity.URL{ID: fmt.Sprintf("%05d", i), ShortCode: fmt.Sprintf("ok%d", i), OriginalURL: "https://fine.example/"})
+	}
+	urls = append(urls,
+		&entity.URL{ID: "99998", ShortCode: "phish1", OriginalURL: "https://login.bad.example/"},
+		&entity.URL{ID: "99999", ShortCode: "phish2", OriginalURL: "https://paths.example/steal/x"},
+		// Matched by canonical URL: stored, or computed for rows created before it was stored.
+		&entity.URL{ID: "99999a", ShortCode: "idn1", OriginalURL: "https://bücher.example/", CanonicalURL: "https://xn--bcher-kva.example/"},
+		&entity.URL{ID: "99999b", ShortCode: "idn2", OriginalURL: "https://BÜCHER.example/shop"},
+	)
+	store := newMemoryStore(urls...)
+
+	path := filepath.Join(t.TempDir(), "feed.txt")
+	require.NoError(t, os.WriteFile(path, []byte("bad.example\npaths.example/steal/*\nxn--bcher-kva.example\n"), 0o600))
+	list := blocklist.NewList([]string{path})
+	require.NoError(t, list.Reload())
+
+	memory := newMemoryCache()
+	urlCache := cache.NewURLCache(memory)
+	ctx := context.Background()
+	require.NoError(t, urlCache.SetURL(ctx, "phish1", "https://login.bad.example/", 1, 0))
+	require.NoError(t, urlCache.SetURL(ctx, "ok1", "https://fine.example/", 1, 0))
+
+	scanner := NewBlocklistScanner(store, store, urlCache, list, NewURLNormalizer(NormalizationPolicy{}))
+	flagged, err := scanner.Scan(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, flagged)
+
+	for _, code := range []string{"phish1", "phish2", "idn1", "idn2"} {
+		u := store.get(code)
+		require.NotNil(t, u.BlockedAt, code)
+		assert.Equal(t, "feed.txt", u.BlockedReason)
+	}
+	assert.Nil(t, store.get("ok1").BlockedAt)
+
+	// Flagged links are evicted from the cache; others stay.
+	_, _, err = urlCache.GetURL(ctx, "phish1")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	_, _, err = urlCache.GetURL(ctx, "ok1")
+	assert.NoError(t, err)
+
+	// Blocked links are not scanned again.
+	flagged, err = scanner.Scan(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, flagged)
+}
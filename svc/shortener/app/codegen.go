@@ -0,0 +1,121 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// Code generation strategies selectable in configuration.
+const (
+	CodeStrategyRandom    = "random"
+	CodeStrategyCounter   = "counter"
+	CodeStrategySnowflake = "snowflake"
+	CodeStrategyHash      = "hash"
+)
+
+// CodeMode describes how the service must treat codes produced by a generator.
+type CodeMode int
+
+const (
+	// CodeModeChecked codes may collide; a failed insert is retried with a new code.
+	CodeModeChecked CodeMode = iota
+	// CodeModeUnique codes never repeat among generated codes.
+	CodeModeUnique
+	// CodeModeDeterministic codes are derived from the input; on a collision with a different
+	// link the next attempt yields the next candidate.
+	CodeModeDeterministic
+)
+
+// CodeInput carries the link a code is generated for. Only deterministic generators use it.
+type CodeInput struct {
+	URL     string
+	Owner   string
+	Attempt int
+}
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// CodeGenerator produces short codes for new links.
+type CodeGenerator interface {
+	Generate(ctx context.Context, input CodeInput) (string, error)
+	// Mode reports how generated codes must be checked.
+	// Custom aliases can still occupy a generated code; the unique constraint on urls.short_code arbitrates.
+	Mode() CodeMode
+}
+
+// CodeGeneratorConfig selects and configures a code generation strategy.
+type CodeGeneratorConfig struct {
+	Strategy string
+	// Length is the code length for the random, counter and hash strategies.
+	Length int
+	// RangeSize is the number of counter values leased from Postgres at a time.
+	RangeSize int64
+	// NodeID distinguishes Snowflake generators; it must be unique per running instance.
+	NodeID int64
+	// HashKey is the secret HMAC key for the hash strategy.
+	HashKey string
+	// CounterKey is the secret key permuting counter values for the counter strategy.
+	CounterKey string
+}
+
+// NewCodeGenerator creates the generator selected by cfg.
+func NewCodeGenerator(cfg CodeGeneratorConfig, leaser CodeRangeLeaser) (CodeGenerator, error) {
+	switch cfg.Strategy {
+	case "", CodeStrategyRandom:
+		return NewRandomCodeGenerator(cfg.Length), nil
+	case CodeStrategyCounter:
+		return NewCounterCodeGenerator(leaser, cfg.RangeSize, []byte(cfg.CounterKey), cfg.Length)
+	case CodeStrategySnowflake:
+		return NewSnowflakeCodeGenerator(cfg.NodeID)
+	case CodeStrategyHash:
+		return NewHashCodeGenerator([]byte(cfg.HashKey), cfg.Length)
+	default:
+		return nil, fmt.Errorf("unknown code generation strategy %q", cfg.Strategy)
+	}
+}
+
+type randomCodeGenerator struct {
+	length int
+}
+
+// NewRandomCodeGenerator creates a generator of random base62 codes of the given length.
+// Random codes may collide; the service inserts them as they are and retries with a new code
+// when the insert fails with storage.ErrDuplicate.
+func NewRandomCodeGenerator(length int) CodeGenerator {
+	return &randomCodeGenerator{length: length}
+}
+
+func (g *randomCodeGenerator) Generate(_ context.Context, _ CodeInput) (string, error) {
+	alphabetSize := big.NewInt(int64(len(base62Alphabet)))
+	code := make([]byte, g.length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		code[i] = base62Alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+func (g *randomCodeGenerator) Mode() CodeMode {
+	return CodeModeChecked
+}
+
+// encodeBase62 encodes a non-negative integer using the base62 alphabet.
+func encodeBase62(n uint64) string {
+	if n == 0 {
+		return base62Alphabet[:1]
+	}
+	var buf [11]byte
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = base62Alphabet[n%62]
+		n /= 62
+	}
+	return string(buf[i:])
+}
@@ -0,0 +1,136 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"strings"
+	"sync"
+)
+
+// counterRangeName identifies the counter used for short codes in the code_ranges table.
+const counterRangeName = "short_code"
+
+// CodeRangeLeaser leases blocks of counter values from shared storage.
+type CodeRangeLeaser interface {
+	// LeaseCodeRange reserves size consecutive values and returns the first one.
+	LeaseCodeRange(ctx context.Context, name string, size int64) (int64, error)
+}
+
+// counterCodeMaxLength is the longest code whose values fit in a uint64 (62^10 < 2^63).
+const counterCodeMaxLength = 10
+
+// feistelRounds is the number of rounds of the counter permutation.
+const feistelRounds = 8
+
+// counterCodeGenerator hands out counter values from ranges leased from Postgres, so each
+// instance only touches the database once per range. Values go through a keyed permutation
+// of the codes of the configured length before base62 encoding, so consecutive links get
+// unrelated codes and cannot be enumerated without the key.
+type counterCodeGenerator struct {
+	leaser    CodeRangeLeaser
+	rangeSize int64
+	perm      *feistel
+	length    int
+
+	mu   sync.Mutex
+	next int64
+	end  int64
+}
+
+// NewCounterCodeGenerator creates a generator backed by leased counter ranges, producing codes
+// of length characters permuted with key.
+func NewCounterCodeGenerator(leaser CodeRangeLeaser, rangeSize int64, key []byte, length int) (CodeGenerator, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("counter code strategy requires a non-empty key")
+	}
+	if length <= 0 || length > counterCodeMaxLength {
+		return nil, fmt.Errorf("counter code length must be between 1 and %d", counterCodeMaxLength)
+	}
+	if rangeSize <= 0 {
+		rangeSize = 1000
+	}
+	domain := uint64(1)
+	for i := 0; i < length; i++ {
+		domain *= uint64(len(base62Alphabet))
+	}
+	return &counterCodeGenerator{
+		leaser:    leaser,
+		rangeSize: rangeSize,
+		perm:      newFeistel(key, domain),
+		length:    length,
+	}, nil
+}
+
+func (g *counterCodeGenerator) Generate(ctx context.Context, _ CodeInput) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.next >= g.end {
+		start, err := g.leaser.LeaseCodeRange(ctx, counterRangeName, g.rangeSize)
+		if err != nil {
+			return "", err
+		}
+		g.next = start
+		g.end = start + g.rangeSize
+	}
+
+	value := uint64(g.next) //nolint:gosec // G115: Leased values are never negative
+	if value >= g.perm.domain {
+		return "", fmt.Errorf("counter code space of length %d is exhausted", g.length)
+	}
+	g.next++
+
+	code := encodeBase62(g.perm.permute(value))
+	return strings.Repeat(base62Alphabet[:1], g.length-len(code)) + code, nil
+}
+
+func (g *counterCodeGenerator) Mode() CodeMode {
+	return CodeModeUnique
+}
+
+// feistel is a keyed bijection on [0, domain). A balanced Feistel network permutes the
+// smallest even-width bit space holding domain, and values falling outside the domain are
+// permuted again (cycle walking), which keeps the mapping a bijection on the domain.
+type feistel struct {
+	key      []byte
+	domain   uint64
+	halfBits uint
+}
+
+func newFeistel(key []byte, domain uint64) *feistel {
+	width := uint(bits.Len64(domain - 1))
+	return &feistel{key: key, domain: domain, halfBits: (width + 1) / 2}
+}
+
+func (f *feistel) permute(value uint64) uint64 {
+	for {
+		value = f.encrypt(value)
+		if value < f.domain {
+			return value
+		}
+	}
+}
+
+func (f *feistel) encrypt(value uint64) uint64 {
+	mask := uint64(1)<<f.halfBits - 1
+	left, right := value>>f.halfBits, value&mask
+	for round := 0; round < feistelRounds; round++ {
+		left, right = right, left^(f.round(round, right)&mask)
+	}
+	return left<<f.halfBits | right
+}
+
+// round is the round function: HMAC-SHA256 of the round number and half block.
+func (f *feistel) round(round int, half uint64) uint64 {
+	var block [9]byte
+	block[0] = byte(round)
+	binary.BigEndian.PutUint64(block[1:], half)
+	mac := hmac.New(sha256.New, f.key)
+	mac.Write(block[:])
+	return binary.BigEndian.Uint64(mac.Sum(nil))
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequenceLeaser leases consecutive ranges starting at next.
+type sequenceLeaser struct {
+	next int64
+}
+
+func (l *sequenceLeaser) LeaseCodeRange(_ context.Context, _ string, size int64) (int64, error) {
+	start := l.next
+	l.next += size
+	return start, nil
+}
+
+func TestFeistelIsBijection(t *testing.T) {
+	for _, domain := range []uint64{1, 2, 62, 1000, 62 * 62} {
+		perm := newFeistel([]byte("key"), domain)
+		seen := make(map[uint64]bool, domain)
+		for v := uint64(0); v < domain; v++ {
+			p := perm.permute(v)
+			require.Less(t, p, domain)
+			require.False(t, seen[p], "domain %d: %d is hit twice", domain, p)
+			seen[p] = true
+		}
+	}
+}
+
+func TestCounterCodeGenerator(t *testing.T) {
+	ctx := context.Background()
+	gen, err := NewCounterCodeGenerator(&sequenceLeaser{}, 10, []byte("secret"), 6)
+	require.NoError(t, err)
+	assert.Equal(t, CodeModeUnique, gen.Mode())
+
+	codes := make(map[string]bool)
+	var first, previous string
+	sequential := 0
+	for i := 0; i < 1000; i++ {
+		code, err := gen.Generate(ctx, CodeInput{})
+		require.NoError(t, err)
+		assert.Len(t, code, 6)
+		assert.False(t, codes[code], "code %s repeats", code)
+		codes[code] = true
+		if first == "" {
+			first = code
+		}
+		if previous != "" && code[:5] == previous[:5] {
+			sequential++
+		}
+		previous = code
+	}
+	// Plain counters share all but the last digit 61 times out of 62.
+	assert.Less(t, sequential, 10)
+
+	// The same key and counter give the same codes; another key gives other codes.
+	again, err := NewCounterCodeGenerator(&sequenceLeaser{}, 10, []byte("secret"), 6)
+	require.NoError(t, err)
+	other, err := NewCounterCodeGenerator(&sequenceLeaser{}, 10, []byte("other"), 6)
+	require.NoError(t, err)
+	code, err := again.Generate(ctx, CodeInput{})
+	require.NoError(t, err)
+	assert.Equal(t, first, code)
+	otherCode, err := other.Generate(ctx, CodeInput{})
+	require.NoError(t, err)
+	assert.NotEqual(t, code, otherCode)
+}
+
+func TestCounterCodeGeneratorExhausted(t *testing.T) {
+	gen, err := NewCounterCodeGenerator(&sequenceLeaser{next: 62*62 - 1}, 10, []byte("secret"), 2)
+	require.NoError(t, err)
+	_, err = gen.Generate(context.Background(), CodeInput{})
+	require.NoError(t, err)
+	_, err = gen.Generate(context.Background(), CodeInput{})
+	assert.Error(t, err)
+}
+
+func TestNewCounterCodeGeneratorValidation(t *testing.T) {
+	_, err := NewCounterCodeGenerator(&sequenceLeaser{}, 10, nil, 8)
+	assert.Error(t, err)
+	_, err = NewCounterCodeGenerator(&sequenceLeaser{}, 10, []byte("k"), counterCodeMaxLength+1)
+	assert.Error(t, err)
+	_, err = NewCounterCodeGenerator(&sequenceLeaser{}, 10, []byte("k"), counterCodeMaxLength)
+	assert.NoError(t, err)
+}
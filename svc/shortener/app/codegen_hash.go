@@ -0,0 +1,60 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// hashCodeMaxLength is the number of base62 digits a SHA-256 digest can fill.
+const hashCodeMaxLength = 43
+
+type hashCodeGenerator struct {
+	key    []byte
+	length int
+}
+
+// NewHashCodeGenerator creates a generator deriving codes from a keyed hash of the canonical URL and owner,
+// so the same link always maps to the same code without a database lookup.
+func NewHashCodeGenerator(key []byte, length int) (CodeGenerator, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("hash code strategy requires a non-empty key")
+	}
+	if length <= 0 || length > hashCodeMaxLength {
+		return nil, fmt.Errorf("hash code length must be between 1 and %d", hashCodeMaxLength)
+	}
+	return &hashCodeGenerator{key: key, length: length}, nil
+}
+
+func (g *hashCodeGenerator) Generate(_ context.Context, input CodeInput) (string, error) {
+	return HashCode(g.key, input.URL, input.Owner, input.Attempt, g.length), nil
+}
+
+func (g *hashCodeGenerator) Mode() CodeMode {
+	return CodeModeDeterministic
+}
+
+// HashCode computes the deterministic short code for canonicalURL and owner.
+// It is exported so codes can be computed offline (e.g. in CI) exactly as the service does:
+// HMAC-SHA256(key, canonicalURL + "\n" + owner + "\n" + attempt), read as a big-endian integer,
+// written in base 62 (big.Int digits 0-9a-zA-Z), left-padded with "0" to 43 digits, last length digits.
+// Attempt starts at 0 and is incremented each time the code is taken by a different link.
+func HashCode(key []byte, canonicalURL, owner string, attempt, length int) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(canonicalURL))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(owner))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(strconv.Itoa(attempt)))
+
+	digits := new(big.Int).SetBytes(mac.Sum(nil)).Text(62)
+	if len(digits) < hashCodeMaxLength {
+		digits = strings.Repeat("0", hashCodeMaxLength-len(digits)) + digits
+	}
+	return digits[len(digits)-length:]
+}
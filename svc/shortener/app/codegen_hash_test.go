@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"url-shorterner/svc/shortener/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashCodeDeterminism(t *testing.T) {
+	key := []byte("secret")
+	code := HashCode(key, "https://example.com/", "", 0, 8)
+	assert.Len(t, code, 8)
+	assert.Equal(t, code, HashCode(key, "https://example.com/", "", 0, 8))
+
+	// Every input takes part in the code.
+	assert.NotEqual(t, code, HashCode([]byte("other"), "https://example.com/", "", 0, 8))
+	assert.NotEqual(t, code, HashCode(key, "https://example.org/", "", 0, 8))
+	assert.NotEqual(t, code, HashCode(key, "https://example.com/", "alice", 0, 8))
+	assert.NotEqual(t, code, HashCode(key, "https://example.com/", "", 1, 8))
+
+	// Shorter codes are suffixes of longer ones.
+	long := HashCode(key, "https://example.com/", "", 0, hashCodeMaxLength)
+	assert.Len(t, long, hashCodeMaxLength)
+	assert.Equal(t, long[len(long)-8:], code)
+	for _, c := range long {
+		assert.Contains(t, base62Alphabet, string(c))
+	}
+}
+
+func TestNewHashCodeGeneratorValidation(t *testing.T) {
+	_, err := NewHashCodeGenerator(nil, 8)
+	assert.Error(t, err)
+	_, err = NewHashCodeGenerator([]byte("k"), 0)
+	assert.Error(t, err)
+	_, err = NewHashCodeGenerator([]byte("k"), hashCodeMaxLength+1)
+	assert.Error(t, err)
+}
+
+func newHashTestService(t *testing.T, store *memoryStore) *service {
+	t.Helper()
+	codes, err := NewHashCodeGenerator([]byte("secret"), 8)
+	require.NoError(t, err)
+	aliases, err := NewAliasValidator(AliasPolicy{MinLength: 1, MaxLength: 64, Charset: "a-zA-Z0-9"}, nil)
+	require.NoError(t, err)
+	return &service{
+		repo:       store,
+		dao:        store,
+		codes:      codes,
+		aliases:    aliases,
+		normalizer: NewURLNormalizer(NormalizationPolicy{}),
+	}
+}
+
+func TestCreateWithDeterministicCode(t *testing.T) {
+	const target = "https://example.com/page"
+	key := []byte("secret")
+	first := HashCode(key, target, "", 0, 8)
+	second := HashCode(key, target, "", 1, 8)
+
+	now := time.Now().UTC()
+	past := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		existing *entity.URL
+		expires  *time.Time
+		// reused reports whether the existing link is returned; otherwise a new link is
+		// created under the next attempt's code.
+		reused bool
+	}{
+		{
+			name:     "same live link",
+			existing: &entity.URL{ID: "old", OriginalURL: target, CanonicalURL: target},
+			reused:   true,
+		},
+		{
+			name:     "same expiry",
+			existing: &entity.URL{ID: "old", OriginalURL: target, CanonicalURL: target, ExpiresAt: &later},
+			expires:  &later,
+			reused:   true,
+		},
+		{
+			name:     "other link",
+			existing: &entity.URL{ID: "old", OriginalURL: "https://other.example/", CanonicalURL: "https://other.example/"},
+		},
+		{
+			name:     "other owner",
+			existing: &entity.URL{ID: "old", OriginalURL: target, CanonicalURL: target, Owner: "bob"},
+		},
+		{
+			name:     "expired",
+			existing: &entity.URL{ID: "old", OriginalURL: target, CanonicalURL: target, ExpiresAt: &past},
+		},
+		{
+			name:     "blocked",
+			existing: &entity.URL{ID: "old", OriginalURL: target, CanonicalURL: target, BlockedAt: &past},
+		},
+		{
+			name:     "requested expiry",
+			existing: &entity.URL{ID: "old", OriginalURL: target, CanonicalURL: target},
+			expires:  &later,
+		},
+		{
+			name:     "other expiry",
+			existing: &entity.URL{ID: "old", OriginalURL: target, CanonicalURL: target, ExpiresAt: &later},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.existing.ShortCode = first
+			store := newMemoryStore(tt.existing)
+			s := newHashTestService(t, store)
+
+			candidate := &entity.URL{ID: "new", OriginalURL: target, CanonicalURL: target, ExpiresAt: tt.expires}
+			stored, err := s.createWithDeterministicCode(context.Background(), candidate)
+			require.NoError(t, err)
+			if tt.reused {
+				assert.Equal(t, "old", stored.ID)
+				assert.Equal(t, first, stored.ShortCode)
+				return
+			}
+			assert.Equal(t, "new", stored.ID)
+			assert.Equal(t, second, stored.ShortCode)
+			assert.Equal(t, "new", store.get(second).ID)
+		})
+	}
+}
+
+func TestCreateWithDeterministicCodeFreeCode(t *testing.T) {
+	store := newMemoryStore()
+	s := newHashTestService(t, store)
+
+	candidate := &entity.URL{ID: "new", OriginalURL: "https://example.com/", CanonicalURL: "https://example.com/"}
+	stored, err := s.createWithDeterministicCode(context.Background(), candidate)
+	require.NoError(t, err)
+	assert.Equal(t, HashCode([]byte("secret"), "https://example.com/", "", 0, 8), stored.ShortCode)
+}
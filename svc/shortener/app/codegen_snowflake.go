@@ -0,0 +1,73 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Snowflake layout: 41 bits of milliseconds since snowflakeEpoch, 10 bits of node ID, 12 bits of sequence.
+const (
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+	snowflakeMaxNodeID    = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1
+)
+
+// snowflakeEpoch keeps IDs (and therefore codes) short for the lifetime of the service.
+var snowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type snowflakeCodeGenerator struct {
+	nodeID int64
+
+	mu       sync.Mutex
+	lastTime int64
+	sequence int64
+}
+
+// NewSnowflakeCodeGenerator creates a generator of base62-encoded Snowflake IDs.
+// Codes are unique as long as every running instance uses a distinct nodeID.
+func NewSnowflakeCodeGenerator(nodeID int64) (CodeGenerator, error) {
+	if nodeID < 0 || nodeID > snowflakeMaxNodeID {
+		return nil, fmt.Errorf("snowflake node ID must be between 0 and %d", snowflakeMaxNodeID)
+	}
+	return &snowflakeCodeGenerator{nodeID: nodeID}, nil
+}
+
+func (g *snowflakeCodeGenerator) Generate(ctx context.Context, _ CodeInput) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Since(snowflakeEpoch).Milliseconds()
+	// Never issue IDs for a timestamp we have already moved past, e.g. after an NTP step back.
+	if now < g.lastTime {
+		now = g.lastTime
+	}
+
+	if now == g.lastTime {
+		g.sequence = (g.sequence + 1) & snowflakeMaxSequence
+		if g.sequence == 0 {
+			// Sequence exhausted for this millisecond; wait for the next one.
+			for now <= g.lastTime {
+				select {
+				case <-ctx.Done():
+					return "", ctx.Err()
+				case <-time.After(time.Millisecond):
+				}
+				now = time.Since(snowflakeEpoch).Milliseconds()
+			}
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastTime = now
+
+	id := now<<(snowflakeNodeBits+snowflakeSequenceBits) | g.nodeID<<snowflakeSequenceBits | g.sequence
+	return encodeBase62(uint64(id)), nil //nolint:gosec // G115: IDs are positive until the 41-bit timestamp overflows
+}
+
+func (g *snowflakeCodeGenerator) Mode() CodeMode {
+	return CodeModeUnique
+}
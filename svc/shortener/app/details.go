@@ -0,0 +1,96 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	appErrors "url-shorterner/internal/errors"
+)
+
+const (
+	// maxTitleLength matches the urls.title column.
+	maxTitleLength = 255
+	// maxDescriptionLength bounds link descriptions.
+	maxDescriptionLength = 2000
+	// maxMetadataSize bounds the encoded metadata of a link, in bytes.
+	maxMetadataSize = 16 * 1024
+)
+
+// LinkDetails are the user-supplied details of a link.
+//
+// swagger:model LinkDetails
+type LinkDetails struct {
+	// Human readable title (optional)
+	// example: Spring sale landing page
+	Title string `json:"title,omitempty"`
+
+	// Free-form notes (optional)
+	Description string `json:"description,omitempty"`
+
+	// Tags to attach to the link (optional)
+	// example: ["campaign", "spring"]
+	Tags []string `json:"tags,omitempty"`
+
+	// Arbitrary JSON object stored with the link (optional)
+	// swagger:type object
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+// normalizeTitle trims a title and checks its length.
+func normalizeTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return "", appErrors.Invalid(appErrors.ErrCodeTitleTooLong, map[string]interface{}{"Max": maxTitleLength})
+	}
+	return title, nil
+}
+
+// normalizeDescription trims a description and checks its length.
+func normalizeDescription(description string) (string, error) {
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		return "", appErrors.Invalid(appErrors.ErrCodeDescriptionTooLong, map[string]interface{}{"Max": maxDescriptionLength})
+	}
+	return description, nil
+}
+
+// normalizeMetadata checks that metadata is a JSON object within the size limit and compacts it.
+// Empty metadata and null become nil.
+func normalizeMetadata(metadata json.RawMessage) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(metadata)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, nil
+	}
+
+	invalid := appErrors.Invalid(appErrors.ErrCodeInvalidMetadata, map[string]interface{}{"Max": maxMetadataSize})
+	var object map[string]json.RawMessage
+	if len(trimmed) > maxMetadataSize || json.Unmarshal(trimmed, &object) != nil || object == nil {
+		return nil, invalid
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, trimmed); err != nil {
+		return nil, invalid
+	}
+	return compact.Bytes(), nil
+}
+
+// normalize validates the details and returns them trimmed, with normalized tags.
+func (d LinkDetails) normalize() (LinkDetails, error) {
+	var err error
+	if d.Title, err = normalizeTitle(d.Title); err != nil {
+		return d, err
+	}
+	if d.Description, err = normalizeDescription(d.Description); err != nil {
+		return d, err
+	}
+	if d.Tags, err = normalizeTags(d.Tags); err != nil {
+		return d, err
+	}
+	if d.Metadata, err = normalizeMetadata(d.Metadata); err != nil {
+		return d, err
+	}
+	return d, nil
+}
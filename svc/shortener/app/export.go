@@ -0,0 +1,67 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"url-shorterner/svc/shortener/entity"
+)
+
+// ExportedURL is a link as written by Export.
+type ExportedURL struct {
+	URL      *entity.URL
+	ShortURL string
+	// Stats is nil unless stats were requested
+	Stats *entity.URLStats
+}
+
+// exportColumns and exportStatsColumns are the columns of an export row without and with stats.
+var (
+	exportColumns = []string{
+		"short_code", "short_url", "url", "canonical_url", "owner", "title", "description", "tags", "metadata",
+		"expires_at", "created_at", "blocked_at",
+	}
+	exportStatsColumns = []string{"total_clicks", "unique_ips", "last_click"}
+)
+
+// ExportColumns returns the columns of Values, with the stats columns if withStats is set.
+func ExportColumns(withStats bool) []string {
+	if !withStats {
+		return exportColumns
+	}
+	return append(append([]string{}, exportColumns...), exportStatsColumns...)
+}
+
+// Values returns the row of the link in ExportColumns order; stats columns are included when Stats is set.
+func (e ExportedURL) Values() []interface{} {
+	tags := e.URL.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	metadata := e.URL.Metadata
+	if len(metadata) == 0 {
+		metadata = json.RawMessage("{}")
+	}
+	values := []interface{}{
+		e.URL.ShortCode, e.ShortURL, e.URL.OriginalURL, e.URL.CanonicalURL, e.URL.Owner, e.URL.Title, e.URL.Description,
+		tags, metadata, e.URL.ExpiresAt, e.URL.CreatedAt, e.URL.BlockedAt,
+	}
+	if e.Stats != nil {
+		values = append(values, e.Stats.TotalClicks, e.Stats.UniqueIPs, e.Stats.LastClick)
+	}
+	return values
+}
+
+// Export streams every link, optionally with its aggregate click stats, to emit.
+// Rows are read through a server-side cursor on the reader pool.
+func (s *service) Export(ctx context.Context, withStats bool, emit func(ExportedURL) error) error {
+	return s.dao.StreamURLs(ctx, withStats, func(url *entity.URL, stats *entity.URLStats) error {
+		return emit(ExportedURL{
+			URL:      url,
+			ShortURL: fmt.Sprintf("%s/%s", s.domain, url.ShortCode),
+			Stats:    stats,
+		})
+	})
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"url-shorterner/internal/cache"
+	"url-shorterner/internal/storage"
+	"url-shorterner/svc/shortener/entity"
+	shortenerStore "url-shorterner/svc/shortener/store"
+)
+
+// memoryStore is an in-memory stand-in for the shortener repository and DAO. Methods the
+// tests do not use panic through the embedded nil interfaces.
+type memoryStore struct {
+	shortenerStore.Repository
+	shortenerStore.DAO
+
+	mu   sync.Mutex
+	urls map[string]*entity.URL
+}
+
+func newMemoryStore(urls ...*entity.URL) *memoryStore {
+	s := &memoryStore{urls: make(map[string]*entity.URL)}
+	for _, u := range urls {
+		s.urls[u.ShortCode] = u
+	}
+	return s
+}
+
+func (s *memoryStore) get(code string) *entity.URL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.urls[code]
+}
+
+func (s *memoryStore) CreateURL(_ context.Context, u *entity.URL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.urls[u.ShortCode]; ok {
+		return storage.ErrDuplicate
+	}
+	stored := *u
+	s.urls[u.ShortCode] = &stored
+	return nil
+}
+
+func (s *memoryStore) GetURLByShortCode(_ context.Context, code string) (*entity.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.urls[code]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	found := *u
+	return &found, nil
+}
+
+func (s *memoryStore) BlockURL(_ context.Context, code, reason string, blockedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.urls[code]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	u.BlockedAt = &blockedAt
+	u.BlockedReason = reason
+	return nil
+}
+
+func (s *memoryStore) ListUnblockedURLs(_ context.Context, afterID string, limit int) ([]*entity.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var urls []*entity.URL
+	for _, u := range s.urls {
+		if u.BlockedAt == nil && u.ID > afterID {
+			found := *u
+			urls = append(urls, &found)
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool { return urls[i].ID < urls[j].ID })
+	if len(urls) > limit {
+		urls = urls[:limit]
+	}
+	return urls, nil
+}
+
+// memoryCache is an in-memory cache.Cache for the key-value operations.
+type memoryCache struct {
+	cache.Cache
+
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: make(map[string]string)}
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		return "", cache.ErrNotFound
+	}
+	return value, nil
+}
+
+func (c *memoryCache) Set(_ context.Context, key, value string, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+	return nil
+}
+
+func (c *memoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+	return nil
+}
+
+func (c *memoryCache) Exists(_ context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.values[key]
+	return ok, nil
+}
@@ -0,0 +1,368 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"url-shorterner/internal/bulk"
+	appErrors "url-shorterner/internal/errors"
+)
+
+// maxImportLineSize bounds a single JSONL line.
+const maxImportLineSize = 1024 * 1024
+
+// ImportRecord is a link read from an import file.
+type ImportRecord struct {
+	URL       string
+	Alias     string
+	ExpiresAt *time.Time
+	Details   LinkDetails
+}
+
+// ImportResult reports the outcome of one import record.
+type ImportResult struct {
+	// Line is the 1-based line of the record in the import file
+	Line  int
+	URL   string
+	Alias string
+	// Short is the short URL of the created link (empty if the record failed)
+	Short string
+	Code  string
+	Error string
+	// Err is the underlying error, kept for localizing Error in the transport layer
+	Err error
+}
+
+// ImportSummary counts the outcomes of an import.
+type ImportSummary struct {
+	Total   int `json:"total"`
+	Created int `json:"created"`
+	Failed  int `json:"failed"`
+}
+
+// ImportReportColumns are the columns of an import report row.
+var ImportReportColumns = []string{"line", "url", "alias", "short", "code", "error"}
+
+// ImportOptions tunes an import.
+type ImportOptions struct {
+	// ResumeAfter skips the records on lines up to and including this line, e.g. when resuming an interrupted import.
+	ResumeAfter int
+}
+
+// pendingRecord is a parsed record waiting for its batch to be shortened.
+type pendingRecord struct {
+	line   int
+	record ImportRecord
+	item   BatchItem
+}
+
+// Import reads records from r and shortens them in best-effort batches of the configured
+// maximum size, validated exactly like ShortenBatch. emit is called for every record in file order;
+// malformed records are reported without stopping the import.
+func (s *service) Import(
+	ctx context.Context,
+	format bulk.Format,
+	r io.Reader,
+	opts ImportOptions,
+	emit func(ImportResult) error,
+) (*ImportSummary, error) {
+	reader, err := newRecordReader(format, r)
+	if err != nil {
+		return nil, appErrors.Invalid(appErrors.ErrCodeImportFormat, nil)
+	}
+
+	summary := &ImportSummary{}
+	batchSize := max(s.batch.MaxSize, 1)
+	pending := make([]pendingRecord, 0, batchSize)
+
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		items := make([]BatchItem, len(pending))
+		for i, p := range pending {
+			items[i] = p.item
+		}
+		results := s.shortenBatchBestEffort(ctx, items)
+		for i, p := range pending {
+			result := ImportResult{
+				Line:  p.line,
+				URL:   p.record.URL,
+				Alias: p.record.Alias,
+				Short: results[i].Short,
+				Code:  results[i].Code,
+				Error: results[i].Error,
+				Err:   results[i].Err,
+			}
+			if err := summary.emit(result, emit); err != nil {
+				return err
+			}
+		}
+		pending = pending[:0]
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		record, line, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var recordErr *importRecordError
+		isRecordErr := errors.As(err, &recordErr)
+		if (err == nil || isRecordErr) && line <= opts.ResumeAfter {
+			continue
+		}
+		if isRecordErr {
+			failure := recordFailure(line, record, recordErr.err)
+			if err := summary.emit(failure, emit); err != nil {
+				return summary, err
+			}
+			continue
+		}
+		if err != nil {
+			return summary, err
+		}
+
+		item, err := record.batchItem()
+		if err != nil {
+			if err := summary.emit(recordFailure(line, record, err), emit); err != nil {
+				return summary, err
+			}
+			continue
+		}
+		pending = append(pending, pendingRecord{line: line, record: record, item: item})
+		if len(pending) == batchSize {
+			if err := flush(); err != nil {
+				return summary, err
+			}
+		}
+	}
+	return summary, flush()
+}
+
+func (sum *ImportSummary) emit(result ImportResult, emit func(ImportResult) error) error {
+	sum.Total++
+	if result.Err != nil {
+		sum.Failed++
+	} else {
+		sum.Created++
+	}
+	return emit(result)
+}
+
+func recordFailure(line int, record ImportRecord, err error) ImportResult {
+	failed := failedResult(BatchItem{URL: record.URL}, err)
+	return ImportResult{
+		Line:  line,
+		URL:   record.URL,
+		Alias: record.Alias,
+		Code:  failed.Code,
+		Error: failed.Error,
+		Err:   err,
+	}
+}
+
+// batchItem converts the record to a shorten request; absolute expiries become relative.
+func (rec ImportRecord) batchItem() (BatchItem, error) {
+	item := BatchItem{URL: rec.URL, LinkDetails: rec.Details}
+	if rec.Alias != "" {
+		alias := rec.Alias
+		item.Alias = &alias
+	}
+	if rec.ExpiresAt != nil {
+		seconds := int(time.Until(*rec.ExpiresAt).Seconds())
+		if seconds <= 0 {
+			return item, appErrors.Invalid(appErrors.ErrCodeExpiryInPast, nil)
+		}
+		item.ExpiresIn = &seconds
+	}
+	return item, nil
+}
+
+// importRecordError marks a malformed record; the import continues with the next one.
+type importRecordError struct {
+	err error
+}
+
+func (e *importRecordError) Error() string {
+	return e.err.Error()
+}
+
+func invalidRecord(format string, args ...interface{}) error {
+	return &importRecordError{err: appErrors.Invalid(appErrors.ErrCodeImportRecord, map[string]interface{}{
+		"Message": fmt.Sprintf(format, args...),
+	})}
+}
+
+// recordReader yields import records with their line numbers until io.EOF.
+type recordReader interface {
+	Next() (ImportRecord, int, error)
+}
+
+func newRecordReader(format bulk.Format, r io.Reader) (recordReader, error) {
+	switch format {
+	case bulk.FormatCSV:
+		return newCSVRecordReader(r), nil
+	case bulk.FormatJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+		return &jsonlRecordReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// csvRecordReader reads CSV files with a header row naming the columns url (required), alias,
+// expires_at (or expiry), title, description, tags (separated by ";") and metadata (a JSON object).
+type csvRecordReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	err     error
+}
+
+func newCSVRecordReader(r io.Reader) *csvRecordReader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return &csvRecordReader{reader: reader}
+}
+
+func (c *csvRecordReader) Next() (ImportRecord, int, error) {
+	if c.columns == nil && c.err == nil {
+		c.err = c.readHeader()
+	}
+	if c.err != nil {
+		return ImportRecord{}, 0, c.err
+	}
+
+	fields, err := c.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return ImportRecord{}, parseErr.Line, invalidRecord("%v", parseErr.Err)
+		}
+		return ImportRecord{}, 0, err
+	}
+	// FieldPos is only valid for a record that was read successfully.
+	line, _ := c.reader.FieldPos(0)
+
+	get := func(name string) string {
+		if i, ok := c.columns[name]; ok && i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+	record := ImportRecord{URL: get("url"), Alias: get("alias")}
+	record.Details.Title = get("title")
+	record.Details.Description = get("description")
+	if tags := get("tags"); tags != "" {
+		record.Details.Tags = strings.Split(tags, bulk.ListSeparator)
+	}
+	if metadata := get("metadata"); metadata != "" {
+		record.Details.Metadata = json.RawMessage(metadata)
+	}
+	expiry := get("expires_at")
+	if expiry == "" {
+		expiry = get("expiry")
+	}
+	if record.ExpiresAt, err = parseExpiry(expiry); err != nil {
+		return record, line, err
+	}
+	if record.URL == "" {
+		return record, line, invalidRecord("missing url")
+	}
+	return record, line, nil
+}
+
+func (c *csvRecordReader) readHeader() error {
+	header, err := c.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return io.EOF
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	c.columns = make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		c.columns[name] = i
+	}
+	if _, ok := c.columns["url"]; !ok {
+		return appErrors.Invalid(appErrors.ErrCodeImportRecord, map[string]interface{}{"Message": "CSV header must contain a url column"})
+	}
+	return nil
+}
+
+// jsonlRecordReader reads one JSON object per line with the fields url, alias, expires_at (or expiry),
+// title, description, tags (an array of strings) and metadata (an object).
+type jsonlRecordReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (j *jsonlRecordReader) Next() (ImportRecord, int, error) {
+	for j.scanner.Scan() {
+		j.line++
+		data := strings.TrimSpace(j.scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		var raw struct {
+			URL       string `json:"url"`
+			Alias     string `json:"alias"`
+			ExpiresAt string `json:"expires_at"`
+			Expiry    string `json:"expiry"`
+			LinkDetails
+		}
+		if err := json.Unmarshal([]byte(data), &raw); err != nil {
+			return ImportRecord{}, j.line, invalidRecord("%v", err)
+		}
+
+		record := ImportRecord{URL: strings.TrimSpace(raw.URL), Alias: strings.TrimSpace(raw.Alias), Details: raw.LinkDetails}
+		expiry := raw.ExpiresAt
+		if expiry == "" {
+			expiry = raw.Expiry
+		}
+		var err error
+		if record.ExpiresAt, err = parseExpiry(expiry); err != nil {
+			return record, j.line, err
+		}
+		if record.URL == "" {
+			return record, j.line, invalidRecord("missing url")
+		}
+		return record, j.line, nil
+	}
+	if err := j.scanner.Err(); err != nil {
+		return ImportRecord{}, j.line, err
+	}
+	return ImportRecord{}, j.line, io.EOF
+}
+
+// parseExpiry accepts RFC 3339 timestamps and plain dates (midnight UTC).
+func parseExpiry(s string) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			t = t.UTC()
+			return &t, nil
+		}
+	}
+	return nil, invalidRecord("invalid expiry %q, expected RFC 3339 or YYYY-MM-DD", s)
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shorterner/internal/bulk"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type readResult struct {
+	record ImportRecord
+	line   int
+	err    error
+}
+
+func readAll(t *testing.T, format bulk.Format, input string) []readResult {
+	t.Helper()
+	reader, err := newRecordReader(format, strings.NewReader(input))
+	require.NoError(t, err)
+	var results []readResult
+	for {
+		record, line, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return results
+		}
+		results = append(results, readResult{record: record, line: line, err: err})
+		require.Less(t, len(results), 100, "reader does not end")
+	}
+}
+
+func TestCSVRecordReaderMalformedQuote(t *testing.T) {
+	// A bare quote in the first field used to panic in FieldPos.
+	results := readAll(t, bulk.FormatCSV, "url,alias\na\"b,c\nhttps://ok.example/,ok\n")
+	require.Len(t, results, 2)
+
+	var recordErr *importRecordError
+	require.ErrorAs(t, results[0].err, &recordErr)
+	assert.Equal(t, 2, results[0].line)
+
+	require.NoError(t, results[1].err)
+	assert.Equal(t, 3, results[1].line)
+	assert.Equal(t, "https://ok.example/", results[1].record.URL)
+	assert.Equal(t, "ok", results[1].record.Alias)
+}
+
+func TestCSVRecordReader(t *testing.T) {
+	input := "\ufeffURL, Alias ,expiry,tags,metadata,title\n" +
+		"https://a.example/,a,2030-01-02,x;y,\"{\"\"k\"\":1}\",Title A\n" +
+		"\n" +
+		"https://b.example/\n" +
+		",missing\n" +
+		"https://c.example/,,not-a-date\n"
+	results := readAll(t, bulk.FormatCSV, input)
+	require.Len(t, results, 4)
+
+	first := results[0]
+	require.NoError(t, first.err)
+	assert.Equal(t, 2, first.line)
+	assert.Equal(t, "a", first.record.Alias)
+	assert.Equal(t, []string{"x", "y"}, first.record.Details.Tags)
+	assert.JSONEq(t, `{"k":1}`, string(first.record.Details.Metadata))
+	assert.Equal(t, "Title A", first.record.Details.Title)
+	require.NotNil(t, first.record.ExpiresAt)
+	assert.Equal(t, time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC), *first.record.ExpiresAt)
+
+	// Short rows leave the missing columns empty.
+	require.NoError(t, results[1].err)
+	assert.Equal(t, 4, results[1].line)
+	assert.Equal(t, "https://b.example/", results[1].record.URL)
+
+	assert.Error(t, results[2].err)
+	assert.Equal(t, 5, results[2].line)
+	assert.Error(t, results[3].err)
+	assert.Equal(t, 6, results[3].line)
+}
+
+func TestCSVRecordReaderEmpty(t *testing.T) {
+	assert.Empty(t, readAll(t, bulk.FormatCSV, ""))
+}
+
+func TestJSONLRecordReader(t *testing.T) {
+	input := `{"url":" https://a.example/ ","alias":"a","expires_at":"2030-01-02T03:04:05Z","tags":["x"]}
+
+not json
+{"alias":"b"}
+{"url":"https://c.example/","expiry":"2030-01-02"}
+`
+	results := readAll(t, bulk.FormatJSONL, input)
+	require.Len(t, results, 4)
+
+	require.NoError(t, results[0].err)
+	assert.Equal(t, 1, results[0].line)
+	assert.Equal(t, "https://a.example/", results[0].record.URL)
+	assert.Equal(t, []string{"x"}, results[0].record.Details.Tags)
+	require.NotNil(t, results[0].record.ExpiresAt)
+
+	var recordErr *importRecordError
+	assert.ErrorAs(t, results[1].err, &recordErr)
+	assert.Equal(t, 3, results[1].line)
+	assert.Error(t, results[2].err)
+	assert.Equal(t, 4, results[2].line)
+
+	require.NoError(t, results[3].err)
+	assert.Equal(t, 5, results[3].line)
+	require.NotNil(t, results[3].record.ExpiresAt)
+}
@@ -0,0 +1,455 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/internal/storage"
+	"url-shorterner/internal/uuid"
+	"url-shorterner/svc/shortener/entity"
+)
+
+const (
+	// defaultLinkPageSize is the page size of ListLinks when no limit is given.
+	defaultLinkPageSize = 50
+	// maxLinkPageSize bounds the page size of ListLinks.
+	maxLinkPageSize = 200
+	// maxActorLength matches the url_versions.actor column.
+	maxActorLength = 255
+)
+
+// Link represents a link with its details
+//
+// swagger:model Link
+type Link struct {
+	// The short code of the link
+	ShortCode string `json:"short_code"`
+
+	// The complete shortened URL
+	ShortURL string `json:"short_url"`
+
+	// The destination URL
+	URL string `json:"url"`
+
+	// Owner of the link (empty for anonymous links)
+	Owner string `json:"owner,omitempty"`
+
+	// Human readable title
+	Title string `json:"title"`
+
+	// Free-form notes
+	Description string `json:"description"`
+
+	// Lowercase tags attached to the link
+	Tags []string `json:"tags"`
+
+	// Campaign the link belongs to (omitted if none)
+	CampaignID *string `json:"campaign_id,omitempty"`
+
+	// JSON object stored with the link
+	// swagger:type object
+	Metadata json.RawMessage `json:"metadata"`
+
+	// Expiration timestamp (null if no expiration)
+	ExpiresAt *time.Time `json:"expires_at"`
+
+	// Creation timestamp
+	CreatedAt time.Time `json:"created_at"`
+
+	// Last update timestamp
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Current version of the link
+	Version int `json:"version"`
+}
+
+// LinkUpdate changes a link; omitted fields are left unchanged
+//
+// swagger:model LinkUpdate
+type LinkUpdate struct {
+	// New destination URL
+	URL *string `json:"url,omitempty"`
+
+	// New expiration in seconds from now; 0 removes the expiration
+	ExpiresIn *int `json:"expires_in,omitempty"`
+
+	// New title; an empty string clears it
+	Title *string `json:"title,omitempty"`
+
+	// New description; an empty string clears it
+	Description *string `json:"description,omitempty"`
+
+	// Tags replacing the current ones; an empty list removes all tags
+	Tags *[]string `json:"tags,omitempty"`
+
+	// JSON object replacing the current metadata; null or {} clears it
+	// swagger:type object
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+// LinkVersion is a recorded version of a link
+//
+// swagger:model LinkVersion
+type LinkVersion struct {
+	// Version number, starting at 1
+	Version int `json:"version"`
+
+	// Kind of change: create, update or rollback
+	Action string `json:"action"`
+
+	// Who made the change
+	Actor string `json:"actor"`
+
+	// Version restored by a rollback (omitted otherwise)
+	RolledBackFrom *int `json:"rolled_back_from,omitempty"`
+
+	// Destination URL of this version
+	URL string `json:"url"`
+
+	// Expiration timestamp of this version (null if no expiration)
+	ExpiresAt *time.Time `json:"expires_at"`
+
+	// Title of this version
+	Title string `json:"title"`
+
+	// Description of this version
+	Description string `json:"description"`
+
+	// Tags of this version
+	Tags []string `json:"tags"`
+
+	// Metadata of this version
+	// swagger:type object
+	Metadata json.RawMessage `json:"metadata"`
+
+	// Number of clicks served by this version
+	Clicks int64 `json:"clicks"`
+
+	// When the version was created
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LinkFilter selects links for ListLinks.
+type LinkFilter struct {
+	// Tags keeps links carrying all of the given tags
+	Tags []string
+	// Owner keeps links of the given owner when set
+	Owner *string
+	// CampaignID keeps links of the given campaign when set
+	CampaignID *string
+	// Cursor is the next_cursor of the previous page
+	Cursor string
+	// Limit is the page size; 0 selects the default
+	Limit int
+}
+
+// LinkPage is a page of links, newest first
+//
+// swagger:model LinkPage
+type LinkPage struct {
+	// Links of the page
+	Links []*Link `json:"links"`
+
+	// Cursor of the next page (empty on the last page)
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// GetLink returns a link with its details.
+func (s *service) GetLink(ctx context.Context, shortCode string) (*Link, error) {
+	urlEntity, err := s.dao.GetURLByShortCode(ctx, shortCode)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, appErrors.NotFound(appErrors.ResourceURL)
+		}
+		return nil, err
+	}
+	return s.link(urlEntity), nil
+}
+
+// UpdateLink changes a link and records the result as a new version attributed to actor.
+// The cached redirect is dropped so the next click is served by the new version.
+func (s *service) UpdateLink(ctx context.Context, shortCode string, update LinkUpdate, actor string) (*Link, error) {
+	change := entity.URLUpdate{
+		Actor:  actor,
+		Action: entity.VersionActionUpdate,
+	}
+	if update.URL != nil {
+		canonical, err := s.destination(ctx, *update.URL)
+		if err != nil {
+			return nil, err
+		}
+		change.OriginalURL = update.URL
+		change.CanonicalURL = &canonical
+	}
+	if update.ExpiresIn != nil {
+		switch {
+		case *update.ExpiresIn < 0:
+			return nil, appErrors.Invalid(appErrors.ErrCodeExpiryInPast, nil)
+		case *update.ExpiresIn == 0:
+			change.ClearExpiry = true
+		default:
+			expiresAt := time.Now().UTC().Add(time.Duration(*update.ExpiresIn) * time.Second)
+			change.ExpiresAt = &expiresAt
+		}
+	}
+	if update.Title != nil {
+		title, err := normalizeTitle(*update.Title)
+		if err != nil {
+			return nil, err
+		}
+		change.Title = &title
+	}
+	if update.Description != nil {
+		description, err := normalizeDescription(*update.Description)
+		if err != nil {
+			return nil, err
+		}
+		change.Description = &description
+	}
+	if update.Tags != nil {
+		tags, err := normalizeTags(*update.Tags)
+		if err != nil {
+			return nil, err
+		}
+		change.Tags = append([]string{}, tags...)
+	}
+	if update.Metadata != nil {
+		metadata, err := normalizeMetadata(update.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		if metadata == nil {
+			metadata = json.RawMessage("{}")
+		}
+		change.Metadata = metadata
+	}
+
+	return s.applyUpdate(ctx, shortCode, change)
+}
+
+// ListLinkVersions returns the version history of a link, newest first.
+func (s *service) ListLinkVersions(ctx context.Context, shortCode string) ([]*LinkVersion, error) {
+	if _, err := s.GetLink(ctx, shortCode); err != nil {
+		return nil, err
+	}
+	versions, err := s.dao.ListURLVersions(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*LinkVersion, 0, len(versions))
+	for _, version := range versions {
+		result = append(result, linkVersion(version))
+	}
+	return result, nil
+}
+
+// RollbackLink restores the destination, expiry and settings of an earlier version, unless its
+// expiry has passed. The rollback is recorded as a new version; history is never rewritten.
+func (s *service) RollbackLink(ctx context.Context, shortCode string, version int, actor string) (*Link, error) {
+	target, err := s.dao.GetURLVersion(ctx, shortCode, version)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			if _, err := s.GetLink(ctx, shortCode); err != nil {
+				return nil, err
+			}
+			return nil, appErrors.NotFound(appErrors.ResourceVersion)
+		}
+		return nil, err
+	}
+	// Restoring an expiry that has passed would take the link down.
+	if target.ExpiresAt != nil && !target.ExpiresAt.After(time.Now()) {
+		return nil, appErrors.Invalid(appErrors.ErrCodeExpiryInPast, nil)
+	}
+	// The old destination may have been blocklisted since.
+	canonical, err := s.destination(ctx, target.OriginalURL)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := target.Settings
+	change := entity.URLUpdate{
+		OriginalURL:    &target.OriginalURL,
+		CanonicalURL:   &canonical,
+		ExpiresAt:      target.ExpiresAt,
+		ClearExpiry:    target.ExpiresAt == nil,
+		Title:          &settings.Title,
+		Description:    &settings.Description,
+		Tags:           append([]string{}, settings.Tags...),
+		Metadata:       settings.Metadata,
+		Actor:          actor,
+		Action:         entity.VersionActionRollback,
+		RolledBackFrom: &target.Version,
+	}
+	if len(change.Metadata) == 0 || string(change.Metadata) == "null" {
+		change.Metadata = json.RawMessage("{}")
+	}
+	return s.applyUpdate(ctx, shortCode, change)
+}
+
+// destination normalizes and validates a new destination and returns its canonical form.
+func (s *service) destination(ctx context.Context, originalURL string) (string, error) {
+	canonical, err := s.normalizer.Normalize(originalURL)
+	if err != nil {
+		return "", appErrors.Invalid(appErrors.ErrCodeInvalidURLFormat, nil)
+	}
+	if err := s.validator.Validate(ctx, canonical); err != nil {
+		return "", err
+	}
+	return canonical, nil
+}
+
+// applyUpdate stores change and drops the cached redirect of the link.
+func (s *service) applyUpdate(ctx context.Context, shortCode string, change entity.URLUpdate) (*Link, error) {
+	if runes := []rune(change.Actor); len(runes) > maxActorLength {
+		change.Actor = string(runes[:maxActorLength])
+	}
+	urlEntity, err := s.repo.UpdateURL(ctx, shortCode, change)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, appErrors.NotFound(appErrors.ResourceURL)
+		}
+		return nil, err
+	}
+	_ = s.urlCache.DeleteURL(ctx, shortCode)
+	return s.link(urlEntity), nil
+}
+
+// ListLinks returns a page of links matching filter, newest first.
+func (s *service) ListLinks(ctx context.Context, filter LinkFilter) (*LinkPage, error) {
+	var after *entity.URLPosition
+	if filter.Cursor != "" {
+		position, err := decodeLinkCursor(filter.Cursor)
+		if err != nil {
+			return nil, appErrors.Invalid(appErrors.ErrCodeInvalidCursor, nil)
+		}
+		after = position
+	}
+	if filter.CampaignID != nil && !uuid.Valid(*filter.CampaignID) {
+		return nil, appErrors.NotFound(appErrors.ResourceCampaign)
+	}
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return nil, err
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultLinkPageSize
+	}
+	limit = min(limit, maxLinkPageSize)
+
+	// One extra row tells whether there is a next page.
+	urls, err := s.dao.ListURLs(ctx, entity.URLFilter{
+		Tags:       tags,
+		Owner:      filter.Owner,
+		CampaignID: filter.CampaignID,
+		After:      after,
+		Limit:      limit + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &LinkPage{Links: make([]*Link, 0, min(len(urls), limit))}
+	if len(urls) > limit {
+		urls = urls[:limit]
+		page.NextCursor = encodeLinkCursor(urls[limit-1])
+	}
+	for _, url := range urls {
+		page.Links = append(page.Links, s.link(url))
+	}
+	return page, nil
+}
+
+// encodeLinkCursor returns a cursor continuing a listing after u. It carries the position
+// itself rather than the ID, so it stays valid when u is deleted.
+func encodeLinkCursor(u *entity.URL) string {
+	raw := strconv.FormatInt(u.CreatedAt.UnixMicro(), 10) + "_" + u.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeLinkCursor(cursor string) (*entity.URLPosition, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	micros, id, ok := strings.Cut(string(raw), "_")
+	if !ok || !uuid.Valid(id) {
+		return nil, errors.New("malformed cursor")
+	}
+	n, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.URLPosition{CreatedAt: time.UnixMicro(n).UTC(), ID: id}, nil
+}
+
+// ListTags returns every tag in use with its number of links.
+func (s *service) ListTags(ctx context.Context) ([]*entity.TagCount, error) {
+	tags, err := s.dao.ListTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tags == nil {
+		tags = []*entity.TagCount{}
+	}
+	return tags, nil
+}
+
+func (s *service) link(u *entity.URL) *Link {
+	tags := u.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	metadata := u.Metadata
+	if len(metadata) == 0 {
+		metadata = json.RawMessage("{}")
+	}
+	return &Link{
+		ShortCode:   u.ShortCode,
+		ShortURL:    fmt.Sprintf("%s/%s", s.domain, u.ShortCode),
+		URL:         u.OriginalURL,
+		Owner:       u.Owner,
+		Title:       u.Title,
+		Description: u.Description,
+		Tags:        tags,
+		CampaignID:  u.CampaignID,
+		Metadata:    metadata,
+		ExpiresAt:   u.ExpiresAt,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+		Version:     u.Version,
+	}
+}
+
+func linkVersion(v *entity.URLVersion) *LinkVersion {
+	tags := v.Settings.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	metadata := v.Settings.Metadata
+	if len(metadata) == 0 || string(metadata) == "null" {
+		metadata = json.RawMessage("{}")
+	}
+	return &LinkVersion{
+		Version:        v.Version,
+		Action:         string(v.Action),
+		Actor:          v.Actor,
+		RolledBackFrom: v.RolledBackFrom,
+		URL:            v.OriginalURL,
+		ExpiresAt:      v.ExpiresAt,
+		Title:          v.Settings.Title,
+		Description:    v.Settings.Description,
+		Tags:           tags,
+		Metadata:       metadata,
+		Clicks:         v.Clicks,
+		CreatedAt:      v.CreatedAt,
+	}
+}
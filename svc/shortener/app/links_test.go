@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	appErrors "url-shorterner/internal/errors"
+	"url-shorterner/svc/shortener/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkCursorRoundTrip(t *testing.T) {
+	created := time.Date(2030, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	u := &entity.URL{ID: "0f8fad5b-d9cb-469f-a165-70867728950e", CreatedAt: created}
+
+	position, err := decodeLinkCursor(encodeLinkCursor(u))
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, position.ID)
+	assert.True(t, created.Equal(position.CreatedAt))
+}
+
+func TestListLinksInvalidCursor(t *testing.T) {
+	s := &service{}
+	for _, cursor := range []string{
+		"0f8fad5b-d9cb-469f-a165-70867728950e",
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("123")),
+		base64.RawURLEncoding.EncodeToString([]byte("123_not-a-uuid")),
+		base64.RawURLEncoding.EncodeToString([]byte("x_0f8fad5b-d9cb-469f-a165-70867728950e")),
+	} {
+		_, err := s.ListLinks(context.Background(), LinkFilter{Cursor: cursor})
+		var invalid *appErrors.InvalidError
+		require.ErrorAs(t, err, &invalid, cursor)
+		assert.Equal(t, appErrors.ErrCodeInvalidCursor, invalid.Code, cursor)
+	}
+}
+
+// versionStore serves a single stored version of every link.
+type versionStore struct {
+	*memoryStore
+	version *entity.URLVersion
+}
+
+func (s *versionStore) GetURLVersion(context.Context, string, int) (*entity.URLVersion, error) {
+	return s.version, nil
+}
+
+func TestRollbackLinkRejectsPassedExpiry(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	store := &versionStore{
+		memoryStore: newMemoryStore(),
+		version:     &entity.URLVersion{Version: 1, OriginalURL: "https://example.com/", ExpiresAt: &past},
+	}
+	s := &service{repo: store, dao: store}
+
+	_, err := s.RollbackLink(context.Background(), "code", 1, "tester")
+	var invalid *appErrors.InvalidError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, appErrors.ErrCodeExpiryInPast, invalid.Code)
+}
@@ -0,0 +1,202 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"net/netip"
+	"net/url"
+	"sort"
+	"strings"
+
+	"url-shorterner/svc/shortener/entity"
+
+	"golang.org/x/net/idna"
+)
+
+// DefaultTrackingParams are query parameters stripped when tracking-parameter stripping is enabled.
+// Entries ending in "*" match by prefix.
+var DefaultTrackingParams = []string{
+	"utm_*", "fbclid", "gclid", "dclid", "gbraid", "wbraid", "msclkid", "yclid",
+	"mc_cid", "mc_eid", "igshid", "_ga", "_gl",
+}
+
+// NormalizationPolicy configures the optional normalization steps, which can change
+// what the destination server sees and are therefore off by default.
+type NormalizationPolicy struct {
+	// StripTrackingParams removes TrackingParams from the query.
+	StripTrackingParams bool
+	// TrackingParams lists parameter names (case-insensitive); a trailing "*" matches by prefix.
+	// Defaults to DefaultTrackingParams when nil.
+	TrackingParams []string
+	// SortQuery orders query parameters by name, keeping the order of repeated names.
+	SortQuery bool
+}
+
+// URLNormalizer computes the canonical form of destination URLs used for dedup,
+// deterministic codes and analytics grouping.
+type URLNormalizer struct {
+	policy   NormalizationPolicy
+	exact    map[string]struct{}
+	prefixes []string
+}
+
+// NewURLNormalizer creates a normalizer for the given policy.
+func NewURLNormalizer(policy NormalizationPolicy) *URLNormalizer {
+	n := &URLNormalizer{
+		policy: policy,
+		exact:  make(map[string]struct{}),
+	}
+	params := policy.TrackingParams
+	if params == nil {
+		params = DefaultTrackingParams
+	}
+	for _, param := range params {
+		param = strings.ToLower(strings.TrimSpace(param))
+		if prefix, ok := strings.CutSuffix(param, "*"); ok {
+			n.prefixes = append(n.prefixes, prefix)
+		} else if param != "" {
+			n.exact[param] = struct{}{}
+		}
+	}
+	return n
+}
+
+// canonicalOf returns the stored canonical URL of u, computing it for rows created before it was stored.
+func (n *URLNormalizer) canonicalOf(u *entity.URL) string {
+	if u.CanonicalURL != "" {
+		return u.CanonicalURL
+	}
+	canonical, err := n.Normalize(u.OriginalURL)
+	if err != nil {
+		return u.OriginalURL
+	}
+	return canonical
+}
+
+// Normalize returns the canonical form of u: lowercase scheme and host, IDN hosts converted
+// to punycode, default port removed, dot-segments resolved, an empty path replaced by "/",
+// and, if enabled, tracking parameters stripped and the query sorted.
+// It fails only if u cannot be parsed or its host is not a valid domain name.
+func (n *URLNormalizer) Normalize(u string) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	if parsed.Opaque != "" || parsed.Host == "" {
+		return parsed.String(), nil
+	}
+
+	host, err := canonicalHost(parsed.Hostname())
+	if err != nil {
+		return "", err
+	}
+	if port := parsed.Port(); port != "" && !isDefaultPort(parsed.Scheme, port) {
+		host += ":" + port
+	}
+	parsed.Host = host
+
+	escapedPath := removeDotSegments(parsed.EscapedPath())
+	if path, err := url.PathUnescape(escapedPath); err == nil {
+		parsed.Path = path
+		parsed.RawPath = escapedPath
+	}
+
+	parsed.RawQuery = n.normalizeQuery(parsed.RawQuery)
+	parsed.ForceQuery = false
+	return parsed.String(), nil
+}
+
+// canonicalHost lowercases host and converts internationalized names to punycode.
+// IPv6 literals are bracketed again so a port can be appended.
+func canonicalHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if addr.Is6() {
+			return "[" + addr.String() + "]", nil
+		}
+		return addr.String(), nil
+	}
+	return idna.Lookup.ToASCII(host)
+}
+
+// removeDotSegments resolves "." and ".." path segments (RFC 3986 section 5.2.4),
+// treating percent-encoded dots like literal ones. The result always starts with "/".
+func removeDotSegments(path string) string {
+	if path == "" || path == "/" {
+		return "/"
+	}
+
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	out := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch strings.ReplaceAll(strings.ToLower(segment), "%2e", ".") {
+		case ".":
+			if last {
+				out = append(out, "")
+			}
+		case "..":
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+			if last {
+				out = append(out, "")
+			}
+		default:
+			out = append(out, segment)
+		}
+	}
+	return "/" + strings.Join(out, "/")
+}
+
+// normalizeQuery applies tracking-parameter stripping and sorting on the raw query,
+// leaving the encoding of the remaining parameters untouched.
+func (n *URLNormalizer) normalizeQuery(rawQuery string) string {
+	if rawQuery == "" || (!n.policy.StripTrackingParams && !n.policy.SortQuery) {
+		return rawQuery
+	}
+
+	type param struct {
+		name string
+		raw  string
+	}
+	var params []param
+	for _, raw := range strings.Split(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(raw, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if n.policy.StripTrackingParams && n.isTrackingParam(name) {
+			continue
+		}
+		params = append(params, param{name: name, raw: raw})
+	}
+
+	if n.policy.SortQuery {
+		sort.SliceStable(params, func(i, j int) bool {
+			return params[i].name < params[j].name
+		})
+	}
+
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.raw
+	}
+	return strings.Join(parts, "&")
+}
+
+func (n *URLNormalizer) isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	if _, ok := n.exact[name]; ok {
+		return true
+	}
+	for _, prefix := range n.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
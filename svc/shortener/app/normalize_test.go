@@ -0,0 +1,96 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy NormalizationPolicy
+		in     string
+		want   string
+	}{
+		{name: "lowercase scheme and host", in: "HTTPS://Example.COM/Path", want: "https://example.com/Path"},
+		{name: "trailing dot", in: "https://example.com./", want: "https://example.com/"},
+		{name: "empty path", in: "https://example.com", want: "https://example.com/"},
+		{name: "default http port", in: "http://example.com:80/a", want: "http://example.com/a"},
+		{name: "default https port", in: "https://example.com:443/a", want: "https://example.com/a"},
+		{name: "other port kept", in: "https://example.com:8443/a", want: "https://example.com:8443/a"},
+		{name: "http port on https kept", in: "https://example.com:80/a", want: "https://example.com:80/a"},
+		{name: "idn host", in: "https://bücher.example/", want: "https://xn--bcher-kva.example/"},
+		{name: "ipv4", in: "http://192.168.0.1:80/", want: "http://192.168.0.1/"},
+		{name: "ipv6", in: "http://[2001:DB8::1]:8080/", want: "http://[2001:db8::1]:8080/"},
+		{name: "dot segments", in: "https://example.com/a/./b/../c", want: "https://example.com/a/c"},
+		{name: "trailing dot segment", in: "https://example.com/a/b/..", want: "https://example.com/a/"},
+		{name: "encoded dot segments", in: "https://example.com/a/%2E%2e/b", want: "https://example.com/b"},
+		{name: "dot segments above root", in: "https://example.com/../../a", want: "https://example.com/a"},
+		{name: "escaping kept", in: "https://example.com/a%2Fb?q=%20", want: "https://example.com/a%2Fb?q=%20"},
+		{name: "empty query dropped", in: "https://example.com/?", want: "https://example.com/"},
+		{name: "fragment kept", in: "https://example.com/a#Top", want: "https://example.com/a#Top"},
+		{name: "query untouched by default", in: "https://example.com/?b=2&utm_source=x&a=1", want: "https://example.com/?b=2&utm_source=x&a=1"},
+		{
+			name:   "tracking params stripped",
+			policy: NormalizationPolicy{StripTrackingParams: true},
+			in:     "https://example.com/?UTM_Source=x&id=7&fbclid=abc&utm_medium=y",
+			want:   "https://example.com/?id=7",
+		},
+		{
+			name:   "all params stripped",
+			policy: NormalizationPolicy{StripTrackingParams: true},
+			in:     "https://example.com/?gclid=1",
+			want:   "https://example.com/",
+		},
+		{
+			name:   "custom tracking params",
+			policy: NormalizationPolicy{StripTrackingParams: true, TrackingParams: []string{"ref", "x_*"}},
+			in:     "https://example.com/?ref=a&x_y=b&utm_source=c",
+			want:   "https://example.com/?utm_source=c",
+		},
+		{
+			name:   "sorted query keeps repeated order",
+			policy: NormalizationPolicy{SortQuery: true},
+			in:     "https://example.com/?b=2&a=3&b=1&&a=1",
+			want:   "https://example.com/?a=3&a=1&b=2&b=1",
+		},
+		{
+			name:   "sorted by unescaped name",
+			policy: NormalizationPolicy{SortQuery: true},
+			in:     "https://example.com/?%62=1&a=2",
+			want:   "https://example.com/?a=2&%62=1",
+		},
+		{name: "opaque", in: "mailto:Someone@Example.com", want: "mailto:Someone@Example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewURLNormalizer(tt.policy).Normalize(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	n := NewURLNormalizer(NormalizationPolicy{})
+	for _, in := range []string{"http://exa mple.com/", "https://%zz/", "://missing-scheme"} {
+		_, err := n.Normalize(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestNormalizeIsIdempotent(t *testing.T) {
+	n := NewURLNormalizer(NormalizationPolicy{StripTrackingParams: true, SortQuery: true})
+	for _, in := range []string{
+		"HTTPS://Bücher.Example:443/a/../b/./c?z=1&utm_source=x&a=2",
+		"http://[::1]:80/%7Euser/",
+	} {
+		once, err := n.Normalize(in)
+		require.NoError(t, err)
+		twice, err := n.Normalize(once)
+		require.NoError(t, err)
+		assert.Equal(t, once, twice, in)
+	}
+}
@@ -3,13 +3,12 @@ package app
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"errors"
 	"fmt"
-	"net/url"
+	"io"
 	"time"
 
+	"url-shorterner/internal/bulk"
 	"url-shorterner/internal/cache"
 	appErrors "url-shorterner/internal/errors"
 	eventsPublisher "url-shorterner/internal/events"
@@ -22,11 +21,22 @@ import (
 	"github.com/bits-and-blooms/bloom/v3"
 )
 
+// maxCodeAttempts bounds how many candidate codes are tried before giving up.
+const maxCodeAttempts = 10
+
 // Service defines the interface for URL shortening operations.
 type Service interface {
-	Shorten(ctx context.Context, originalURL string, expiresIn *int, alias *string) (*ShortenResponse, error)
-	ShortenBatch(ctx context.Context, items []BatchItem) ([]BatchResult, error)
+	Shorten(ctx context.Context, originalURL string, expiresIn *int, alias, owner *string, details LinkDetails) (*ShortenResponse, error)
+	ShortenBatch(ctx context.Context, items []BatchItem, mode BatchMode) ([]BatchResult, error)
 	GetOriginalURL(ctx context.Context, shortCode string, clickInfo *ClickInfo) (string, error)
+	Import(ctx context.Context, format bulk.Format, r io.Reader, opts ImportOptions, emit func(ImportResult) error) (*ImportSummary, error)
+	Export(ctx context.Context, withStats bool, emit func(ExportedURL) error) error
+	GetLink(ctx context.Context, shortCode string) (*Link, error)
+	UpdateLink(ctx context.Context, shortCode string, update LinkUpdate, actor string) (*Link, error)
+	ListLinkVersions(ctx context.Context, shortCode string) ([]*LinkVersion, error)
+	RollbackLink(ctx context.Context, shortCode string, version int, actor string) (*Link, error)
+	ListLinks(ctx context.Context, filter LinkFilter) (*LinkPage, error)
+	ListTags(ctx context.Context) ([]*entity.TagCount, error)
 }
 
 // ClickInfo contains information about a click event.
@@ -34,16 +44,21 @@ type ClickInfo struct {
 	IPAddress string
 	UserAgent string
 	Referer   string
+	UTM       analyticsEvents.UTM
 }
 
 type service struct {
-	repo         shortenerStore.Repository
-	dao          shortenerStore.DAO
-	urlCache     *cache.URLCache
-	bloomFilter  *bloom.BloomFilter
-	shortCodeLen int
-	domain       string
-	publisher    eventsPublisher.Publisher
+	repo        shortenerStore.Repository
+	dao         shortenerStore.DAO
+	urlCache    *cache.URLCache
+	bloomFilter *bloom.BloomFilter
+	codes       CodeGenerator
+	domain      string
+	publisher   eventsPublisher.Publisher
+	validator   *URLValidator
+	aliases     *AliasValidator
+	normalizer  *URLNormalizer
+	batch       BatchOptions
 }
 
 // NewService creates a new URL shortening service instance.
@@ -53,19 +68,27 @@ func NewService(
 	urlCache *cache.URLCache,
 	bloomN uint,
 	bloomP float64,
-	shortCodeLen int,
+	codes CodeGenerator,
 	domain string,
 	publisher eventsPublisher.Publisher,
+	validator *URLValidator,
+	aliases *AliasValidator,
+	normalizer *URLNormalizer,
+	batch BatchOptions,
 ) Service {
 	bf := bloom.NewWithEstimates(bloomN, bloomP)
 	return &service{
-		repo:         repo,
-		dao:          dao,
-		urlCache:     urlCache,
-		bloomFilter:  bf,
-		shortCodeLen: shortCodeLen,
-		domain:       domain,
-		publisher:    publisher,
+		repo:        repo,
+		dao:         dao,
+		urlCache:    urlCache,
+		bloomFilter: bf,
+		codes:       codes,
+		domain:      domain,
+		publisher:   publisher,
+		validator:   validator,
+		aliases:     aliases,
+		normalizer:  normalizer,
+		batch:       batch,
 	}
 }
 
@@ -96,6 +119,11 @@ type BatchItem struct {
 
 	// Custom alias for the shortened URL (optional, must be unique)
 	Alias *string `json:"alias,omitempty"`
+
+	// Owner of the link (optional); part of the hash input for deterministic codes
+	Owner *string `json:"owner,omitempty"`
+
+	LinkDetails
 }
 
 // BatchResult represents the result of shortening a single URL in a batch operation
@@ -108,92 +136,176 @@ type BatchResult struct {
 	// The shortened URL (empty if error occurred)
 	Short string `json:"short"`
 
+	// Error code if shortening failed (e.g. ERR_ALIAS_EXISTS, ERR_BATCH_ABORTED)
+	Code string `json:"code,omitempty"`
+
 	// Error message if shortening failed (empty if successful)
 	Error string `json:"error,omitempty"`
+
+	// Err is the underlying error, kept for localizing Error in the transport layer
+	Err error `json:"-"`
 }
 
-func (s *service) Shorten(ctx context.Context, originalURL string, expiresIn *int, alias *string) (*ShortenResponse, error) {
-	if err := validateURL(originalURL); err != nil {
+func (s *service) Shorten(
+	ctx context.Context,
+	originalURL string,
+	expiresIn *int,
+	alias, owner *string,
+	details LinkDetails,
+) (*ShortenResponse, error) {
+	return s.shortenItem(ctx, BatchItem{
+		URL:         originalURL,
+		ExpiresIn:   expiresIn,
+		Alias:       alias,
+		Owner:       owner,
+		LinkDetails: details,
+	})
+}
+
+func (s *service) shortenItem(ctx context.Context, item BatchItem) (*ShortenResponse, error) {
+	urlEntity, err := s.newURL(ctx, item)
+	if err != nil {
 		return nil, err
 	}
 
-	var shortCode string
-	if alias != nil && *alias != "" {
-		shortCode = *alias
-		exists, err := s.dao.CheckShortCodeExists(ctx, shortCode)
-		if err != nil {
-			return nil, appErrors.Invalid(appErrors.ErrCodeInternal, map[string]interface{}{"Message": "failed to check alias"})
+	switch {
+	case urlEntity.ShortCode != "":
+		if err := s.repo.CreateURL(ctx, urlEntity); err != nil {
+			if errors.Is(err, storage.ErrDuplicate) {
+				return nil, appErrors.Conflict(appErrors.ErrCodeAliasExists, nil)
+			}
+			return nil, appErrors.Invalid(appErrors.ErrCodeInternal, map[string]interface{}{"Message": "failed to create URL"})
 		}
-		if exists {
-			return nil, appErrors.Conflict(appErrors.ErrCodeAliasExists, nil)
-		}
-	} else {
-		var err error
-		shortCode, err = s.generateUniqueShortCode(ctx)
+	case s.codes.Mode() == CodeModeDeterministic:
+		stored, err := s.createWithDeterministicCode(ctx, urlEntity)
 		if err != nil {
 			return nil, err
 		}
+		urlEntity = stored
+	default:
+		if err := s.createWithGeneratedCode(ctx, urlEntity); err != nil {
+			return nil, err
+		}
+	}
+
+	s.remember(ctx, urlEntity)
+	return s.response(urlEntity), nil
+}
+
+// newURL normalizes and validates a shorten request and builds the entity to insert.
+// ShortCode is set only when a custom alias is requested.
+func (s *service) newURL(ctx context.Context, item BatchItem) (*entity.URL, error) {
+	canonical, err := s.normalizer.Normalize(item.URL)
+	if err != nil {
+		return nil, appErrors.Invalid(appErrors.ErrCodeInvalidURLFormat, nil)
+	}
+	// Validating the canonical form lets punycode hosts match blocklist feeds.
+	if err := s.validator.Validate(ctx, canonical); err != nil {
+		return nil, err
+	}
+
+	details, err := item.LinkDetails.normalize()
+	if err != nil {
+		return nil, err
 	}
 
 	now := time.Now().UTC()
 	var expiresAt *time.Time
-	if expiresIn != nil {
-		exp := now.Add(time.Duration(*expiresIn) * time.Second)
+	if item.ExpiresIn != nil {
+		exp := now.Add(time.Duration(*item.ExpiresIn) * time.Second)
 		expiresAt = &exp
 	}
 
 	urlEntity := &entity.URL{
-		ID:          uuid.Generate(),
-		ShortCode:   shortCode,
-		OriginalURL: originalURL,
-		ExpiresAt:   expiresAt,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:           uuid.Generate(),
+		OriginalURL:  item.URL,
+		CanonicalURL: canonical,
+		ExpiresAt:    expiresAt,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Title:        details.Title,
+		Description:  details.Description,
+		Metadata:     details.Metadata,
+		Tags:         details.Tags,
 	}
-
-	if err := s.repo.CreateURL(ctx, urlEntity); err != nil {
-		return nil, appErrors.Invalid(appErrors.ErrCodeInternal, map[string]interface{}{"Message": "failed to create URL"})
+	if item.Owner != nil {
+		urlEntity.Owner = *item.Owner
 	}
+	if item.Alias != nil && *item.Alias != "" {
+		if err := s.aliases.Validate(*item.Alias); err != nil {
+			return nil, err
+		}
+		urlEntity.ShortCode = *item.Alias
+	}
+	return urlEntity, nil
+}
 
-	s.bloomFilter.Add([]byte(shortCode))
+// remember adds a stored URL to the Bloom filter and the cache.
+func (s *service) remember(ctx context.Context, urlEntity *entity.URL) {
+	s.bloomFilter.Add([]byte(urlEntity.ShortCode))
+	s.cacheURL(ctx, urlEntity)
+}
 
+// cacheURL caches the destination of a URL until it expires.
+func (s *service) cacheURL(ctx context.Context, urlEntity *entity.URL) {
+	shortCode := urlEntity.ShortCode
 	var ttl time.Duration
-	if expiresAt != nil {
-		ttl = time.Until(*expiresAt)
+	if urlEntity.ExpiresAt != nil {
+		ttl = time.Until(*urlEntity.ExpiresAt)
 		if ttl > 0 {
-			_ = s.urlCache.SetURL(ctx, shortCode, originalURL, ttl)
+			_ = s.urlCache.SetURL(ctx, shortCode, urlEntity.OriginalURL, urlEntity.Version, ttl)
 		}
 	} else {
-		_ = s.urlCache.SetURL(ctx, shortCode, originalURL, 365*24*time.Hour)
+		_ = s.urlCache.SetURL(ctx, shortCode, urlEntity.OriginalURL, urlEntity.Version, 365*24*time.Hour)
 	}
+}
 
-	shortURL := fmt.Sprintf("%s/%s", s.domain, shortCode)
-
+func (s *service) response(urlEntity *entity.URL) *ShortenResponse {
 	return &ShortenResponse{
-		ShortCode: shortCode,
-		ShortURL:  shortURL,
-		ExpiresAt: expiresAt,
-	}, nil
+		ShortCode: urlEntity.ShortCode,
+		ShortURL:  fmt.Sprintf("%s/%s", s.domain, urlEntity.ShortCode),
+		ExpiresAt: urlEntity.ExpiresAt,
+	}
 }
 
-func (s *service) ShortenBatch(ctx context.Context, items []BatchItem) ([]BatchResult, error) {
-	results := make([]BatchResult, 0, len(items))
-	for _, item := range items {
-		resp, err := s.Shorten(ctx, item.URL, item.ExpiresIn, item.Alias)
+// createWithDeterministicCode inserts urlEntity under its hash-derived code, letting the unique
+// constraint on urls.short_code decide collisions. If the code already belongs to a live link with
+// the same canonical URL, owner and expiry, that link is returned; otherwise the next candidate is
+// tried, so expired or blocked links and links expiring at another time are never handed out.
+func (s *service) createWithDeterministicCode(ctx context.Context, urlEntity *entity.URL) (*entity.URL, error) {
+	input := CodeInput{
+		URL:   urlEntity.CanonicalURL,
+		Owner: urlEntity.Owner,
+	}
+
+	for attempt := 0; attempt < maxCodeAttempts; attempt++ {
+		input.Attempt = attempt
+		code, err := s.codes.Generate(ctx, input)
 		if err != nil {
-			results = append(results, BatchResult{
-				URL:   item.URL,
-				Short: "",
-				Error: err.Error(),
-			})
+			return nil, appErrors.Invalid(appErrors.ErrCodeInternal, map[string]interface{}{"Message": "failed to generate short code"})
+		}
+		if s.aliases.IsReserved(code) {
 			continue
 		}
-		results = append(results, BatchResult{
-			URL:   item.URL,
-			Short: resp.ShortURL,
-		})
+
+		urlEntity.ShortCode = code
+		err = s.repo.CreateURL(ctx, urlEntity)
+		if err == nil {
+			return urlEntity, nil
+		}
+		if !errors.Is(err, storage.ErrDuplicate) {
+			return nil, appErrors.Invalid(appErrors.ErrCodeInternal, map[string]interface{}{"Message": "failed to create URL"})
+		}
+
+		existing, err := s.repo.GetURLByShortCode(ctx, code)
+		if err != nil {
+			return nil, appErrors.Invalid(appErrors.ErrCodeInternal, map[string]interface{}{"Message": "failed to get URL"})
+		}
+		if s.reusable(existing, urlEntity) {
+			return existing, nil
+		}
 	}
-	return results, nil
+	return nil, appErrors.Invalid(appErrors.ErrCodeShortCodeGeneration, map[string]interface{}{"Attempts": maxCodeAttempts})
 }
 
 func (s *service) GetOriginalURL(ctx context.Context, shortCode string, clickInfo *ClickInfo) (string, error) {
@@ -201,9 +313,12 @@ func (s *service) GetOriginalURL(ctx context.Context, shortCode string, clickInf
 		return "", appErrors.NotFound(appErrors.ResourceURL)
 	}
 
-	cachedURL, err := s.urlCache.GetURL(ctx, shortCode)
+	cachedURL, version, err := s.urlCache.GetURL(ctx, shortCode)
 	if err == nil {
-		s.publishClickEvent(ctx, shortCode, clickInfo)
+		if s.validator.IsBlocked(cachedURL) {
+			return "", appErrors.Forbidden(appErrors.ErrCodeURLBlocked, nil)
+		}
+		s.publishClickEvent(ctx, shortCode, version, clickInfo)
 		return cachedURL, nil
 	}
 
@@ -219,21 +334,18 @@ func (s *service) GetOriginalURL(ctx context.Context, shortCode string, clickInf
 		return "", appErrors.Expired(appErrors.ErrCodeExpired, map[string]interface{}{"Resource": appErrors.ResourceURL})
 	}
 
-	var ttl time.Duration
-	if urlEntity.ExpiresAt != nil {
-		ttl = time.Until(*urlEntity.ExpiresAt)
-		if ttl > 0 {
-			_ = s.urlCache.SetURL(ctx, shortCode, urlEntity.OriginalURL, ttl)
-		}
-	} else {
-		_ = s.urlCache.SetURL(ctx, shortCode, urlEntity.OriginalURL, 365*24*time.Hour)
+	if urlEntity.BlockedAt != nil || s.validator.IsBlocked(urlEntity.OriginalURL) {
+		return "", appErrors.Forbidden(appErrors.ErrCodeURLBlocked, nil)
 	}
 
-	s.publishClickEvent(ctx, shortCode, clickInfo)
+	s.cacheURL(ctx, urlEntity)
+
+	s.publishClickEvent(ctx, shortCode, urlEntity.Version, clickInfo)
 	return urlEntity.OriginalURL, nil
 }
 
-func (s *service) publishClickEvent(ctx context.Context, shortCode string, clickInfo *ClickInfo) {
+// publishClickEvent records a click on the given version of a link; version 0 means unknown.
+func (s *service) publishClickEvent(ctx context.Context, shortCode string, version int, clickInfo *ClickInfo) {
 	if s.publisher == nil || clickInfo == nil {
 		return
 	}
@@ -241,9 +353,11 @@ func (s *service) publishClickEvent(ctx context.Context, shortCode string, click
 	go func() {
 		clickEvent := analyticsEvents.ClickEvent{
 			ShortCode: shortCode,
+			Version:   version,
 			IPAddress: clickInfo.IPAddress,
 			UserAgent: clickInfo.UserAgent,
 			Referer:   clickInfo.Referer,
+			UTM:       clickInfo.UTM,
 			Timestamp: time.Now().UTC(),
 		}
 		if err := s.publisher.PublishClickEvent(ctx, clickEvent); err != nil {
@@ -254,34 +368,50 @@ func (s *service) publishClickEvent(ctx context.Context, shortCode string, click
 	}()
 }
 
-func (s *service) generateUniqueShortCode(ctx context.Context) (string, error) {
-	maxAttempts := 10
-	for i := 0; i < maxAttempts; i++ {
-		code := generateShortCode(s.shortCodeLen)
-		exists, err := s.dao.CheckShortCodeExists(ctx, code)
-		if err != nil {
-			return "", appErrors.Invalid(appErrors.ErrCodeInternal, map[string]interface{}{"Message": "failed to check short code"})
-		}
-		if !exists {
-			return code, nil
-		}
-	}
-	return "", appErrors.Invalid(appErrors.ErrCodeShortCodeGeneration, map[string]interface{}{"Attempts": maxAttempts})
+// reusable reports whether existing, which holds the deterministic code of candidate, can be
+// returned instead of creating candidate: a live link with the same canonical URL, owner and expiry.
+func (s *service) reusable(existing, candidate *entity.URL) bool {
+	return existing.Owner == candidate.Owner && s.normalizer.canonicalOf(existing) == candidate.CanonicalURL &&
+		isLive(existing, time.Now().UTC()) && sameExpiry(existing.ExpiresAt, candidate.ExpiresAt)
 }
 
-func validateURL(u string) error {
-	parsed, err := url.Parse(u)
-	if err != nil {
-		return appErrors.Invalid(appErrors.ErrCodeInvalidURLFormat, nil)
-	}
-	if parsed.Scheme != "http" && parsed.Scheme != "https" {
-		return appErrors.Invalid(appErrors.ErrCodeInvalidURLScheme, nil)
+// isLive reports whether u can be redirected to at now.
+func isLive(u *entity.URL, now time.Time) bool {
+	return u.BlockedAt == nil && (u.ExpiresAt == nil || u.ExpiresAt.After(now))
+}
+
+// sameExpiry reports whether two expiries are both unset or within a second of each other, which
+// covers retries of the same request.
+func sameExpiry(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
 	}
-	return nil
+	diff := a.Sub(*b)
+	return diff > -time.Second && diff < time.Second
 }
 
-func generateShortCode(length int) string {
-	bytes := make([]byte, length*3/4+1)
-	_, _ = rand.Read(bytes)
-	return base64.URLEncoding.EncodeToString(bytes)[:length]
+// createWithGeneratedCode inserts urlEntity under freshly generated codes until one is free.
+// The unique constraint on urls.short_code is the only existence check: even collision-free
+// generators can hit a code already taken by a custom alias.
+func (s *service) createWithGeneratedCode(ctx context.Context, urlEntity *entity.URL) error {
+	for i := 0; i < maxCodeAttempts; i++ {
+		code, err := s.codes.Generate(ctx, CodeInput{Attempt: i})
+		if err != nil {
+			return appErrors.Invalid(appErrors.ErrCodeInternal, map[string]interface{}{"Message": "failed to generate short code"})
+		}
+		// Generated codes must not shadow routes such as /metrics.
+		if s.aliases.IsReserved(code) {
+			continue
+		}
+
+		urlEntity.ShortCode = code
+		err = s.repo.CreateURL(ctx, urlEntity)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, storage.ErrDuplicate) {
+			return appErrors.Invalid(appErrors.ErrCodeInternal, map[string]interface{}{"Message": "failed to create URL"})
+		}
+	}
+	return appErrors.Invalid(appErrors.ErrCodeShortCodeGeneration, map[string]interface{}{"Attempts": maxCodeAttempts})
 }
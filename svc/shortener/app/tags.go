@@ -0,0 +1,50 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	appErrors "url-shorterner/internal/errors"
+)
+
+const (
+	// maxTagLength matches the tags.name column.
+	maxTagLength = 64
+	// maxTagsPerURL bounds how many tags a single link may carry.
+	maxTagsPerURL = 20
+)
+
+// normalizeTags trims and lowercases tags, dropping empty and duplicate entries.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]struct{}, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, appErrors.Invalid(appErrors.ErrCodeInvalidTags, map[string]interface{}{
+				"MaxLength": maxTagLength,
+				"MaxCount":  maxTagsPerURL,
+			})
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxTagsPerURL {
+		return nil, appErrors.Invalid(appErrors.ErrCodeInvalidTags, map[string]interface{}{
+			"MaxLength": maxTagLength,
+			"MaxCount":  maxTagsPerURL,
+		})
+	}
+	return normalized, nil
+}
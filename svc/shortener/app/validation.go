@@ -0,0 +1,250 @@
+// Package app provides the core business logic for URL shortening operations.
+package app
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shorterner/internal/blocklist"
+	appErrors "url-shorterner/internal/errors"
+)
+
+// hostLookupTimeout bounds DNS resolution of destination hosts during validation.
+const hostLookupTimeout = 2 * time.Second
+
+// reservedPrefixes are special-purpose IPv4 ranges not covered by netip's predicates.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this network" (RFC 791)
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT (RFC 6598)
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking (RFC 2544)
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved and limited broadcast (RFC 1112, RFC 919)
+}
+
+// IPv6 ranges that carry an IPv4 address, which is checked like the address itself.
+var (
+	// nat64Prefix is the well-known NAT64 prefix (RFC 6052); the IPv4 address is the last 32 bits.
+	nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+	// localNAT64Prefix is the local-use NAT64 prefix (RFC 8215), with the IPv4 address last.
+	localNAT64Prefix = netip.MustParsePrefix("64:ff9b:1::/48")
+	// sixToFourPrefix is 6to4 (RFC 3056); the IPv4 address follows the prefix.
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")
+)
+
+// URLPolicy configures which destinations are accepted by the URL validator.
+type URLPolicy struct {
+	// BlockPrivateAddresses rejects private, loopback, link-local and other non-public destinations.
+	BlockPrivateAddresses bool
+	// BlockNonDefaultPorts rejects explicit ports other than 80 for http and 443 for https.
+	BlockNonDefaultPorts bool
+	// ResolveHosts resolves hostnames and rejects those pointing at non-public addresses.
+	ResolveHosts bool
+}
+
+// URLValidator validates destination URLs before they are shortened.
+type URLValidator struct {
+	policy    URLPolicy
+	blocklist *blocklist.List
+	selfHost  string
+	lookupIPs func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// NewURLValidator creates a validator that also rejects links pointing back at domain
+// or matching the abuse blocklist.
+func NewURLValidator(domain string, policy URLPolicy, blocklist *blocklist.List) *URLValidator {
+	selfHost := ""
+	if parsed, err := url.Parse(domain); err == nil {
+		selfHost = normalizeHost(parsed.Hostname())
+	}
+	return &URLValidator{
+		policy:    policy,
+		blocklist: blocklist,
+		selfHost:  selfHost,
+		lookupIPs: net.DefaultResolver.LookupIPAddr,
+	}
+}
+
+// Validate checks that u is an acceptable destination URL.
+func (v *URLValidator) Validate(ctx context.Context, u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return appErrors.Invalid(appErrors.ErrCodeInvalidURLFormat, nil)
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return appErrors.Invalid(appErrors.ErrCodeInvalidURLScheme, nil)
+	}
+	if parsed.User != nil {
+		return appErrors.Invalid(appErrors.ErrCodeURLCredentials, nil)
+	}
+
+	host := normalizeHost(parsed.Hostname())
+	if host == "" {
+		return appErrors.Invalid(appErrors.ErrCodeInvalidURLFormat, nil)
+	}
+	if v.selfHost != "" && host == v.selfHost {
+		return appErrors.Invalid(appErrors.ErrCodeURLSelfReference, nil)
+	}
+
+	if v.IsBlocked(u) {
+		return appErrors.Invalid(appErrors.ErrCodeURLBlocked, nil)
+	}
+
+	if port := parsed.Port(); port != "" && v.policy.BlockNonDefaultPorts {
+		if !isDefaultPort(scheme, port) {
+			return appErrors.Invalid(appErrors.ErrCodeURLPortNotAllowed, map[string]interface{}{"Port": port})
+		}
+	}
+
+	if !v.policy.BlockPrivateAddresses {
+		return nil
+	}
+	return v.validateHostAddresses(ctx, host)
+}
+
+// IsBlocked reports whether u matches the abuse blocklist.
+func (v *URLValidator) IsBlocked(u string) bool {
+	_, blocked := v.blocklist.Match(u)
+	return blocked
+}
+
+// validateHostAddresses rejects IP literals (including legacy numeric IPv4 forms browsers accept)
+// and, when enabled, hostnames resolving to non-public addresses.
+func (v *URLValidator) validateHostAddresses(ctx context.Context, host string) error {
+	if addr, ok := parseIPLiteral(host); ok {
+		if !isPublicAddr(addr) {
+			return appErrors.Invalid(appErrors.ErrCodeURLPrivateAddress, nil)
+		}
+		return nil
+	}
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return appErrors.Invalid(appErrors.ErrCodeURLPrivateAddress, nil)
+	}
+	if !v.policy.ResolveHosts {
+		return nil
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, hostLookupTimeout)
+	defer cancel()
+	addrs, err := v.lookupIPs(lookupCtx, host)
+	if err != nil || len(addrs) == 0 {
+		return appErrors.Invalid(appErrors.ErrCodeURLUnresolvableHost, nil)
+	}
+	for _, ipAddr := range addrs {
+		addr, ok := netip.AddrFromSlice(ipAddr.IP)
+		if !ok || !isPublicAddr(addr.Unmap()) {
+			return appErrors.Invalid(appErrors.ErrCodeURLPrivateAddress, nil)
+		}
+	}
+	return nil
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+func isDefaultPort(scheme, port string) bool {
+	return (scheme == "http" && port == "80") || (scheme == "https" && port == "443")
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	if embedded, ok := embeddedIPv4(addr); ok {
+		return isPublicAddr(embedded)
+	}
+	return true
+}
+
+// embeddedIPv4 returns the IPv4 address carried by a NAT64 or 6to4 address, which reaches that
+// IPv4 host through a translator or relay.
+func embeddedIPv4(addr netip.Addr) (netip.Addr, bool) {
+	b := addr.As16()
+	switch {
+	case !addr.Is6():
+		return netip.Addr{}, false
+	case nat64Prefix.Contains(addr), localNAT64Prefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[12:16])), true
+	case sixToFourPrefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[2:6])), true
+	default:
+		return netip.Addr{}, false
+	}
+}
+
+// parseIPLiteral parses standard IP literals as well as the legacy inet_aton forms
+// (e.g. "2130706433", "0x7f.1", "0177.0.0.1") that resolve to IPv4 addresses in browsers.
+func parseIPLiteral(host string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return addr.Unmap(), true
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return netip.Addr{}, false
+	}
+	values := make([]uint64, len(parts))
+	for i, part := range parts {
+		value, ok := parseIPv4Part(part)
+		if !ok {
+			return netip.Addr{}, false
+		}
+		values[i] = value
+	}
+
+	// The last part fills all remaining bytes; earlier parts are single bytes.
+	var ip uint64
+	for i, value := range values[:len(values)-1] {
+		if value > 0xFF {
+			return netip.Addr{}, false
+		}
+		ip |= value << (8 * (3 - i))
+	}
+	last := values[len(values)-1]
+	if last >= 1<<(8*(5-len(values))) {
+		return netip.Addr{}, false
+	}
+	ip |= last
+
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)}), true
+}
+
+// parseIPv4Part parses a part of a legacy IPv4 literal: hexadecimal with a 0x prefix, octal with
+// a leading zero, decimal otherwise. Unlike strconv's base prefixes, 0b, 0o and underscores are
+// not numbers to browsers.
+func parseIPv4Part(part string) (uint64, bool) {
+	base := 10
+	switch {
+	case len(part) >= 2 && (part[:2] == "0x" || part[:2] == "0X"):
+		part, base = part[2:], 16
+		if part == "" {
+			return 0, true
+		}
+	case len(part) > 1 && part[0] == '0':
+		part, base = part[1:], 8
+	}
+	if part == "" || strings.ContainsAny(part, "_+-") {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(part, base, 32)
+	return value, err == nil
+}
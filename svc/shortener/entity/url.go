@@ -1,15 +1,128 @@
 // Package entity defines domain entities for the shortener service.
 package entity
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // URL represents a shortened URL entity.
 type URL struct {
 	ID          string
 	ShortCode   string
 	OriginalURL string
-	ExpiresAt   *time.Time
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// CanonicalURL is the normalized destination used for dedup and grouping; redirects use OriginalURL.
+	CanonicalURL string
+	// Owner scopes deterministic codes; empty for anonymous links.
+	Owner     string
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Title, Description and Metadata are user-supplied details; Metadata is a JSON object.
+	Title       string
+	Description string
+	Metadata    json.RawMessage
+	// Tags are lowercase labels attached through the url_tags table.
+	Tags []string
+	// CampaignID is the campaign the URL belongs to, if any.
+	CampaignID *string
+	// Version is incremented by every change recorded in url_versions.
+	Version int
+	// BlockedAt is set when the destination was flagged by the abuse blocklist.
+	BlockedAt     *time.Time
+	BlockedReason string
 }
 
+// URLStats holds aggregate click statistics of a URL for exports.
+type URLStats struct {
+	TotalClicks int64
+	UniqueIPs   int64
+	LastClick   *time.Time
+}
+
+// URLFilter selects URLs for listing, newest first.
+type URLFilter struct {
+	// Tags keeps URLs carrying all of the given tags.
+	Tags []string
+	// Owner keeps URLs of the given owner when set.
+	Owner *string
+	// CampaignID keeps URLs of the given campaign when set.
+	CampaignID *string
+	// After continues a listing after the URL at this position when set.
+	After *URLPosition
+	Limit int
+}
+
+// URLPosition is the position of a URL in a listing, newest first.
+type URLPosition struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// VersionAction is the kind of change that created a URL version.
+type VersionAction string
+
+const (
+	// VersionActionCreate is the first version of a URL.
+	VersionActionCreate VersionAction = "create"
+	// VersionActionUpdate is a change of destination, expiry or settings.
+	VersionActionUpdate VersionAction = "update"
+	// VersionActionRollback restores an earlier version.
+	VersionActionRollback VersionAction = "rollback"
+)
+
+// URLUpdate changes a URL and records the result as a new version; nil fields are left unchanged.
+// A non-nil empty Tags removes all tags.
+type URLUpdate struct {
+	OriginalURL  *string
+	CanonicalURL *string
+	ExpiresAt    *time.Time
+	// ClearExpiry removes the expiry; it takes precedence over ExpiresAt.
+	ClearExpiry bool
+	Title       *string
+	Description *string
+	Tags        []string
+	Metadata    json.RawMessage
+
+	// Actor identifies who made the change.
+	Actor  string
+	Action VersionAction
+	// RolledBackFrom is the version restored by a rollback.
+	RolledBackFrom *int
+}
+
+// URLSettings are the settings of a URL captured by each version.
+type URLSettings struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Tags        []string        `json:"tags"`
+	Metadata    json.RawMessage `json:"metadata"`
+}
+
+// URLVersion is a snapshot of a URL after a change.
+type URLVersion struct {
+	Version      int
+	OriginalURL  string
+	CanonicalURL string
+	ExpiresAt    *time.Time
+	Settings     URLSettings
+	Actor        string
+	Action       VersionAction
+	// RolledBackFrom is the version restored by a rollback.
+	RolledBackFrom *int
+	CreatedAt      time.Time
+	// Clicks is the number of clicks served by this version.
+	Clicks int64
+}
+
+// TagCount is the number of links carrying a tag
+//
+// swagger:model TagCount
+type TagCount struct {
+	// Tag name
+	// example: campaign
+	Name string `json:"name"`
+
+	// Number of links carrying the tag
+	Links int64 `json:"links"`
+}
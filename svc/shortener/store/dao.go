@@ -3,8 +3,9 @@ package store
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"time"
+	"fmt"
 
 	"url-shorterner/internal/storage"
 	"url-shorterner/svc/shortener/entity"
@@ -16,9 +17,18 @@ import (
 // DAO defines the data access interface for shortener read operations.
 type DAO interface {
 	GetURLByShortCode(ctx context.Context, shortCode string) (*entity.URL, error)
-	CheckShortCodeExists(ctx context.Context, shortCode string) (bool, error)
+	ListUnblockedURLs(ctx context.Context, afterID string, limit int) ([]*entity.URL, error)
+	ListURLs(ctx context.Context, filter entity.URLFilter) ([]*entity.URL, error)
+	ListTags(ctx context.Context) ([]*entity.TagCount, error)
+	// ListURLVersions returns the versions of a URL, newest first, with the clicks each one served.
+	ListURLVersions(ctx context.Context, shortCode string) ([]*entity.URLVersion, error)
+	GetURLVersion(ctx context.Context, shortCode string, version int) (*entity.URLVersion, error)
+	StreamURLs(ctx context.Context, withStats bool, fn func(url *entity.URL, stats *entity.URLStats) error) error
 }
 
+// streamFetchSize is the number of rows fetched from a server-side cursor per round trip.
+const streamFetchSize = 500
+
 type dao struct {
 	db *pgxpool.Pool
 }
@@ -29,45 +39,363 @@ func NewDAO(db *pgxpool.Pool) DAO {
 }
 
 func (d *dao) GetURLByShortCode(ctx context.Context, shortCode string) (*entity.URL, error) {
+	return getURLByShortCode(ctx, d.db, shortCode)
+}
+
+// urlColumns are the columns scanned by scanURL, selected from urls aliased as u.
+const urlColumns = `
+	u.id, u.short_code, u.original_url, u.canonical_url, u.owner, u.title, u.description, u.metadata,
+	u.expires_at, u.created_at, u.updated_at, u.blocked_at, u.blocked_reason, u.campaign_id, u.version,
+	ARRAY(
+		SELECT t.name FROM url_tags ut JOIN tags t ON t.id = ut.tag_id
+		WHERE ut.url_id = u.id ORDER BY t.name
+	)
+`
+
+func getURLByShortCode(ctx context.Context, db querier, shortCode string) (*entity.URL, error) {
 	query := `
-		SELECT id, short_code, original_url, expires_at, created_at, updated_at
-		FROM urls
-		WHERE short_code = @short_code
+		SELECT ` + urlColumns + `
+		FROM urls u
+		WHERE u.short_code = @short_code
 	`
 	args := pgx.NamedArgs{
 		"short_code": shortCode,
 	}
 
+	url, err := scanURL(db.QueryRow(ctx, query, args))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	return url, err
+}
+
+func scanURL(row pgx.Row) (*entity.URL, error) {
 	var url entity.URL
-	var expiresAt *time.Time
-	err := d.db.QueryRow(ctx, query, args).Scan(
+	var blockedReason *string
+	var metadata []byte
+	err := row.Scan(
 		&url.ID,
 		&url.ShortCode,
 		&url.OriginalURL,
-		&expiresAt,
+		&url.CanonicalURL,
+		&url.Owner,
+		&url.Title,
+		&url.Description,
+		&metadata,
+		&url.ExpiresAt,
 		&url.CreatedAt,
 		&url.UpdatedAt,
+		&url.BlockedAt,
+		&blockedReason,
+		&url.CampaignID,
+		&url.Version,
+		&url.Tags,
 	)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, storage.ErrNotFound
-		}
 		return nil, err
 	}
 
-	url.ExpiresAt = expiresAt
+	url.Metadata = metadata
+	if blockedReason != nil {
+		url.BlockedReason = *blockedReason
+	}
 	return &url, nil
 }
 
-func (d *dao) CheckShortCodeExists(ctx context.Context, shortCode string) (bool, error) {
+// ListURLs returns up to filter.Limit URLs matching filter, newest first.
+func (d *dao) ListURLs(ctx context.Context, filter entity.URLFilter) ([]*entity.URL, error) {
+	query := `
+		SELECT ` + urlColumns + `
+		FROM urls u
+		WHERE (@owner::text IS NULL OR u.owner = @owner)
+			AND (@campaign_id::uuid IS NULL OR u.campaign_id = @campaign_id)
+			AND (@after_created_at::timestamptz IS NULL
+				OR (u.created_at, u.id) < (@after_created_at, @after_id::uuid))
+			AND cardinality(@tags::text[]) = (
+				SELECT COUNT(*) FROM url_tags ut JOIN tags t ON t.id = ut.tag_id
+				WHERE ut.url_id = u.id AND t.name = ANY(@tags::text[])
+			)
+		ORDER BY u.created_at DESC, u.id DESC
+		LIMIT @limit
+	`
+	args := pgx.NamedArgs{
+		"owner":            filter.Owner,
+		"campaign_id":      filter.CampaignID,
+		"after_created_at": nil,
+		"after_id":         nil,
+		"tags":             tagsArg(filter.Tags),
+		"limit":            filter.Limit,
+	}
+	if filter.After != nil {
+		args["after_created_at"] = filter.After.CreatedAt
+		args["after_id"] = filter.After.ID
+	}
+
+	rows, err := d.db.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	urls := make([]*entity.URL, 0, filter.Limit)
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, rows.Err()
+}
+
+// urlVersionColumns are the columns scanned by scanURLVersion, selected from urlVersionFrom.
+const urlVersionColumns = `
+	v.version, v.original_url, v.canonical_url, v.expires_at, v.settings, v.actor, v.action,
+	v.rolled_back_from, v.created_at, COALESCE(c.clicks, 0)
+`
+
+// urlVersionFrom joins the versions of the link @short_code with the clicks each one served,
+// counted in one pass over the link's clicks.
+const urlVersionFrom = `
+	FROM url_versions v
+	JOIN urls u ON u.id = v.url_id
+	LEFT JOIN (
+		SELECT url_version, COUNT(*) AS clicks
+		FROM analytics
+		WHERE short_code = @short_code
+		GROUP BY url_version
+	) c ON c.url_version = v.version
+`
+
+func (d *dao) ListURLVersions(ctx context.Context, shortCode string) ([]*entity.URLVersion, error) {
+	query := `
+		SELECT ` + urlVersionColumns + urlVersionFrom + `
+		WHERE u.short_code = @short_code
+		ORDER BY v.version DESC
+	`
+	rows, err := d.db.Query(ctx, query, pgx.NamedArgs{"short_code": shortCode})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make([]*entity.URLVersion, 0)
+	for rows.Next() {
+		version, err := scanURLVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+func (d *dao) GetURLVersion(ctx context.Context, shortCode string, version int) (*entity.URLVersion, error) {
 	query := `
-		SELECT EXISTS(SELECT 1 FROM urls WHERE short_code = @short_code)
+		SELECT ` + urlVersionColumns + urlVersionFrom + `
+		WHERE u.short_code = @short_code AND v.version = @version
 	`
 	args := pgx.NamedArgs{
 		"short_code": shortCode,
+		"version":    version,
+	}
+	urlVersion, err := scanURLVersion(d.db.QueryRow(ctx, query, args))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrNotFound
 	}
+	return urlVersion, err
+}
+
+func scanURLVersion(row pgx.Row) (*entity.URLVersion, error) {
+	var version entity.URLVersion
+	var settings []byte
+	err := row.Scan(
+		&version.Version,
+		&version.OriginalURL,
+		&version.CanonicalURL,
+		&version.ExpiresAt,
+		&settings,
+		&version.Actor,
+		&version.Action,
+		&version.RolledBackFrom,
+		&version.CreatedAt,
+		&version.Clicks,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(settings, &version.Settings); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// ListTags returns every tag in use with its number of links, most used first.
+func (d *dao) ListTags(ctx context.Context) ([]*entity.TagCount, error) {
+	query := `
+		SELECT t.name, COUNT(*) AS links
+		FROM tags t
+		JOIN url_tags ut ON ut.tag_id = t.id
+		GROUP BY t.name
+		ORDER BY links DESC, t.name
+	`
+	rows, err := d.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*entity.TagCount
+	for rows.Next() {
+		var tag entity.TagCount
+		if err := rows.Scan(&tag.Name, &tag.Links); err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+	return tags, rows.Err()
+}
+
+// ListUnblockedURLs returns up to limit URLs not yet flagged by the blocklist, ordered by ID.
+// Pass the last returned ID as afterID to fetch the next page.
+func (d *dao) ListUnblockedURLs(ctx context.Context, afterID string, limit int) ([]*entity.URL, error) {
+	query := `
+		SELECT id, short_code, original_url, canonical_url, expires_at, created_at, updated_at
+		FROM urls
+		WHERE blocked_at IS NULL AND (@after_id = '' OR id > @after_id::uuid)
+		ORDER BY id
+		LIMIT @limit
+	`
+	args := pgx.NamedArgs{
+		"after_id": afterID,
+		"limit":    limit,
+	}
+
+	rows, err := d.db.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	urls := make([]*entity.URL, 0, limit)
+	for rows.Next() {
+		var url entity.URL
+		err := rows.Scan(
+			&url.ID,
+			&url.ShortCode,
+			&url.OriginalURL,
+			&url.CanonicalURL,
+			&url.ExpiresAt,
+			&url.CreatedAt,
+			&url.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, &url)
+	}
+
+	return urls, rows.Err()
+}
+
+// StreamURLs calls fn for every URL in creation order, reading through a server-side cursor
+// so memory use does not grow with the table. stats is nil unless withStats is set.
+// Returning an error from fn stops the stream.
+func (d *dao) StreamURLs(ctx context.Context, withStats bool, fn func(url *entity.URL, stats *entity.URLStats) error) error {
+	statsColumns, statsJoin := "", ""
+	if withStats {
+		// Human clicks read like the analytics stats: complete days from the daily rollups, the
+		// rest of the current day from the hourly ones and clicks after the watermark from the
+		// raw table, whose IPs already counted in analytics_unique_ips are not counted again.
+		statsColumns = ", s.total_clicks, s.unique_ips, s.last_click"
+		statsJoin = `
+			CROSS JOIN (
+				SELECT rolled_up_to, date_trunc('day', rolled_up_to, 'UTC') AS rolled_up_day
+				FROM analytics_rollup_state
+			) state
+			LEFT JOIN LATERAL (
+				SELECT
+					days.clicks + hours.clicks + tail.clicks AS total_clicks,
+					(
+						SELECT COUNT(*) FROM analytics_unique_ips i
+						WHERE i.short_code = u.short_code AND i.human
+					) + tail.unique_ips AS unique_ips,
+					GREATEST(days.last_click, hours.last_click, tail.last_click) AS last_click
+				FROM (
+					SELECT COALESCE(SUM(human_clicks), 0)::bigint AS clicks, MAX(human_last_click) AS last_click
+					FROM analytics_daily r
+					WHERE r.short_code = u.short_code AND r.bucket < state.rolled_up_day
+				) days, (
+					SELECT COALESCE(SUM(human_clicks), 0)::bigint AS clicks, MAX(human_last_click) AS last_click
+					FROM analytics_hourly r
+					WHERE r.short_code = u.short_code
+						AND r.bucket >= state.rolled_up_day AND r.bucket < state.rolled_up_to
+				) hours, (
+					SELECT
+						COUNT(*) AS clicks,
+						COUNT(DISTINCT a.ip_address) FILTER (WHERE NOT EXISTS (
+							SELECT 1 FROM analytics_unique_ips i
+							WHERE i.short_code = a.short_code AND i.ip_address = a.ip_address AND i.human
+						)) AS unique_ips,
+						MAX(a.clicked_at) AS last_click
+					FROM analytics a
+					WHERE a.short_code = u.short_code AND a.clicked_at >= state.rolled_up_to AND NOT a.is_bot
+				) tail
+			) s ON TRUE`
+	}
+	query := `
+		DECLARE export_urls NO SCROLL CURSOR FOR
+		SELECT u.id, u.short_code, u.original_url, u.canonical_url, u.owner, u.title, u.description, u.metadata,
+			u.expires_at, u.created_at, u.updated_at, u.blocked_at,
+			ARRAY(
+				SELECT t.name FROM url_tags ut JOIN tags t ON t.id = ut.tag_id
+				WHERE ut.url_id = u.id ORDER BY t.name
+			)` + statsColumns + `
+		FROM urls u` + statsJoin + `
+		ORDER BY u.created_at, u.id
+	`
 
-	var exists bool
-	err := d.db.QueryRow(ctx, query, args).Scan(&exists)
-	return exists, err
+	// Cursors only live inside a transaction; read-only keeps it safe on replicas.
+	return pgx.BeginTxFunc(ctx, d.db, pgx.TxOptions{AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, query); err != nil {
+			return err
+		}
+		for {
+			rows, err := tx.Query(ctx, fmt.Sprintf("FETCH FORWARD %d FROM export_urls", streamFetchSize))
+			if err != nil {
+				return err
+			}
+			n := 0
+			for rows.Next() {
+				n++
+				var url entity.URL
+				var metadata []byte
+				dest := []any{
+					&url.ID, &url.ShortCode, &url.OriginalURL, &url.CanonicalURL, &url.Owner, &url.Title, &url.Description,
+					&metadata, &url.ExpiresAt, &url.CreatedAt, &url.UpdatedAt, &url.BlockedAt, &url.Tags,
+				}
+				var stats *entity.URLStats
+				if withStats {
+					stats = &entity.URLStats{}
+					dest = append(dest, &stats.TotalClicks, &stats.UniqueIPs, &stats.LastClick)
+				}
+				if err := rows.Scan(dest...); err != nil {
+					rows.Close()
+					return err
+				}
+				url.Metadata = metadata
+				if err := fn(&url, stats); err != nil {
+					rows.Close()
+					return err
+				}
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			if n < streamFetchSize {
+				return nil
+			}
+		}
+	})
 }
@@ -3,20 +3,44 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"url-shorterner/internal/storage"
 	"url-shorterner/svc/shortener/entity"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Repository defines the interface for shortener write operations.
 type Repository interface {
 	CreateURL(ctx context.Context, url *entity.URL) error
+	CreateURLs(ctx context.Context, urls []*entity.URL) (map[string]bool, error)
+	GetURLByShortCode(ctx context.Context, shortCode string) (*entity.URL, error)
+	// UpdateURL applies update, records the result as a new version and returns the updated URL,
+	// or storage.ErrNotFound.
+	UpdateURL(ctx context.Context, shortCode string, update entity.URLUpdate) (*entity.URL, error)
+	BlockURL(ctx context.Context, shortCode, reason string, blockedAt time.Time) error
+	LeaseCodeRange(ctx context.Context, name string, size int64) (int64, error)
+	// WithTx runs fn with a repository bound to a single transaction, committing if fn returns nil.
+	WithTx(ctx context.Context, fn func(repo Repository) error) error
+}
+
+// querier is satisfied by both the pool and a transaction.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
 type repository struct {
-	db *pgxpool.Pool
+	db querier
 }
 
 // NewRepository creates a new shortener repository instance.
@@ -24,19 +48,371 @@ func NewRepository(db *pgxpool.Pool) Repository {
 	return &repository{db: db}
 }
 
+func (r *repository) WithTx(ctx context.Context, fn func(repo Repository) error) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return fn(&repository{db: tx})
+	})
+}
+
 func (r *repository) CreateURL(ctx context.Context, url *entity.URL) error {
+	// Data-modifying CTEs keep the URL and its tags in one statement: a duplicate short code
+	// fails the whole insert.
+	query := `
+		WITH new_url AS (
+			INSERT INTO urls (
+				id, short_code, original_url, canonical_url, owner, title, description, metadata,
+				expires_at, created_at, updated_at
+			)
+			VALUES (
+				@id, @short_code, @original_url, @canonical_url, @owner, @title, @description, @metadata::jsonb,
+				@expires_at, @created_at, @updated_at
+			)
+			RETURNING id
+		), first_version AS (
+			INSERT INTO url_versions (url_id, version, original_url, canonical_url, expires_at, settings, actor, action, created_at)
+			SELECT id, 1, @original_url, @canonical_url, @expires_at, @settings::jsonb, @owner, @action, @created_at
+			FROM new_url
+		), url_tag_ids AS (
+			INSERT INTO tags (name)
+			SELECT DISTINCT unnest(@tags::text[])
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id
+		)
+		INSERT INTO url_tags (url_id, tag_id)
+		SELECT new_url.id, url_tag_ids.id FROM new_url CROSS JOIN url_tag_ids
+	`
+	args := pgx.NamedArgs{
+		"id":            url.ID,
+		"short_code":    url.ShortCode,
+		"original_url":  url.OriginalURL,
+		"canonical_url": url.CanonicalURL,
+		"owner":         url.Owner,
+		"title":         url.Title,
+		"description":   url.Description,
+		"metadata":      metadataArg(url.Metadata),
+		"expires_at":    url.ExpiresAt,
+		"created_at":    url.CreatedAt,
+		"updated_at":    url.UpdatedAt,
+		"tags":          tagsArg(url.Tags),
+		"settings":      settingsArg(url),
+		"action":        entity.VersionActionCreate,
+	}
+	_, err := r.db.Exec(ctx, query, args)
+	if err == nil {
+		url.Version = 1
+	}
+	return storage.MapError(err)
+}
+
+// CreateURLs inserts urls in a single multi-row statement. Rows whose short code is already
+// taken, by an existing row or an earlier row of the same batch, are skipped.
+// It returns the IDs of the inserted rows. Tags and first versions are written in further
+// statements, so callers should use WithTx.
+func (r *repository) CreateURLs(ctx context.Context, urls []*entity.URL) (map[string]bool, error) {
+	inserted := make(map[string]bool, len(urls))
+	if len(urls) == 0 {
+		return inserted, nil
+	}
+
+	values := make([]string, len(urls))
+	args := make(pgx.NamedArgs, len(urls)*11)
+	for i, url := range urls {
+		values[i] = fmt.Sprintf(
+			"(@id%[1]d, @short_code%[1]d, @original_url%[1]d, @canonical_url%[1]d, @owner%[1]d, @title%[1]d, "+
+				"@description%[1]d, @metadata%[1]d::jsonb, @expires_at%[1]d, @created_at%[1]d, @updated_at%[1]d)", i,
+		)
+		args[fmt.Sprintf("id%d", i)] = url.ID
+		args[fmt.Sprintf("short_code%d", i)] = url.ShortCode
+		args[fmt.Sprintf("original_url%d", i)] = url.OriginalURL
+		args[fmt.Sprintf("canonical_url%d", i)] = url.CanonicalURL
+		args[fmt.Sprintf("owner%d", i)] = url.Owner
+		args[fmt.Sprintf("title%d", i)] = url.Title
+		args[fmt.Sprintf("description%d", i)] = url.Description
+		args[fmt.Sprintf("metadata%d", i)] = metadataArg(url.Metadata)
+		args[fmt.Sprintf("expires_at%d", i)] = url.ExpiresAt
+		args[fmt.Sprintf("created_at%d", i)] = url.CreatedAt
+		args[fmt.Sprintf("updated_at%d", i)] = url.UpdatedAt
+	}
+	query := `
+		INSERT INTO urls (
+			id, short_code, original_url, canonical_url, owner, title, description, metadata,
+			expires_at, created_at, updated_at
+		)
+		VALUES ` + strings.Join(values, ", ") + `
+		ON CONFLICT (short_code) DO NOTHING
+		RETURNING id
+	`
+
+	rows, err := r.db.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		inserted[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var urlIDs, tagNames []string
+	var created []*entity.URL
+	for _, url := range urls {
+		if !inserted[url.ID] {
+			continue
+		}
+		url.Version = 1
+		created = append(created, url)
+		for _, tag := range url.Tags {
+			urlIDs = append(urlIDs, url.ID)
+			tagNames = append(tagNames, tag)
+		}
+	}
+	if len(urlIDs) > 0 {
+		if err := r.attachTags(ctx, urlIDs, tagNames); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.insertFirstVersions(ctx, created); err != nil {
+		return nil, err
+	}
+	return inserted, nil
+}
+
+// insertFirstVersions records version 1 of newly created urls.
+func (r *repository) insertFirstVersions(ctx context.Context, urls []*entity.URL) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	ids := make([]string, len(urls))
+	originals := make([]string, len(urls))
+	canonicals := make([]string, len(urls))
+	expiries := make([]*time.Time, len(urls))
+	settings := make([]string, len(urls))
+	actors := make([]string, len(urls))
+	createdAt := make([]time.Time, len(urls))
+	for i, url := range urls {
+		ids[i] = url.ID
+		originals[i] = url.OriginalURL
+		canonicals[i] = url.CanonicalURL
+		expiries[i] = url.ExpiresAt
+		settings[i] = settingsArg(url)
+		actors[i] = url.Owner
+		createdAt[i] = url.CreatedAt
+	}
+
 	query := `
-		INSERT INTO urls (id, short_code, original_url, expires_at, created_at, updated_at)
-		VALUES (@id, @short_code, @original_url, @expires_at, @created_at, @updated_at)
+		INSERT INTO url_versions (url_id, version, original_url, canonical_url, expires_at, settings, actor, action, created_at)
+		SELECT v.id, 1, v.original_url, v.canonical_url, v.expires_at, v.settings, v.actor, @action, v.created_at
+		FROM unnest(
+			@ids::uuid[], @original_urls::text[], @canonical_urls::text[], @expires_at::timestamptz[],
+			@settings::jsonb[], @actors::text[], @created_at::timestamptz[]
+		) AS v(id, original_url, canonical_url, expires_at, settings, actor, created_at)
 	`
 	args := pgx.NamedArgs{
-		"id":           url.ID,
-		"short_code":   url.ShortCode,
-		"original_url": url.OriginalURL,
-		"expires_at":   url.ExpiresAt,
-		"created_at":   url.CreatedAt,
-		"updated_at":   url.UpdatedAt,
+		"ids":            ids,
+		"original_urls":  originals,
+		"canonical_urls": canonicals,
+		"expires_at":     expiries,
+		"settings":       settings,
+		"actors":         actors,
+		"created_at":     createdAt,
+		"action":         entity.VersionActionCreate,
 	}
 	_, err := r.db.Exec(ctx, query, args)
 	return err
 }
+
+// settingsArg encodes the settings of url as recorded in url_versions.
+func settingsArg(url *entity.URL) string {
+	settings := entity.URLSettings{
+		Title:       url.Title,
+		Description: url.Description,
+		Tags:        tagsArg(url.Tags),
+		Metadata:    json.RawMessage(metadataArg(url.Metadata)),
+	}
+	encoded, err := json.Marshal(settings)
+	if err != nil {
+		return "{}"
+	}
+	return string(encoded)
+}
+
+// attachTags links urlIDs[i] to tagNames[i], creating missing tags.
+func (r *repository) attachTags(ctx context.Context, urlIDs, tagNames []string) error {
+	query := `
+		WITH pairs AS (
+			SELECT * FROM unnest(@url_ids::uuid[], @tag_names::text[]) AS p(url_id, name)
+		), url_tag_ids AS (
+			INSERT INTO tags (name)
+			SELECT DISTINCT name FROM pairs
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id, name
+		)
+		INSERT INTO url_tags (url_id, tag_id)
+		SELECT pairs.url_id, url_tag_ids.id FROM pairs JOIN url_tag_ids USING (name)
+		ON CONFLICT DO NOTHING
+	`
+	args := pgx.NamedArgs{
+		"url_ids":   urlIDs,
+		"tag_names": tagNames,
+	}
+	_, err := r.db.Exec(ctx, query, args)
+	return err
+}
+
+// tagsArg returns a non-nil slice so an empty tag list is sent as an empty array rather than NULL.
+func tagsArg(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// metadataArg returns the metadata JSON, defaulting to an empty object.
+func metadataArg(metadata []byte) string {
+	if len(metadata) == 0 {
+		return "{}"
+	}
+	return string(metadata)
+}
+
+func (r *repository) UpdateURL(ctx context.Context, shortCode string, update entity.URLUpdate) (*entity.URL, error) {
+	var updated *entity.URL
+	err := pgx.BeginFunc(ctx, r.db, func(dbTx pgx.Tx) error {
+		tx := &repository{db: dbTx}
+		// The blocklist flag is kept: a blocked link stays blocked whatever its destination.
+		query := `
+			UPDATE urls SET
+				original_url = COALESCE(@original_url, original_url),
+				canonical_url = COALESCE(@canonical_url, canonical_url),
+				expires_at = CASE WHEN @clear_expiry THEN NULL ELSE COALESCE(@expires_at, expires_at) END,
+				title = COALESCE(@title, title),
+				description = COALESCE(@description, description),
+				metadata = COALESCE(@metadata::jsonb, metadata),
+				version = version + 1,
+				updated_at = NOW()
+			WHERE short_code = @short_code
+			RETURNING id
+		`
+		args := pgx.NamedArgs{
+			"short_code":    shortCode,
+			"original_url":  update.OriginalURL,
+			"canonical_url": update.CanonicalURL,
+			"expires_at":    update.ExpiresAt,
+			"clear_expiry":  update.ClearExpiry,
+			"title":         update.Title,
+			"description":   update.Description,
+			"metadata":      nil,
+		}
+		if update.Metadata != nil {
+			args["metadata"] = string(update.Metadata)
+		}
+		var id string
+		if err := tx.db.QueryRow(ctx, query, args).Scan(&id); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return storage.ErrNotFound
+			}
+			return err
+		}
+
+		if update.Tags != nil {
+			if _, err := tx.db.Exec(ctx, `DELETE FROM url_tags WHERE url_id = @id`, pgx.NamedArgs{"id": id}); err != nil {
+				return err
+			}
+			if len(update.Tags) > 0 {
+				urlIDs := make([]string, len(update.Tags))
+				for i := range urlIDs {
+					urlIDs[i] = id
+				}
+				if err := tx.attachTags(ctx, urlIDs, update.Tags); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.recordVersion(ctx, id, update); err != nil {
+			return err
+		}
+
+		var err error
+		updated, err = getURLByShortCode(ctx, tx.db, shortCode)
+		return err
+	})
+	return updated, err
+}
+
+// recordVersion snapshots the current state of the URL as its latest version.
+func (r *repository) recordVersion(ctx context.Context, id string, update entity.URLUpdate) error {
+	query := `
+		INSERT INTO url_versions (
+			url_id, version, original_url, canonical_url, expires_at, settings, actor, action, rolled_back_from, created_at
+		)
+		SELECT u.id, u.version, u.original_url, u.canonical_url, u.expires_at,
+			jsonb_build_object(
+				'title', u.title,
+				'description', u.description,
+				'metadata', u.metadata,
+				'tags', to_jsonb(ARRAY(
+					SELECT t.name FROM url_tags ut JOIN tags t ON t.id = ut.tag_id WHERE ut.url_id = u.id ORDER BY t.name
+				))
+			),
+			@actor, @action, @rolled_back_from, u.updated_at
+		FROM urls u
+		WHERE u.id = @id
+	`
+	args := pgx.NamedArgs{
+		"id":               id,
+		"actor":            update.Actor,
+		"action":           update.Action,
+		"rolled_back_from": update.RolledBackFrom,
+	}
+	_, err := r.db.Exec(ctx, query, args)
+	return err
+}
+
+// GetURLByShortCode reads a URL from the primary (or the current transaction), for checks that
+// must see a conflicting row immediately after a failed insert regardless of replica lag.
+func (r *repository) GetURLByShortCode(ctx context.Context, shortCode string) (*entity.URL, error) {
+	return getURLByShortCode(ctx, r.db, shortCode)
+}
+
+func (r *repository) BlockURL(ctx context.Context, shortCode, reason string, blockedAt time.Time) error {
+	query := `
+		UPDATE urls
+		SET blocked_at = @blocked_at, blocked_reason = @blocked_reason, updated_at = @blocked_at
+		WHERE short_code = @short_code AND blocked_at IS NULL
+	`
+	args := pgx.NamedArgs{
+		"short_code":     shortCode,
+		"blocked_reason": reason,
+		"blocked_at":     blockedAt,
+	}
+	_, err := r.db.Exec(ctx, query, args)
+	return err
+}
+
+// LeaseCodeRange atomically reserves size consecutive counter values and returns the first one.
+// Counters start at 1; the row lock on code_ranges serializes concurrent leases across instances.
+func (r *repository) LeaseCodeRange(ctx context.Context, name string, size int64) (int64, error) {
+	query := `
+		INSERT INTO code_ranges (name, next_value, updated_at)
+		VALUES (@name, 1 + @size, NOW())
+		ON CONFLICT (name) DO UPDATE
+		SET next_value = code_ranges.next_value + @size, updated_at = NOW()
+		RETURNING next_value - @size
+	`
+	args := pgx.NamedArgs{
+		"name": name,
+		"size": size,
+	}
+
+	var start int64
+	err := r.db.QueryRow(ctx, query, args).Scan(&start)
+	return start, err
+}
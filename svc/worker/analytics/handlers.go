@@ -22,14 +22,7 @@ func NewEventHandlers(service app.Service) *EventHandlers {
 
 // HandleClickEvent processes a click event and records it in analytics.
 func (h *EventHandlers) HandleClickEvent(ctx context.Context, event events.ClickEvent) error {
-	err := h.service.RecordClick(
-		ctx,
-		event.ShortCode,
-		event.IPAddress,
-		event.UserAgent,
-		event.Referer,
-	)
-	if err != nil {
+	if err := h.service.RecordClick(ctx, event); err != nil {
 		log.Printf("Failed to record click event: %v", err)
 		return err
 	}
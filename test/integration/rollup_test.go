@@ -0,0 +1,141 @@
+// Package integration provides integration tests for the API.
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shorterner/internal/storage"
+	"url-shorterner/internal/uuid"
+	"url-shorterner/svc/analytics/entity"
+	analyticsStore "url-shorterner/svc/analytics/store"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollUpLateClicks(t *testing.T) {
+	ctx := context.Background()
+	pool, err := storage.NewDBPool(ctx, testCfg.DatabaseURL)
+	require.NoError(t, err)
+	defer pool.Close()
+	repo := analyticsStore.NewRepository(pool)
+	dao := analyticsStore.NewDAO(pool)
+
+	code := fmt.Sprintf("rollup-%d", time.Now().UnixNano())
+	tag := fmt.Sprintf("rollup-tag-%d", time.Now().UnixNano())
+	body, _ := json.Marshal(map[string]interface{}{"url": "https://example.com", "alias": code, "tags": []string{tag}})
+	req := httptest.NewRequest(http.MethodPost, "/shorten", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Bring the rollups up to date, so the clicks below land before the watermark. The rollup
+	// is driven by the database clock that stamps the clicks as recorded.
+	settled := dbNow(ctx, t, pool)
+	watermark := settled.Truncate(time.Hour)
+	for {
+		got, err := repo.RollUp(ctx, watermark, settled, 24*time.Hour)
+		require.NoError(t, err)
+		if !got.Before(watermark) {
+			break
+		}
+	}
+
+	clicks := []struct {
+		at      time.Time
+		ip      string
+		browser string
+		bot     bool
+	}{
+		{watermark.Add(-2*time.Hour + time.Minute), "10.0.0.1", "Firefox", false},
+		{watermark.Add(-2*time.Hour + 2*time.Minute), "10.0.0.1", "Chrome", true},
+		{watermark.Add(-time.Microsecond), "10.0.0.3", "Firefox", false},
+		{watermark, "10.0.0.2", "Firefox", false},
+	}
+	for _, click := range clicks {
+		require.NoError(t, repo.CreateAnalytics(ctx, &entity.Record{
+			ID:        uuid.Generate(),
+			ShortCode: code,
+			IPAddress: click.ip,
+			Browser:   click.browser,
+			IsBot:     click.bot,
+			ClickedAt: click.at,
+		}))
+	}
+
+	// Until they settle, late clicks are missing from the rollups; the click at the
+	// watermark is read from the raw clicks.
+	stats, err := dao.GetAnalyticsStats(ctx, code, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.TotalClicks)
+
+	// Settle every click recorded so far, then roll the last hours and the late clicks up
+	// again: buckets are recomputed, not added to.
+	now := dbNow(ctx, t, pool)
+	for run := 0; run < 2; run++ {
+		if run > 0 {
+			_, err := pool.Exec(ctx, `UPDATE analytics_rollup_state SET rolled_up_to = $1, settled_up_to = $2`,
+				watermark.Add(-3*time.Hour), settled)
+			require.NoError(t, err)
+		}
+		got, err := repo.RollUp(ctx, watermark, now, 24*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, watermark, got, "run %d", run)
+
+		stats, err := dao.GetAnalyticsStats(ctx, code, true)
+		require.NoError(t, err)
+		assert.Equal(t, 4, stats.TotalClicks, "run %d", run)
+		assert.Equal(t, 3, stats.UniqueIPs, "run %d", run)
+
+		stats, err = dao.GetAnalyticsStats(ctx, code, false)
+		require.NoError(t, err)
+		assert.Equal(t, 3, stats.TotalClicks, "run %d", run)
+		assert.Equal(t, 3, stats.UniqueIPs, "run %d", run)
+
+		points, err := dao.GetClickSeries(ctx, code, entity.SeriesQuery{
+			From:        watermark.Add(-3 * time.Hour),
+			To:          watermark.Add(time.Hour),
+			Interval:    entity.IntervalHour,
+			IncludeBots: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, points, 4, "run %d", run)
+		assert.Equal(t, []int{0, 2, 1, 1}, []int{points[0].Clicks, points[1].Clicks, points[2].Clicks, points[3].Clicks}, "run %d", run)
+		assert.Equal(t, 1, points[1].UniqueIPs, "run %d", run)
+
+		items, err := dao.GetBreakdown(ctx, code, entity.DimensionBrowser, 0, true)
+		require.NoError(t, err)
+		require.Len(t, items, 2, "run %d", run)
+		assert.Equal(t, entity.BreakdownItem{Value: "Firefox", Clicks: 3, UniqueIPs: 3}, *items[0], "run %d", run)
+		assert.Equal(t, entity.BreakdownItem{Value: "Chrome", Clicks: 1, UniqueIPs: 1}, *items[1], "run %d", run)
+
+		items, err = dao.GetBreakdown(ctx, code, entity.DimensionBrowser, 0, false)
+		require.NoError(t, err)
+		require.Len(t, items, 1, "run %d", run)
+		assert.Equal(t, "Firefox", items[0].Value, "run %d", run)
+
+		tags, err := dao.GetTagStats(ctx, []string{tag}, true)
+		require.NoError(t, err)
+		require.Len(t, tags, 1, "run %d", run)
+		assert.Equal(t, 1, tags[0].Links, "run %d", run)
+		assert.Equal(t, 4, tags[0].TotalClicks, "run %d", run)
+		assert.Equal(t, 3, tags[0].UniqueIPs, "run %d", run)
+	}
+}
+
+// dbNow returns the current time of the database clock.
+func dbNow(ctx context.Context, t *testing.T, pool *pgxpool.Pool) time.Time {
+	t.Helper()
+	var now time.Time
+	require.NoError(t, pool.QueryRow(ctx, `SELECT clock_timestamp()`).Scan(&now))
+	return now.UTC()
+}
@@ -5,18 +5,31 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
+	"url-shorterner/internal/access"
+	"url-shorterner/internal/apikey"
+	"url-shorterner/internal/blocklist"
 	"url-shorterner/internal/cache"
+	"url-shorterner/internal/clientip"
 	"url-shorterner/internal/config"
 	"url-shorterner/internal/events"
+	internalHTTP "url-shorterner/internal/http"
 	"url-shorterner/internal/middleware"
 	"url-shorterner/internal/rate"
 	"url-shorterner/internal/storage"
+	"url-shorterner/internal/wordlist"
 	analyticsApp "url-shorterner/svc/analytics/app"
 	analyticsStore "url-shorterner/svc/analytics/store"
 	analyticsTransport "url-shorterner/svc/api/analytics/transport"
+	campaignsTransport "url-shorterner/svc/api/campaigns/transport"
+	jobsTransport "url-shorterner/svc/api/jobs/transport"
 	shortenerTransport "url-shorterner/svc/api/shortener/transport"
+	campaignsApp "url-shorterner/svc/campaigns/app"
+	campaignsStore "url-shorterner/svc/campaigns/store"
+	jobsApp "url-shorterner/svc/jobs/app"
+	jobsStore "url-shorterner/svc/jobs/store"
 	shortenerApp "url-shorterner/svc/shortener/app"
 	shortenerStore "url-shorterner/svc/shortener/store"
 
@@ -24,6 +37,16 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// testBlocklistFile is the feed of the blocklist used by the test router. Tests may rewrite it
+// and call testBlocklist.Reload.
+var (
+	testBlocklistFile = filepath.Join(os.TempDir(), "url-shortener-test-blocklist.txt")
+	testBlocklist     = blocklist.NewList([]string{testBlocklistFile})
+)
+
+// testAPIKey is accepted by the test router as the key of "tester".
+const testAPIKey = "test-api-key"
+
 // SetupTestConfig creates a test configuration with default values.
 // Environment variables can override defaults:
 //   - TEST_DATABASE_URL: PostgreSQL connection string
@@ -46,6 +69,23 @@ func SetupTestConfig() (*config.Config, error) {
 		BloomN:            1000000,
 		BloomP:            0.001,
 		Domain:            "http://localhost:8080",
+
+		APIKeys: []string{"tester:" + testAPIKey},
+
+		URLBlockPrivateAddresses: true,
+
+		AliasMinLength: 3,
+		AliasMaxLength: 64,
+		AliasCharset:   "a-zA-Z0-9_-",
+
+		BatchMaxSize:     100,
+		BatchConcurrency: 8,
+
+		JobWorkers:        1,
+		JobPollInterval:   100 * time.Millisecond,
+		JobLease:          30 * time.Second,
+		JobMaxAttempts:    3,
+		JobMaxUploadBytes: 1024 * 1024,
 	}
 
 	return cfg, nil
@@ -74,34 +114,116 @@ func SetupTestRouter(cfg *config.Config) *gin.Engine {
 	urlCache := cache.NewURLCache(redisCache)
 	rateLimitCache := cache.NewRateLimitCache(redisCache)
 
+	if err := os.WriteFile(testBlocklistFile, []byte("blocked.example\n"), 0o600); err != nil {
+		panic(fmt.Sprintf("Failed to write blocklist: %v", err))
+	}
+	if err := testBlocklist.Reload(); err != nil {
+		panic(fmt.Sprintf("Failed to load blocklist: %v", err))
+	}
+
 	shortenerRepo := shortenerStore.NewRepository(writerPool)
 	shortenerDAO := shortenerStore.NewDAO(readerPool)
 	var eventPublisher events.Publisher
 
+	aliasValidator, err := shortenerApp.NewAliasValidator(shortenerApp.AliasPolicy{
+		MinLength: cfg.AliasMinLength,
+		MaxLength: cfg.AliasMaxLength,
+		Charset:   cfg.AliasCharset,
+	}, wordlist.New([]string{"badword"}))
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create alias validator: %v", err))
+	}
+
+	codeGenerator, err := shortenerApp.NewCodeGenerator(shortenerApp.CodeGeneratorConfig{
+		Strategy:   cfg.CodeStrategy,
+		Length:     cfg.ShortCodeLength,
+		RangeSize:  int64(cfg.CodeRangeSize),
+		NodeID:     int64(cfg.SnowflakeNodeID),
+		HashKey:    cfg.CodeHashKey,
+		CounterKey: cfg.CodeCounterKey,
+	}, shortenerRepo)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create code generator: %v", err))
+	}
+
 	shortenerService := shortenerApp.NewService(
 		shortenerRepo,
 		shortenerDAO,
 		urlCache,
 		cfg.BloomN,
 		cfg.BloomP,
-		cfg.ShortCodeLength,
+		codeGenerator,
 		cfg.Domain,
 		eventPublisher,
+		shortenerApp.NewURLValidator(cfg.Domain, shortenerApp.URLPolicy{
+			BlockPrivateAddresses: cfg.URLBlockPrivateAddresses,
+			BlockNonDefaultPorts:  cfg.URLBlockNonDefaultPorts,
+			ResolveHosts:          cfg.URLResolveHosts,
+		}, testBlocklist),
+		aliasValidator,
+		shortenerApp.NewURLNormalizer(shortenerApp.NormalizationPolicy{
+			StripTrackingParams: cfg.URLStripTrackingParams,
+			TrackingParams:      cfg.URLTrackingParams,
+			SortQuery:           cfg.URLSortQuery,
+		}),
+		shortenerApp.BatchOptions{
+			MaxSize:     cfg.BatchMaxSize,
+			Concurrency: cfg.BatchConcurrency,
+		},
 	)
 
+	jobsRepo := jobsStore.NewRepository(writerPool)
+	jobWorkers := jobsApp.NewWorkerPool(jobsRepo, shortenerService, jobsApp.WorkerOptions{
+		Workers:      cfg.JobWorkers,
+		PollInterval: cfg.JobPollInterval,
+		Lease:        cfg.JobLease,
+		MaxAttempts:  cfg.JobMaxAttempts,
+		BatchSize:    cfg.BatchMaxSize,
+	})
+	jobWorkers.Start(ctx)
+	jobsService := jobsApp.NewService(jobsRepo, jobsStore.NewDAO(readerPool), jobWorkers)
+
 	analyticsRepo := analyticsStore.NewRepository(writerPool)
 	analyticsDAO := analyticsStore.NewDAO(readerPool)
-	analyticsService := analyticsApp.NewService(analyticsRepo, analyticsDAO)
+	analyticsService := analyticsApp.NewService(
+		analyticsRepo, analyticsDAO, nil, nil, analyticsApp.IPModeFull,
+		cache.NewVisitorCache(redisCache, 30*24*time.Hour), analyticsApp.BotOptions{},
+		analyticsApp.LiveOptions{Feed: cache.NewLiveCache(redisCache), SummaryInterval: time.Second},
+	)
+
+	campaignsService := campaignsApp.NewService(
+		campaignsStore.NewRepository(writerPool),
+		campaignsStore.NewDAO(readerPool),
+		analyticsService,
+	)
 
 	limiter := rate.NewLimiter(rateLimitCache, cfg.RateLimitMax, cfg.RateLimitWindow)
+	acl := access.NewList(access.NewStaticSource(cfg.IPAllowlist, cfg.IPDenylist))
+	if err := acl.Refresh(ctx); err != nil {
+		panic(fmt.Sprintf("Failed to load IP access rules: %v", err))
+	}
+
+	resolver, err := clientip.NewResolver(cfg.TrustedProxies, cfg.ClientIPHeaders)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create client IP resolver: %v", err))
+	}
+
+	apiKeys, err := apikey.Parse(cfg.APIKeys)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to parse API keys: %v", err))
+	}
 
 	router := gin.New()
 	router.Use(middleware.Recovery())
+	router.Use(middleware.ResolveClientIP(resolver))
 	router.Use(middleware.Logger())
 
-	shortenerTransport.SetupRouter(router, shortenerService, limiter)
-	analyticsTransport.SetupRouter(router, analyticsService, limiter)
+	shortenerTransport.SetupRouter(router, shortenerService, limiter, acl, apiKeys)
+	analyticsTransport.SetupRouter(router, analyticsService, limiter, acl, apiKeys)
+	jobsTransport.SetupRouter(router, jobsService, cfg.JobMaxUploadBytes, limiter, acl, apiKeys)
+	campaignsTransport.SetupRouter(router, campaignsService, limiter, acl)
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	aliasValidator.Reserve(internalHTTP.RouteSegments(router)...)
 
 	return router
 }